@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fkmcps/constants"
 	"fkmcps/middlewares"
 	"fkmcps/tools/doc"
 	"fkmcps/tools/fetch"
@@ -26,9 +27,9 @@ type toolInfo struct {
 
 // availableTools is the registry of all tool groups.
 var availableTools = []toolInfo{
-	{Name: "doc", Description: "Document Tools (get_document_info, read_document_smart, read_document_by_page, read_document_by_line)", Register: doc.GetTools},
+	{Name: "doc", Description: "Document Tools (get_document_info, read_document_smart, read_document_by_page, read_document_by_line, get_document_outline, read_document_section, diff_documents, convert_document, list_document_images, get_document_image, list_documents, write_document and edit_document with --doc-writable)", Register: doc.GetTools},
 	{Name: "fetch", Description: "Web Fetch Tools (fetch)", Register: fetch.GetTools},
-	{Name: "search", Description: "Web Search Tools (search)", Register: search.GetTools},
+	{Name: "search", Description: "Web Search Tools (search, search_news, search_images, search_videos, search_suggest, instant_answer, search_and_read)", Register: search.GetTools},
 }
 
 // allToolNames returns a slice of all available tool names.
@@ -65,6 +66,108 @@ func newServerCommand() *cli.Command {
 				Name:  "tools",
 				Usage: "Comma-separated list of tools to enable (e.g. doc,fetch,search). Defaults to all.",
 			},
+			&cli.IntFlag{
+				Name:  "doc-cache-size",
+				Value: doc.DefaultCacheMemoryBudget / (1024 * 1024),
+				Usage: "Memory budget in MB for parsed documents (0 disables the cache)",
+			},
+			&cli.StringFlag{
+				Name:  "doc-cache-dir",
+				Usage: "Directory for persisting parsed documents across restarts (disabled when empty)",
+			},
+			&cli.StringFlag{
+				Name:  "doc-output-dir",
+				Usage: "Directory document tools may write converted files into (inline output only when empty)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "doc-root",
+				Usage: "Directory list_documents may browse; repeat for several (defaults to the working directory)",
+			},
+			&cli.BoolFlag{
+				Name:  "doc-writable",
+				Value: false,
+				Usage: "Enable the write_document and edit_document tools (requires --doc-output-dir)",
+			},
+			&cli.BoolFlag{
+				Name:  "fetch-allow-private",
+				Value: false,
+				Usage: "Let fetch reach private, loopback, link-local and cloud metadata addresses (trusted deployments only)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "fetch-allow-host",
+				Usage: "Host fetch may request, including its subdomains; repeat for several (all public hosts when unset)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "fetch-deny-host",
+				Usage: "Host or CIDR range fetch must never request, including subdomains; repeat for several",
+			},
+			&cli.IntSliceFlag{
+				Name:  "fetch-allowed-ports",
+				Value: fetch.DefaultAllowedPorts,
+				Usage: "Ports fetch may connect to",
+			},
+			&cli.StringSliceFlag{
+				Name:  "fetch-allowed-methods",
+				Value: fetch.DefaultAllowedMethods,
+				Usage: "HTTP methods fetch may send",
+			},
+			&cli.StringSliceFlag{
+				Name:  "fetch-allowed-headers",
+				Usage: "Request header names fetch callers may set; repeat for several (any except hop-by-hop and proxy headers when unset)",
+			},
+			&cli.StringFlag{
+				Name:  "search-engine",
+				Value: search.EngineDuckDuckGo,
+				Usage: "Default search engine: an engine name, a comma-separated list, or all for a merged metasearch",
+			},
+			&cli.StringFlag{
+				Name:    "searxng-url",
+				Usage:   "Base URL of a SearxNG instance with JSON output enabled (enables the searxng engine)",
+				Sources: cli.EnvVars(constants.SEARXNG_URL),
+			},
+			&cli.StringFlag{
+				Name:    "bing-api-key",
+				Usage:   "Bing Web Search API key (enables the bing engine)",
+				Sources: cli.EnvVars(constants.BING_API_KEY),
+			},
+			&cli.StringFlag{
+				Name:    "brave-api-key",
+				Usage:   "Brave Search API key (enables the brave engine)",
+				Sources: cli.EnvVars(constants.BRAVE_API_KEY),
+			},
+			&cli.StringFlag{
+				Name:    "google-api-key",
+				Usage:   "Google Custom Search API key; with --google-cx enables the google engine",
+				Sources: cli.EnvVars(constants.GOOGLE_API_KEY),
+			},
+			&cli.StringFlag{
+				Name:    "google-cx",
+				Usage:   "Google Programmable Search Engine ID",
+				Sources: cli.EnvVars(constants.GOOGLE_CX),
+			},
+			&cli.StringFlag{
+				Name:  "search-engines-file",
+				Usage: "JSON file defining additional search engines backed by JSON APIs",
+			},
+			&cli.IntFlag{
+				Name:  "search-max-results",
+				Value: search.DefaultMaxResultsLimit,
+				Usage: "Upper bound on the max_results a search call may request",
+			},
+			&cli.DurationFlag{
+				Name:  "search-cache-ttl",
+				Value: search.DefaultCacheTTL,
+				Usage: "How long search results are reused for identical queries (0 disables the cache)",
+			},
+			&cli.IntFlag{
+				Name:  "search-cache-size",
+				Value: search.DefaultCacheSize,
+				Usage: "Maximum number of cached search responses (0 disables the cache)",
+			},
+			&cli.StringFlag{
+				Name:  "search-cache-dir",
+				Usage: "Directory for persisting cached search responses across restarts (disabled when empty)",
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			host := cmd.String("host")
@@ -85,6 +188,48 @@ func newServerCommand() *cli.Command {
 				selectedTools = allToolNames()
 			}
 
+			if cmd.Bool("doc-writable") && cmd.String("doc-output-dir") == "" {
+				return fmt.Errorf("--doc-writable requires --doc-output-dir")
+			}
+
+			doc.Configure(&doc.Config{
+				CacheMemoryBudget: int64(cmd.Int("doc-cache-size")) * 1024 * 1024,
+				CacheDir:          cmd.String("doc-cache-dir"),
+				OutputDir:         cmd.String("doc-output-dir"),
+				Roots:             cmd.StringSlice("doc-root"),
+				Writable:          cmd.Bool("doc-writable"),
+			})
+
+			fetch.Configure(&fetch.Config{
+				AllowPrivate:   cmd.Bool("fetch-allow-private"),
+				AllowHosts:     cmd.StringSlice("fetch-allow-host"),
+				DenyHosts:      cmd.StringSlice("fetch-deny-host"),
+				AllowedPorts:   cmd.IntSlice("fetch-allowed-ports"),
+				AllowedMethods: cmd.StringSlice("fetch-allowed-methods"),
+				AllowedHeaders: cmd.StringSlice("fetch-allowed-headers"),
+			})
+
+			searchOptions := &search.Options{
+				DefaultEngine: cmd.String("search-engine"),
+				SearxNGURL:    cmd.String("searxng-url"),
+				BingAPIKey:    cmd.String("bing-api-key"),
+				BraveAPIKey:   cmd.String("brave-api-key"),
+				GoogleAPIKey:  cmd.String("google-api-key"),
+				GoogleCX:      cmd.String("google-cx"),
+				MaxResults:    cmd.Int("search-max-results"),
+				CacheTTL:      cmd.Duration("search-cache-ttl"),
+				CacheSize:     cmd.Int("search-cache-size"),
+				CacheDir:      cmd.String("search-cache-dir"),
+			}
+			if path := cmd.String("search-engines-file"); path != "" {
+				engines, err := search.LoadJSONEngines(path)
+				if err != nil {
+					return err
+				}
+				searchOptions.JSONEngines = engines
+			}
+			search.Configure(searchOptions)
+
 			return runServer(addr, selectedTools)
 		},
 	}
@@ -1,3 +1,12 @@
 package constants
 
 const MCP_PROXY_URL = "FEIKONG_PROXY_URL"
+
+// Search engine credentials, read from the environment so keys stay out of command lines
+const (
+	SEARXNG_URL    = "FEIKONG_SEARXNG_URL"
+	BING_API_KEY   = "FEIKONG_BING_API_KEY"
+	BRAVE_API_KEY  = "FEIKONG_BRAVE_API_KEY"
+	GOOGLE_API_KEY = "FEIKONG_GOOGLE_API_KEY"
+	GOOGLE_CX      = "FEIKONG_GOOGLE_CX"
+)
@@ -2,15 +2,54 @@ package structs
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 type ToolFunc[I any, O any] func(ctx context.Context, input I) (output O, err error)
 
+// ContentProvider Implemented by tool outputs that carry extra content blocks (e.g. images)
+// returned alongside the JSON text of the output
+type ContentProvider interface {
+	ToolContent() []mcp.Content
+}
+
+// sessionIDKey Context key of the MCP session ID
+type sessionIDKey struct{}
+
+// SessionID MCP session ID of the tool call in ctx; empty outside a session or for stdio
+func SessionID(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey{}).(string)
+	return id
+}
+
 func WarpToolFunc[I any, O any](toolFunc ToolFunc[I, O]) mcp.ToolHandlerFor[I, O] {
-	return func(ctx context.Context, _ *mcp.CallToolRequest, input I) (_ *mcp.CallToolResult, output O, _ error) {
+	return func(ctx context.Context, req *mcp.CallToolRequest, input I) (_ *mcp.CallToolResult, output O, _ error) {
+		if req != nil && req.Session != nil {
+			ctx = context.WithValue(ctx, sessionIDKey{}, req.Session.ID())
+		}
+
 		result, err := toolFunc(ctx, input)
-		return nil, result, err
+		if err != nil {
+			return nil, result, err
+		}
+
+		provider, ok := any(result).(ContentProvider)
+		if !ok {
+			return nil, result, nil
+		}
+		extra := provider.ToolContent()
+		if len(extra) == 0 {
+			return nil, result, nil
+		}
+
+		// Setting Content replaces the default JSON text block, so add it back first
+		text, err := json.Marshal(result)
+		if err != nil {
+			return nil, result, err
+		}
+		content := append([]mcp.Content{&mcp.TextContent{Text: string(text)}}, extra...)
+		return &mcp.CallToolResult{Content: content}, result, nil
 	}
 }
@@ -0,0 +1,84 @@
+package doc
+
+import "github.com/wsshow/docreader"
+
+// PageLineRange Where a page's lines sit in whole-document line numbering
+type PageLineRange struct {
+	PageNumber int    `json:"page_number" jsonschema:"description:Page number"`
+	PageName   string `json:"page_name,omitempty" jsonschema:"description:Page name (e.g., sheet name)"`
+	FirstLine  int    `json:"first_line" jsonschema:"description:Global line number of the page's first line"`
+	LineCount  int    `json:"line_count" jsonschema:"description:Number of lines in this page"`
+}
+
+// LineSegment Part of a global line range that falls on one page
+type LineSegment struct {
+	PageNumber      int    `json:"page_number" jsonschema:"description:Page number"`
+	PageName        string `json:"page_name,omitempty" jsonschema:"description:Page name (e.g., sheet name)"`
+	StartLine       int    `json:"start_line" jsonschema:"description:First line read, within the page"`
+	EndLine         int    `json:"end_line" jsonschema:"description:Last line read, within the page (inclusive)"`
+	GlobalStartLine int    `json:"global_start_line" jsonschema:"description:First line read, across the whole document"`
+	GlobalEndLine   int    `json:"global_end_line" jsonschema:"description:Last line read, across the whole document (inclusive)"`
+}
+
+// indexBase First page/line number under the requested convention
+func indexBase(oneBased bool) int {
+	if oneBased {
+		return 1
+	}
+	return 0
+}
+
+// pageLineRanges First global line and line count of every page (0-based)
+func pageLineRanges(result *docreader.DocumentResult) []PageLineRange {
+	ranges := make([]PageLineRange, len(result.Pages))
+	line := 0
+	for i, page := range result.Pages {
+		ranges[i] = PageLineRange{
+			PageNumber: page.PageNumber,
+			PageName:   page.PageName,
+			FirstLine:  line,
+			LineCount:  len(page.Lines),
+		}
+		line += len(page.Lines)
+	}
+	return ranges
+}
+
+// globalLineConfig Read configuration selecting global lines start..end (0-based, inclusive),
+// along with the page segments the range covers
+func globalLineConfig(result *docreader.DocumentResult, start, end int) (*docreader.ReadConfig, []LineSegment) {
+	config := docreader.NewReadConfig()
+	var segments []LineSegment
+
+	for _, r := range pageLineRanges(result) {
+		last := r.FirstLine + r.LineCount - 1
+		if r.LineCount == 0 || last < start || r.FirstLine > end {
+			continue
+		}
+		from := max(start, r.FirstLine) - r.FirstLine
+		to := min(end, last) - r.FirstLine
+		config.AddPageLineRange(r.PageNumber, from, to)
+		segments = append(segments, LineSegment{
+			PageNumber:      r.PageNumber,
+			PageName:        r.PageName,
+			StartLine:       from,
+			EndLine:         to,
+			GlobalStartLine: r.FirstLine + from,
+			GlobalEndLine:   r.FirstLine + to,
+		})
+	}
+
+	return config, segments
+}
+
+// shiftSegments Convert 0-based segments to the requested convention
+func shiftSegments(segments []LineSegment, base int) []LineSegment {
+	for i := range segments {
+		segments[i].PageNumber += base
+		segments[i].StartLine += base
+		segments[i].EndLine += base
+		segments[i].GlobalStartLine += base
+		segments[i].GlobalEndLine += base
+	}
+	return segments
+}
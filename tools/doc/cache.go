@@ -0,0 +1,262 @@
+package doc
+
+import (
+	"container/list"
+	"encoding/gob"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/wsshow/docreader"
+)
+
+// DefaultCacheMemoryBudget Default memory budget for parsed documents (256MB)
+const DefaultCacheMemoryBudget = 256 * 1024 * 1024
+
+// parsedCache LRU of parsed documents keyed by path, modification time, size and read kind,
+// with optional gob persistence to disk
+type parsedCache struct {
+	mu      sync.Mutex
+	budget  int64
+	dir     string
+	used    int64
+	order   *list.List
+	entries map[string]*list.Element
+	hits    int64
+	misses  int64
+}
+
+// parsedEntry A cached value with its estimated memory size
+type parsedEntry struct {
+	key   string
+	value any
+	size  int64
+}
+
+func newParsedCache(budget int64, dir string) *parsedCache {
+	return &parsedCache{
+		budget:  budget,
+		dir:     dir,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// result Get the full page/line parse of a document
+func (c *parsedCache) result(filePath string, load func(string) (*docreader.DocumentResult, error)) (*docreader.DocumentResult, error) {
+	return cachedLoad(c, filePath, "pages", func() (*docreader.DocumentResult, error) {
+		return load(filePath)
+	}, resultSize)
+}
+
+// document Get the full text of a document, raw or cleaned
+func (c *parsedCache) document(filePath string, clean bool, load func(string, bool) (*docreader.Document, error)) (*docreader.Document, error) {
+	kind := "text"
+	if clean {
+		kind = "text-clean"
+	}
+	return cachedLoad(c, filePath, kind, func() (*docreader.Document, error) {
+		return load(filePath, clean)
+	}, documentSize)
+}
+
+// cachedLoad Look up a value in memory, then on disk, and load it on a miss
+func cachedLoad[T any](c *parsedCache, filePath, kind string, load func() (T, error), size func(T) int64) (T, error) {
+	var zero T
+
+	if c.budget <= 0 {
+		return load()
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		// Let the loader report a consistent "file not found" error
+		return load()
+	}
+
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		absPath = filePath
+	}
+	key := fmt.Sprintf("%s|%d|%d|%s", absPath, info.ModTime().UnixNano(), info.Size(), kind)
+
+	if value, ok := c.get(key); ok {
+		c.count(true)
+		c.logStats("HIT", filePath)
+		return value.(T), nil
+	}
+
+	if value, ok := loadPersisted[T](c.dir, key); ok {
+		c.put(key, value, size(value))
+		c.count(true)
+		c.logStats("DISK HIT", filePath)
+		return value, nil
+	}
+
+	// Only a value found in neither memory nor on disk is a miss
+	c.count(false)
+	value, err := load()
+	if err != nil {
+		return zero, err
+	}
+
+	c.put(key, value, size(value))
+	c.persist(key, value)
+	c.logStats("MISS", filePath)
+
+	return value, nil
+}
+
+// get Return a cached value and mark it as recently used
+func (c *parsedCache) get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*parsedEntry).value, true
+}
+
+// count Record a lookup served from memory or disk (hit) or by parsing the document (miss)
+func (c *parsedCache) count(hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if hit {
+		c.hits++
+	} else {
+		c.misses++
+	}
+}
+
+// put Store a value, evicting least recently used entries to stay within the budget
+func (c *parsedCache) put(key string, value any, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if size > c.budget {
+		return
+	}
+
+	if element, ok := c.entries[key]; ok {
+		c.used -= element.Value.(*parsedEntry).size
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+
+	for c.used+size > c.budget && c.order.Len() > 0 {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*parsedEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.used -= entry.size
+	}
+
+	c.entries[key] = c.order.PushFront(&parsedEntry{key: key, value: value, size: size})
+	c.used += size
+}
+
+// logStats Log a cache lookup together with the running statistics
+func (c *parsedCache) logStats(event, filePath string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	log.Printf("[DOC CACHE] %s | Path: %s | Hits: %d | Misses: %d | Entries: %d | Memory: %s / %s",
+		event,
+		filePath,
+		c.hits,
+		c.misses,
+		c.order.Len(),
+		formatFileSize(c.used),
+		formatFileSize(c.budget))
+}
+
+// persist Write a value to the on-disk cache, if enabled
+func (c *parsedCache) persist(key string, value any) {
+	if c.dir == "" {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		log.Printf("[DOC CACHE] failed to create cache directory: %v", err)
+		return
+	}
+
+	target := persistedPath(c.dir, key)
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		log.Printf("[DOC CACHE] failed to persist entry: %v", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	err = gob.NewEncoder(tmp).Encode(value)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		log.Printf("[DOC CACHE] failed to persist entry: %v", err)
+		return
+	}
+
+	// The disk cache shares the memory budget as its size limit; only its own entries are
+	// trimmed, as the directory may be shared
+	trimCache(c.dir, "*.gob", target, c.budget)
+}
+
+// loadPersisted Read a value from the on-disk cache, if enabled and present
+func loadPersisted[T any](dir, key string) (T, bool) {
+	var value T
+	if dir == "" {
+		return value, false
+	}
+
+	f, err := os.Open(persistedPath(dir, key))
+	if err != nil {
+		return value, false
+	}
+	defer f.Close()
+
+	if err := gob.NewDecoder(f).Decode(&value); err != nil {
+		return value, false
+	}
+	return value, true
+}
+
+// persistedPath On-disk cache file for a key
+func persistedPath(dir, key string) string {
+	return filepath.Join(dir, cacheKey(key)+".gob")
+}
+
+// resultSize Estimate the memory used by a parsed document
+func resultSize(result *docreader.DocumentResult) int64 {
+	size := int64(len(result.Content)) + metadataSize(result.Metadata)
+	for _, page := range result.Pages {
+		size += int64(len(page.PageName))
+		for _, line := range page.Lines {
+			size += int64(len(line)) + 16 // string header
+		}
+	}
+	return size
+}
+
+// documentSize Estimate the memory used by a full-text document
+func documentSize(doc *docreader.Document) int64 {
+	return int64(len(doc.Content)) + metadataSize(doc.Metadata)
+}
+
+// metadataSize Estimate the memory used by a metadata map
+func metadataSize(metadata map[string]string) int64 {
+	var size int64
+	for key, value := range metadata {
+		size += int64(len(key) + len(value))
+	}
+	return size
+}
@@ -0,0 +1,46 @@
+package doc
+
+// Config Document tool settings
+type Config struct {
+	// CacheMemoryBudget is the maximum memory in bytes used by parsed documents.
+	// 0 disables the parsed-document cache.
+	CacheMemoryBudget int64
+
+	// CacheDir is the directory where parsed documents are persisted across restarts.
+	// Optional. Empty disables on-disk persistence.
+	CacheDir string
+
+	// OutputDir is the only directory document tools may write files into.
+	// Optional. Empty means converted documents are only returned inline.
+	OutputDir string
+
+	// Roots are the directories list_documents may browse.
+	// Optional. Empty means the current working directory.
+	Roots []string
+
+	// Writable enables the write_document and edit_document tools, which create and modify files in OutputDir
+	Writable bool
+}
+
+// documentCache Parsed documents shared by all doc tools
+var documentCache = newParsedCache(DefaultCacheMemoryBudget, "")
+
+// outputDir Directory document tools write into, empty when writing is not allowed
+var outputDir string
+
+// documentRoots Directories list_documents may browse
+var documentRoots []string
+
+// writable Whether the document creation and editing tools are registered
+var writable bool
+
+// Configure Apply document tool settings, replacing the parsed-document cache
+func Configure(config *Config) {
+	if config == nil {
+		config = &Config{CacheMemoryBudget: DefaultCacheMemoryBudget}
+	}
+	documentCache = newParsedCache(config.CacheMemoryBudget, config.CacheDir)
+	outputDir = config.OutputDir
+	documentRoots = config.Roots
+	writable = config.Writable
+}
@@ -0,0 +1,319 @@
+package doc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/wsshow/docreader"
+)
+
+// sheetFormats Extensions whose pages are sheets of "Row N: a | b" lines
+var sheetFormats = map[string]bool{
+	".xlsx": true,
+	".csv":  true,
+	".ods":  true,
+}
+
+// convertExtensions Default output file extension for each conversion format
+var convertExtensions = map[string]string{
+	"markdown": ".md",
+	"text":     ".txt",
+	"json":     ".json",
+	"csv":      ".csv",
+}
+
+// sheetRowPattern Row prefix written by the sheet readers
+var sheetRowPattern = regexp.MustCompile(`^Row \d+: `)
+
+// ConvertDocumentRequest Document conversion request
+type ConvertDocumentRequest struct {
+	FilePath   string `json:"file_path" jsonschema:"required,description:Document file path"`
+	Format     string `json:"format" jsonschema:"required,description:Target format: markdown, text, json (pages/lines/metadata) or csv (spreadsheets only)"`
+	StartPage  int    `json:"start_page,omitempty" jsonschema:"description:Starting page number (0-based, default 0)"`
+	EndPage    int    `json:"end_page,omitempty" jsonschema:"description:Ending page number (inclusive, 0 or -1 means to end, default -1)"`
+	OutputPath string `json:"output_path,omitempty" jsonschema:"description:File path inside the server output directory to write to (requires --doc-writable); the content is returned inline when empty"`
+	Overwrite  bool   `json:"overwrite,omitempty" jsonschema:"description:Replace output_path if it already exists (default false)"`
+	MaxChars   int    `json:"max_chars,omitempty" jsonschema:"description:Maximum character limit for inline content (default 50000)"`
+}
+
+// ConvertDocumentResponse Document conversion response
+type ConvertDocumentResponse struct {
+	Format       string   `json:"format" jsonschema:"description:Target format"`
+	Content      string   `json:"content,omitempty" jsonschema:"description:Converted content (when returned inline)"`
+	OutputFiles  []string `json:"output_files,omitempty" jsonschema:"description:Written files (csv writes one file per sheet when several sheets are converted)"`
+	Pages        int      `json:"pages" jsonschema:"description:Number of pages or sheets converted"`
+	IsTruncated  bool     `json:"is_truncated" jsonschema:"description:Whether inline content is truncated"`
+	OriginalSize int      `json:"original_size" jsonschema:"description:Converted content size (character count)"`
+	ReturnedSize int      `json:"returned_size" jsonschema:"description:Returned content size (character count)"`
+	ErrorMessage string   `json:"error_message,omitempty" jsonschema:"description:Error message"`
+	Suggestion   string   `json:"suggestion,omitempty" jsonschema:"description:Suggestion (how to get the full content)"`
+}
+
+// convertedJSON JSON conversion output
+type convertedJSON struct {
+	FilePath   string            `json:"file_path"`
+	TotalPages int               `json:"total_pages"`
+	Metadata   map[string]string `json:"metadata"`
+	Pages      []convertedPage   `json:"pages"`
+}
+
+// convertedPage One page in the JSON conversion output
+type convertedPage struct {
+	PageNumber int      `json:"page_number"`
+	PageName   string   `json:"page_name,omitempty"`
+	Lines      []string `json:"lines"`
+}
+
+// ConvertDocument Convert a document to markdown, text, json or csv
+func ConvertDocument(ctx context.Context, req *ConvertDocumentRequest) (*ConvertDocumentResponse, error) {
+	format := strings.ToLower(req.Format)
+	if _, ok := convertExtensions[format]; !ok {
+		return &ConvertDocumentResponse{ErrorMessage: "format must be one of: markdown, text, json, csv"}, nil
+	}
+	// Writing files is gated like write_document and edit_document
+	if req.OutputPath != "" && !writable {
+		return &ConvertDocumentResponse{ErrorMessage: "output_path requires the server to be started with --doc-writable; omit it to return the content inline"}, nil
+	}
+
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &ConvertDocumentResponse{ErrorMessage: fmt.Sprintf("Failed to access document: %v", err)}, nil
+	}
+
+	isSheet := sheetFormats[strings.ToLower(filepath.Ext(filePath))]
+	if format == "csv" && !isSheet {
+		return &ConvertDocumentResponse{ErrorMessage: "csv conversion is only supported for spreadsheets (XLSX, ODS, CSV)"}, nil
+	}
+
+	var config *docreader.ReadConfig
+	if req.StartPage > 0 || req.EndPage > 0 {
+		endPage := req.EndPage
+		if endPage <= 0 {
+			endPage = 999999 // Read to the end
+		}
+		config = docreader.NewReadConfig().WithPageRange(req.StartPage, endPage)
+	}
+
+	result, err := readDocumentWithConfig(filePath, config)
+	if err != nil {
+		return &ConvertDocumentResponse{ErrorMessage: fmt.Sprintf("Failed to read document: %v", err)}, nil
+	}
+
+	response := &ConvertDocumentResponse{
+		Format: format,
+		Pages:  len(result.Pages),
+	}
+
+	// Each output is a file name suffix (for per-sheet csv files) and its content
+	type output struct {
+		suffix  string
+		content string
+	}
+	var outputs []output
+
+	switch format {
+	case "markdown":
+		outputs = append(outputs, output{content: convertToMarkdown(result, isSheet)})
+	case "text":
+		outputs = append(outputs, output{content: result.Content})
+	case "json":
+		content, err := convertToJSON(req.FilePath, result)
+		if err != nil {
+			return &ConvertDocumentResponse{ErrorMessage: fmt.Sprintf("Failed to convert document: %v", err)}, nil
+		}
+		outputs = append(outputs, output{content: content})
+	case "csv":
+		for _, page := range result.Pages {
+			content, err := sheetToCSV(page.Lines)
+			if err != nil {
+				return &ConvertDocumentResponse{ErrorMessage: fmt.Sprintf("Failed to convert document: %v", err)}, nil
+			}
+			suffix := ""
+			if len(result.Pages) > 1 {
+				suffix = "-" + sheetFileName(page)
+			}
+			outputs = append(outputs, output{suffix: suffix, content: content})
+		}
+	}
+
+	if req.OutputPath != "" {
+		ext := filepath.Ext(req.OutputPath)
+		base := strings.TrimSuffix(req.OutputPath, ext)
+		if ext == "" {
+			ext = convertExtensions[format]
+		}
+		for _, out := range outputs {
+			written, err := writeOutputFile(base+out.suffix+ext, []byte(out.content), req.Overwrite)
+			if err != nil {
+				response.ErrorMessage = fmt.Sprintf("Failed to write output: %v", err)
+				return response, nil
+			}
+			response.OutputFiles = append(response.OutputFiles, written)
+			response.OriginalSize += len(out.content)
+		}
+		return response, nil
+	}
+
+	var contents []string
+	for _, out := range outputs {
+		if len(outputs) > 1 {
+			contents = append(contents, fmt.Sprintf("# Sheet: %s\n%s", strings.TrimPrefix(out.suffix, "-"), out.content))
+		} else {
+			contents = append(contents, out.content)
+		}
+	}
+	content := strings.Join(contents, "\n")
+
+	maxChars := req.MaxChars
+	if maxChars <= 0 {
+		maxChars = 50000
+	}
+
+	response.OriginalSize = len(content)
+	if len(content) > maxChars {
+		content = truncateRunes(content, maxChars)
+		response.IsTruncated = true
+		response.Suggestion = fmt.Sprintf("Converted content is large (%d characters), only returning the first %d. Use output_path to write the full result, or convert a smaller page range", response.OriginalSize, maxChars)
+	}
+	response.Content = content
+	response.ReturnedSize = len(content)
+
+	return response, nil
+}
+
+// convertToMarkdown Render pages as markdown; sheets become tables
+func convertToMarkdown(result *docreader.DocumentResult, isSheet bool) string {
+	var builder strings.Builder
+
+	// Sources such as Markdown and HTML already start with their title heading
+	if title := result.Metadata["title"]; title != "" && !startsWithHeading(result) {
+		builder.WriteString("# " + title + "\n\n")
+	}
+
+	for _, page := range result.Pages {
+		if result.TotalPages > 1 {
+			if page.PageName != "" {
+				builder.WriteString(fmt.Sprintf("## %s\n\n", page.PageName))
+			} else {
+				builder.WriteString(fmt.Sprintf("## Page %d\n\n", page.PageNumber+1))
+			}
+		}
+
+		if isSheet {
+			builder.WriteString(sheetToMarkdownTable(page.Lines))
+		} else {
+			builder.WriteString(strings.Join(page.Lines, "\n"))
+		}
+		builder.WriteString("\n\n")
+	}
+
+	return strings.TrimRight(builder.String(), "\n") + "\n"
+}
+
+// startsWithHeading Report whether the first non-empty line is a markdown heading
+func startsWithHeading(result *docreader.DocumentResult) bool {
+	for _, page := range result.Pages {
+		for _, line := range page.Lines {
+			if strings.TrimSpace(line) != "" {
+				return strings.HasPrefix(line, "#")
+			}
+		}
+	}
+	return false
+}
+
+// convertToJSON Render the pages/lines model as indented JSON
+func convertToJSON(filePath string, result *docreader.DocumentResult) (string, error) {
+	out := convertedJSON{
+		FilePath:   filePath,
+		TotalPages: result.TotalPages,
+		Metadata:   result.Metadata,
+		Pages:      make([]convertedPage, len(result.Pages)),
+	}
+	for i, page := range result.Pages {
+		lines := page.Lines
+		if lines == nil {
+			lines = []string{}
+		}
+		out.Pages[i] = convertedPage{PageNumber: page.PageNumber, PageName: page.PageName, Lines: lines}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+// sheetCells Split a "Row N: a | b" sheet line into cells
+func sheetCells(line string) []string {
+	return strings.Split(sheetRowPattern.ReplaceAllString(line, ""), " | ")
+}
+
+// sheetToCSV Render sheet lines as CSV
+func sheetToCSV(lines []string) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for _, line := range lines {
+		if err := writer.Write(sheetCells(line)); err != nil {
+			return "", err
+		}
+	}
+	writer.Flush()
+	return buf.String(), writer.Error()
+}
+
+// sheetToMarkdownTable Render sheet lines as a markdown table, using the first row as the header
+func sheetToMarkdownTable(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+
+	rows := make([][]string, len(lines))
+	columns := 0
+	for i, line := range lines {
+		rows[i] = sheetCells(line)
+		columns = max(columns, len(rows[i]))
+	}
+
+	var builder strings.Builder
+	writeRow := func(cells []string) {
+		builder.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(cells) {
+				cell = strings.ReplaceAll(cells[i], "|", `\|`)
+			}
+			builder.WriteString(" " + cell + " |")
+		}
+		builder.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	builder.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+
+	return strings.TrimSuffix(builder.String(), "\n")
+}
+
+// sheetFileName File name suffix for a sheet
+func sheetFileName(page docreader.PageContent) string {
+	name := page.PageName
+	if name == "" {
+		name = fmt.Sprintf("sheet%d", page.PageNumber+1)
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>| `, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
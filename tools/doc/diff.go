@@ -0,0 +1,635 @@
+package doc
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wsshow/docreader"
+)
+
+const (
+	// maxDiffEditDistance Upper bound on edits explored by the diff; beyond it the remaining text is reported as one change
+	maxDiffEditDistance = 2000
+	// sideBySideWidth Maximum width of the left column in side-by-side output
+	sideBySideWidth = 80
+)
+
+// DiffDocumentsRequest Document comparison request
+type DiffDocumentsRequest struct {
+	OldFilePath      string `json:"old_file_path" jsonschema:"required,description:Path of the original document (any supported format)"`
+	NewFilePath      string `json:"new_file_path" jsonschema:"required,description:Path of the revised document (any supported format)"`
+	Format           string `json:"format,omitempty" jsonschema:"description:Output format: unified (default) or side_by_side"`
+	Granularity      string `json:"granularity,omitempty" jsonschema:"description:Diff granularity: line (default) or word. word marks changes inside changed lines as [-removed-]{+added+}"`
+	ContextLines     *int   `json:"context_lines,omitempty" jsonschema:"description:Number of unchanged context lines around each change (default 3, 0 shows only the changed lines)"`
+	IgnoreWhitespace bool   `json:"ignore_whitespace,omitempty" jsonschema:"description:Ignore differences in spacing when comparing lines (default false)"`
+	MaxChars         int    `json:"max_chars,omitempty" jsonschema:"description:Maximum character limit of the diff output (default 50000)"`
+}
+
+// DiffDocumentsResponse Document comparison response
+type DiffDocumentsResponse struct {
+	Diff         string        `json:"diff" jsonschema:"description:Diff output in the requested format"`
+	Summary      DiffSummary   `json:"summary" jsonschema:"description:Summary of the differences"`
+	Sections     []DiffSection `json:"sections,omitempty" jsonschema:"description:Changed sections with page attribution"`
+	IsTruncated  bool          `json:"is_truncated" jsonschema:"description:Whether the diff output is truncated"`
+	OriginalSize int           `json:"original_size" jsonschema:"description:Full diff size (character count)"`
+	ReturnedSize int           `json:"returned_size" jsonschema:"description:Returned diff size (character count)"`
+	ErrorMessage string        `json:"error_message,omitempty" jsonschema:"description:Error message"`
+	Suggestion   string        `json:"suggestion,omitempty" jsonschema:"description:Suggestion (how to see the rest of the diff)"`
+}
+
+// DiffSummary Counts of differences between two documents
+type DiffSummary struct {
+	Identical       bool `json:"identical" jsonschema:"description:Whether the documents have identical text"`
+	AddedLines      int  `json:"added_lines" jsonschema:"description:Number of lines only in the new document"`
+	RemovedLines    int  `json:"removed_lines" jsonschema:"description:Number of lines only in the old document"`
+	UnchangedLines  int  `json:"unchanged_lines" jsonschema:"description:Number of lines in both documents"`
+	AddedSections   int  `json:"added_sections" jsonschema:"description:Number of sections that only add lines"`
+	RemovedSections int  `json:"removed_sections" jsonschema:"description:Number of sections that only remove lines"`
+	ChangedSections int  `json:"changed_sections" jsonschema:"description:Number of sections that replace lines"`
+}
+
+// DiffSection A contiguous block of changes
+type DiffSection struct {
+	Type         string `json:"type" jsonschema:"description:Section type (added, removed, changed)"`
+	OldPage      int    `json:"old_page" jsonschema:"description:Page in the old document where the section starts (0-based, -1 if none)"`
+	OldLine      int    `json:"old_line" jsonschema:"description:Line within old_page where the section starts (0-based, -1 if none)"`
+	OldLineCount int    `json:"old_line_count" jsonschema:"description:Number of old lines in the section"`
+	NewPage      int    `json:"new_page" jsonschema:"description:Page in the new document where the section starts (0-based, -1 if none)"`
+	NewLine      int    `json:"new_line" jsonschema:"description:Line within new_page where the section starts (0-based, -1 if none)"`
+	NewLineCount int    `json:"new_line_count" jsonschema:"description:Number of new lines in the section"`
+	Heading      string `json:"heading,omitempty" jsonschema:"description:Nearest preceding heading in the new document, when an outline is available"`
+}
+
+// documentLine A line of a document with its page coordinates
+type documentLine struct {
+	page int
+	line int
+	text string
+}
+
+// diffOp Kind of a diff operation
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+// diffEdit One step of an edit script; oldIndex/newIndex point into the compared sequences
+type diffEdit struct {
+	op       diffOp
+	oldIndex int
+	newIndex int
+}
+
+// diffHunk A group of edits with surrounding context
+type diffHunk struct {
+	edits   []diffEdit
+	section DiffSection
+}
+
+// DiffDocuments Compare the text of two documents
+func DiffDocuments(ctx context.Context, req *DiffDocumentsRequest) (*DiffDocumentsResponse, error) {
+	if req.OldFilePath == "" || req.NewFilePath == "" {
+		return &DiffDocumentsResponse{ErrorMessage: "Both old_file_path and new_file_path are required"}, nil
+	}
+
+	format := strings.ToLower(req.Format)
+	if format == "" {
+		format = "unified"
+	}
+	if format != "unified" && format != "side_by_side" {
+		return &DiffDocumentsResponse{ErrorMessage: "format must be one of: unified, side_by_side"}, nil
+	}
+
+	granularity := strings.ToLower(req.Granularity)
+	if granularity == "" {
+		granularity = "line"
+	}
+	if granularity != "line" && granularity != "word" {
+		return &DiffDocumentsResponse{ErrorMessage: "granularity must be one of: line, word"}, nil
+	}
+
+	// Absent means the default; 0 is a valid request for no context
+	contextLines := 3
+	if req.ContextLines != nil && *req.ContextLines >= 0 {
+		contextLines = *req.ContextLines
+	}
+
+	maxChars := req.MaxChars
+	if maxChars <= 0 {
+		maxChars = 50000
+	}
+
+	oldLines, _, err := loadDocumentLines(ctx, req.OldFilePath)
+	if err != nil {
+		return &DiffDocumentsResponse{ErrorMessage: fmt.Sprintf("Failed to read old document: %v", err)}, nil
+	}
+	newLines, newOutline, err := loadDocumentLines(ctx, req.NewFilePath)
+	if err != nil {
+		return &DiffDocumentsResponse{ErrorMessage: fmt.Sprintf("Failed to read new document: %v", err)}, nil
+	}
+
+	normalize := func(text string) string { return text }
+	if req.IgnoreWhitespace {
+		normalize = func(text string) string { return strings.Join(strings.Fields(text), " ") }
+	}
+	oldKeys := make([]string, len(oldLines))
+	for i, line := range oldLines {
+		oldKeys[i] = normalize(line.text)
+	}
+	newKeys := make([]string, len(newLines))
+	for i, line := range newLines {
+		newKeys[i] = normalize(line.text)
+	}
+
+	edits := diffSequences(oldKeys, newKeys)
+	hunks := groupHunks(edits, contextLines, oldLines, newLines, newOutline)
+
+	response := &DiffDocumentsResponse{}
+	for _, edit := range edits {
+		switch edit.op {
+		case diffEqual:
+			response.Summary.UnchangedLines++
+		case diffDelete:
+			response.Summary.RemovedLines++
+		case diffInsert:
+			response.Summary.AddedLines++
+		}
+	}
+	response.Summary.Identical = len(hunks) == 0
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", req.OldFilePath, req.NewFilePath))
+	for _, hunk := range hunks {
+		switch hunk.section.Type {
+		case "added":
+			response.Summary.AddedSections++
+		case "removed":
+			response.Summary.RemovedSections++
+		default:
+			response.Summary.ChangedSections++
+		}
+		response.Sections = append(response.Sections, hunk.section)
+
+		if format == "side_by_side" {
+			writeSideBySideHunk(&builder, hunk, oldLines, newLines, granularity)
+		} else {
+			writeUnifiedHunk(&builder, hunk, oldLines, newLines, granularity)
+		}
+	}
+
+	diff := builder.String()
+	if response.Summary.Identical {
+		diff = ""
+	}
+	response.OriginalSize = len(diff)
+
+	if len(diff) > maxChars {
+		// Cut at a line boundary so markers stay intact, else on a character boundary
+		cut := strings.LastIndex(diff[:maxChars], "\n")
+		if cut <= 0 {
+			cut = len(truncateRunes(diff, maxChars))
+		}
+		diff = diff[:cut]
+		response.IsTruncated = true
+		response.Suggestion = fmt.Sprintf("Diff is large (%d characters), only returning the first %d. Use the sections list with read_document_by_line to inspect the remaining changes, or compare smaller page ranges", response.OriginalSize, len(diff))
+	}
+
+	response.Diff = diff
+	response.ReturnedSize = len(diff)
+
+	return response, nil
+}
+
+// loadDocumentLines Flatten a document into lines with page coordinates, along with its outline when available
+func loadDocumentLines(ctx context.Context, filePath string) ([]documentLine, []OutlineNode, error) {
+	localPath, err := resolveDocumentPath(ctx, filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result, err := readDocumentWithConfig(localPath, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return flattenLines(result), outlineOrNil(localPath, result), nil
+}
+
+// flattenLines List all lines of a parsed document in order
+func flattenLines(result *docreader.DocumentResult) []documentLine {
+	var lines []documentLine
+	for _, page := range result.Pages {
+		for i, text := range page.Lines {
+			lines = append(lines, documentLine{page: page.PageNumber, line: i, text: text})
+		}
+	}
+	return lines
+}
+
+// outlineOrNil Best-effort outline, used only to label diff sections
+func outlineOrNil(filePath string, result *docreader.DocumentResult) []OutlineNode {
+	nodes, err := buildOutline(filePath, result)
+	if err != nil {
+		return nil
+	}
+	return nodes
+}
+
+// diffSequences Compute an edit script turning a into b (Myers' algorithm).
+// Common prefix and suffix are matched first; if the remaining edit distance exceeds
+// maxDiffEditDistance, the remainder is reported as a single replacement.
+func diffSequences(a, b []string) []diffEdit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]diffEdit, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, diffEdit{op: diffEqual, oldIndex: i, newIndex: i})
+	}
+
+	middle := myersDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	for _, edit := range middle {
+		edit.oldIndex += prefix
+		edit.newIndex += prefix
+		edits = append(edits, edit)
+	}
+
+	for i := 0; i < suffix; i++ {
+		edits = append(edits, diffEdit{op: diffEqual, oldIndex: len(a) - suffix + i, newIndex: len(b) - suffix + i})
+	}
+
+	return edits
+}
+
+// myersDiff Greedy Myers diff returning the shortest edit script
+func myersDiff(a, b []string) []diffEdit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(n, m)
+	}
+
+	maxD := min(n+m, maxDiffEditDistance)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		return replaceAll(n, m)
+	}
+
+	// Backtrack through the saved frontiers
+	var reversed []diffEdit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		frontier := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && frontier[offset+k-1] < frontier[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := frontier[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, diffEdit{op: diffEqual, oldIndex: x, newIndex: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				y--
+				reversed = append(reversed, diffEdit{op: diffInsert, oldIndex: x, newIndex: y})
+			} else {
+				x--
+				reversed = append(reversed, diffEdit{op: diffDelete, oldIndex: x, newIndex: y})
+			}
+		}
+	}
+
+	edits := make([]diffEdit, len(reversed))
+	for i, edit := range reversed {
+		edits[len(reversed)-1-i] = edit
+	}
+	return edits
+}
+
+// replaceAll Edit script deleting all of a and inserting all of b
+func replaceAll(n, m int) []diffEdit {
+	edits := make([]diffEdit, 0, n+m)
+	for i := 0; i < n; i++ {
+		edits = append(edits, diffEdit{op: diffDelete, oldIndex: i, newIndex: 0})
+	}
+	for j := 0; j < m; j++ {
+		edits = append(edits, diffEdit{op: diffInsert, oldIndex: n, newIndex: j})
+	}
+	return edits
+}
+
+// groupHunks Group edits into hunks with up to contextLines unchanged lines around each change
+func groupHunks(edits []diffEdit, contextLines int, oldLines, newLines []documentLine, outline []OutlineNode) []diffHunk {
+	var hunks []diffHunk
+
+	i := 0
+	for i < len(edits) {
+		if edits[i].op == diffEqual {
+			i++
+			continue
+		}
+
+		start := max(i-contextLines, 0)
+		if len(hunks) > 0 {
+			// Never overlap the previous hunk
+			last := hunks[len(hunks)-1]
+			lastEnd := last.edits[len(last.edits)-1]
+			for start < i && edits[start].oldIndex <= lastEnd.oldIndex && edits[start].newIndex <= lastEnd.newIndex && edits[start].op == diffEqual {
+				start++
+			}
+		}
+
+		// Extend while changes are separated by at most 2*contextLines equal lines
+		end := i
+		for end < len(edits) {
+			if edits[end].op != diffEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].op == diffEqual {
+				run++
+			}
+			if run < len(edits) && run-end <= 2*contextLines {
+				end = run
+				continue
+			}
+			end = min(end+contextLines, len(edits))
+			break
+		}
+
+		hunkEdits := edits[start:end]
+		hunks = append(hunks, diffHunk{
+			edits:   hunkEdits,
+			section: describeSection(edits[i:end], oldLines, newLines, outline),
+		})
+		i = end
+	}
+
+	return hunks
+}
+
+// describeSection Classify the changes in a hunk and attribute them to pages
+func describeSection(edits []diffEdit, oldLines, newLines []documentLine, outline []OutlineNode) DiffSection {
+	section := DiffSection{OldPage: -1, OldLine: -1, NewPage: -1, NewLine: -1}
+
+	for _, edit := range edits {
+		switch edit.op {
+		case diffDelete:
+			if section.OldLineCount == 0 {
+				section.OldPage, section.OldLine = oldLines[edit.oldIndex].page, oldLines[edit.oldIndex].line
+			}
+			section.OldLineCount++
+		case diffInsert:
+			if section.NewLineCount == 0 {
+				section.NewPage, section.NewLine = newLines[edit.newIndex].page, newLines[edit.newIndex].line
+			}
+			section.NewLineCount++
+		}
+	}
+
+	switch {
+	case section.OldLineCount == 0:
+		section.Type = "added"
+	case section.NewLineCount == 0:
+		section.Type = "removed"
+	default:
+		section.Type = "changed"
+	}
+
+	// Label the section with the closest heading at or before its position in the new document
+	anchorPage, anchorLine := section.NewPage, section.NewLine
+	if anchorPage < 0 && len(edits) > 0 && edits[0].newIndex < len(newLines) {
+		anchorPage, anchorLine = newLines[edits[0].newIndex].page, newLines[edits[0].newIndex].line
+	}
+	for _, node := range outline {
+		if node.PageNumber < 0 || positionBefore(anchorPage, anchorLine, node.PageNumber, node.LineNumber) {
+			continue
+		}
+		section.Heading = node.Title
+	}
+
+	return section
+}
+
+// writeUnifiedHunk Write a hunk in unified diff format
+func writeUnifiedHunk(builder *strings.Builder, hunk diffHunk, oldLines, newLines []documentLine, granularity string) {
+	writeHunkHeader(builder, hunk, oldLines, newLines)
+
+	for i := 0; i < len(hunk.edits); {
+		edit := hunk.edits[i]
+		if edit.op == diffEqual {
+			builder.WriteString(" " + oldLines[edit.oldIndex].text + "\n")
+			i++
+			continue
+		}
+
+		// Collect the run of deletions and insertions
+		var removed, added []string
+		for i < len(hunk.edits) && hunk.edits[i].op != diffEqual {
+			if hunk.edits[i].op == diffDelete {
+				removed = append(removed, oldLines[hunk.edits[i].oldIndex].text)
+			} else {
+				added = append(added, newLines[hunk.edits[i].newIndex].text)
+			}
+			i++
+		}
+
+		if granularity == "word" && len(removed) > 0 && len(added) > 0 {
+			// Pair lines up and show inline word changes
+			pairs := min(len(removed), len(added))
+			for j := 0; j < pairs; j++ {
+				builder.WriteString("~" + wordDiff(removed[j], added[j]) + "\n")
+			}
+			for _, text := range removed[pairs:] {
+				builder.WriteString("-" + text + "\n")
+			}
+			for _, text := range added[pairs:] {
+				builder.WriteString("+" + text + "\n")
+			}
+			continue
+		}
+
+		for _, text := range removed {
+			builder.WriteString("-" + text + "\n")
+		}
+		for _, text := range added {
+			builder.WriteString("+" + text + "\n")
+		}
+	}
+}
+
+// writeSideBySideHunk Write a hunk as two columns: old | new
+func writeSideBySideHunk(builder *strings.Builder, hunk diffHunk, oldLines, newLines []documentLine, granularity string) {
+	writeHunkHeader(builder, hunk, oldLines, newLines)
+
+	type row struct {
+		left, right string
+		marker      string
+	}
+	var rows []row
+
+	for i := 0; i < len(hunk.edits); {
+		edit := hunk.edits[i]
+		if edit.op == diffEqual {
+			text := oldLines[edit.oldIndex].text
+			rows = append(rows, row{left: text, right: newLines[edit.newIndex].text, marker: " "})
+			i++
+			continue
+		}
+
+		var removed, added []string
+		for i < len(hunk.edits) && hunk.edits[i].op != diffEqual {
+			if hunk.edits[i].op == diffDelete {
+				removed = append(removed, oldLines[hunk.edits[i].oldIndex].text)
+			} else {
+				added = append(added, newLines[hunk.edits[i].newIndex].text)
+			}
+			i++
+		}
+
+		for j := 0; j < max(len(removed), len(added)); j++ {
+			switch {
+			case j < len(removed) && j < len(added):
+				right := added[j]
+				if granularity == "word" {
+					right = wordDiff(removed[j], added[j])
+				}
+				rows = append(rows, row{left: removed[j], right: right, marker: "|"})
+			case j < len(removed):
+				rows = append(rows, row{left: removed[j], marker: "<"})
+			default:
+				rows = append(rows, row{right: added[j], marker: ">"})
+			}
+		}
+	}
+
+	width := 0
+	for _, r := range rows {
+		width = max(width, utf8.RuneCountInString(r.left))
+	}
+	width = min(width, sideBySideWidth)
+
+	for _, r := range rows {
+		padding := max(width-utf8.RuneCountInString(r.left), 0)
+		builder.WriteString(r.left + strings.Repeat(" ", padding) + " " + r.marker + " " + r.right + "\n")
+	}
+}
+
+// writeHunkHeader Write an @@ header with global line ranges and page coordinates
+func writeHunkHeader(builder *strings.Builder, hunk diffHunk, oldLines, newLines []documentLine) {
+	oldStart, oldCount, newStart, newCount := -1, 0, -1, 0
+	for _, edit := range hunk.edits {
+		if edit.op != diffInsert {
+			if oldStart < 0 {
+				oldStart = edit.oldIndex
+			}
+			oldCount++
+		}
+		if edit.op != diffDelete {
+			if newStart < 0 {
+				newStart = edit.newIndex
+			}
+			newCount++
+		}
+	}
+
+	position := func(lines []documentLine, index int) string {
+		if index < 0 || index >= len(lines) {
+			return "end"
+		}
+		return fmt.Sprintf("page %d line %d", lines[index].page, lines[index].line)
+	}
+
+	builder.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@ old: %s, new: %s",
+		oldStart+1, oldCount, newStart+1, newCount,
+		position(oldLines, oldStart), position(newLines, newStart)))
+	if hunk.section.Heading != "" {
+		builder.WriteString(" | " + hunk.section.Heading)
+	}
+	builder.WriteString("\n")
+}
+
+var wordTokenPattern = regexp.MustCompile(`\s+|[\p{L}\p{N}_]+|[^\s\p{L}\p{N}_]`)
+
+// wordDiff Render a line-level change as inline word changes: [-removed-]{+added+}
+func wordDiff(oldText, newText string) string {
+	oldTokens := wordTokenPattern.FindAllString(oldText, -1)
+	newTokens := wordTokenPattern.FindAllString(newText, -1)
+
+	var builder strings.Builder
+	var removed, added strings.Builder
+	flush := func() {
+		if removed.Len() > 0 {
+			builder.WriteString("[-" + removed.String() + "-]")
+			removed.Reset()
+		}
+		if added.Len() > 0 {
+			builder.WriteString("{+" + added.String() + "+}")
+			added.Reset()
+		}
+	}
+
+	for _, edit := range diffSequences(oldTokens, newTokens) {
+		switch edit.op {
+		case diffEqual:
+			flush()
+			builder.WriteString(oldTokens[edit.oldIndex])
+		case diffDelete:
+			removed.WriteString(oldTokens[edit.oldIndex])
+		case diffInsert:
+			added.WriteString(newTokens[edit.newIndex])
+		}
+	}
+	flush()
+
+	return builder.String()
+}
@@ -0,0 +1,1321 @@
+package doc
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fkmcps/tools/fetch"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/wsshow/docreader"
+)
+
+func TestApplyReadConfig(t *testing.T) {
+	newResult := func() *docreader.DocumentResult {
+		return &docreader.DocumentResult{
+			Pages: []docreader.PageContent{
+				{PageNumber: 0, Lines: []string{"a0", "a1", "a2"}},
+				{PageNumber: 1, Lines: []string{"b0", "b1"}},
+				{PageNumber: 2, Lines: []string{"c0"}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		config    *docreader.ReadConfig
+		wantPages []int
+		wantLines int
+	}{
+		{
+			name:      "nil config reads everything",
+			config:    nil,
+			wantPages: []int{0, 1, 2},
+			wantLines: 6,
+		},
+		{
+			name:      "page range",
+			config:    docreader.NewReadConfig().WithPageRange(1, 999999),
+			wantPages: []int{1, 2},
+			wantLines: 3,
+		},
+		{
+			name:      "line range on one page",
+			config:    docreader.NewReadConfig().AddPageLineRange(0, 1, 999999),
+			wantPages: []int{0},
+			wantLines: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := applyReadConfig(newResult(), tt.config)
+
+			if len(result.Pages) != len(tt.wantPages) {
+				t.Fatalf("Pages = %d, want %d", len(result.Pages), len(tt.wantPages))
+			}
+			for i, page := range result.Pages {
+				if page.PageNumber != tt.wantPages[i] {
+					t.Errorf("Page %d number = %d, want %d", i, page.PageNumber, tt.wantPages[i])
+				}
+			}
+			if result.TotalLines != tt.wantLines {
+				t.Errorf("TotalLines = %d, want %d", result.TotalLines, tt.wantLines)
+			}
+			if result.TotalPages != 3 {
+				t.Errorf("TotalPages = %d, want 3", result.TotalPages)
+			}
+		})
+	}
+}
+
+func TestRegisteredFormatReaders(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name         string
+		fileName     string
+		content      string
+		wantPages    int
+		wantContains string
+	}{
+		{
+			name:         "html as markdown",
+			fileName:     "page.html",
+			content:      "<html><head><title>T</title></head><body><h1>Title</h1><p>Body</p></body></html>",
+			wantPages:    1,
+			wantContains: "# Title",
+		},
+		{
+			name:         "json is indented",
+			fileName:     "data.json",
+			content:      `{"a":1}`,
+			wantPages:    1,
+			wantContains: "\"a\": 1",
+		},
+		{
+			name:         "mbox splits messages",
+			fileName:     "mail.mbox",
+			content:      "From a@b Mon Jan  1 00:00:00 2024\nSubject: one\n\nfirst\n\nFrom c@d Mon Jan  1 00:00:00 2024\nSubject: two\n\nsecond\n",
+			wantPages:    2,
+			wantContains: "Subject: two",
+		},
+		{
+			name:         "source code",
+			fileName:     "main.go",
+			content:      "package main\n\nfunc main() {}\n",
+			wantPages:    1,
+			wantContains: "func main() {}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.fileName)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := readDocumentWithConfig(path, nil)
+			if err != nil {
+				t.Fatalf("readDocumentWithConfig() error = %v", err)
+			}
+
+			if result.TotalPages != tt.wantPages {
+				t.Errorf("TotalPages = %d, want %d", result.TotalPages, tt.wantPages)
+			}
+			if !strings.Contains(result.Content, tt.wantContains) {
+				t.Errorf("Content = %q, want to contain %q", result.Content, tt.wantContains)
+			}
+		})
+	}
+}
+
+func TestParsedCache(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("one\ntwo\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loads := 0
+	load := func(filePath string) (*docreader.DocumentResult, error) {
+		loads++
+		return parseDocument(filePath)
+	}
+
+	cache := newParsedCache(1024*1024, filepath.Join(dir, "cache"))
+	for range 3 {
+		if _, err := cache.result(path, load); err != nil {
+			t.Fatalf("result() error = %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1 (later reads should hit the cache)", loads)
+	}
+	if cache.hits != 2 || cache.misses != 1 {
+		t.Errorf("hits/misses = %d/%d, want 2/1", cache.hits, cache.misses)
+	}
+
+	// A fresh cache with the same directory is served from disk
+	persisted := newParsedCache(1024*1024, filepath.Join(dir, "cache"))
+	result, err := persisted.result(path, load)
+	if err != nil {
+		t.Fatalf("result() error = %v", err)
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1 (persisted entry should be reused)", loads)
+	}
+	if persisted.hits != 1 || persisted.misses != 0 {
+		t.Errorf("disk hit counted as hits/misses = %d/%d, want 1/0", persisted.hits, persisted.misses)
+	}
+	if len(result.Pages) != 1 || result.Pages[0].Lines[1] != "two" {
+		t.Errorf("persisted result pages = %+v", result.Pages)
+	}
+
+	// Modifying the file changes the key
+	if err := os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.result(path, load); err != nil {
+		t.Fatalf("result() error = %v", err)
+	}
+	if loads != 2 {
+		t.Errorf("loads = %d, want 2 after modification", loads)
+	}
+
+	// Trimming a shared cache directory removes only cache entries
+	shared := filepath.Join(dir, "shared")
+	if err := os.MkdirAll(shared, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	foreign := filepath.Join(shared, "operator-notes.txt")
+	if err := os.WriteFile(foreign, bytes.Repeat([]byte("x"), 4096), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(foreign, old, old)
+	small := newParsedCache(64, shared)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		other := filepath.Join(dir, name)
+		if err := os.WriteFile(other, []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := small.result(other, load); err != nil {
+			t.Fatalf("result() error = %v", err)
+		}
+	}
+	if _, err := os.Stat(foreign); err != nil {
+		t.Errorf("foreign file removed by the cache trim: %v", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(shared, "*.gob")); len(entries) != 1 {
+		t.Errorf("persisted entries = %v, want only the newest", entries)
+	}
+}
+
+func TestBuiltinFormatContent(t *testing.T) {
+	outputDir = t.TempDir()
+	defer func() { outputDir = "" }()
+
+	xlsx, _ := WriteDocument(t.Context(), &WriteDocumentRequest{OutputPath: "book.xlsx", Sheets: []SheetData{
+		{Name: "Fruit", Rows: [][]string{{"item", "price"}, {"apple", "1.5"}, {"pear", "2"}}},
+		{Name: "Veg", Rows: [][]string{{"leek"}, {"kale"}}},
+	}})
+	if xlsx.ErrorMessage != "" {
+		t.Fatalf("WriteDocument() error = %s", xlsx.ErrorMessage)
+	}
+	pdfPath := filepath.Join(outputDir, "pages.pdf")
+	if err := os.WriteFile(pdfPath, testTextPDF([]string{"first line", "second line"}, []string{"third line"}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Cached reads render the same content as reading through docreader directly
+	configs := map[string]*docreader.ReadConfig{
+		"whole":      nil,
+		"page range": docreader.NewReadConfig().WithPageRange(1, 1),
+		"lines":      docreader.NewReadConfig().WithPages(0).WithLineRange(1, 1),
+	}
+	for _, path := range []string{xlsx.OutputPath, pdfPath} {
+		for name, config := range configs {
+			want, err := docreader.ReadDocumentWithConfig(path, config)
+			if err != nil {
+				t.Fatalf("docreader %s %s: %v", filepath.Ext(path), name, err)
+			}
+			got, err := readDocumentWithConfig(path, config)
+			if err != nil {
+				t.Fatalf("readDocumentWithConfig(%s, %s) error = %v", filepath.Ext(path), name, err)
+			}
+			if got.Content != want.Content {
+				t.Errorf("%s %s content = %q, want %q", filepath.Ext(path), name, got.Content, want.Content)
+			}
+		}
+	}
+}
+
+func TestDocxHeadings(t *testing.T) {
+	tests := []struct {
+		name      string
+		paragraph string
+		wantLevel int
+	}{
+		{"heading style", `<w:pPr><w:pStyle w:val="Heading2"/></w:pPr>`, 2},
+		{"spaced style name", `<w:pPr><w:pStyle w:val="heading 3"/></w:pPr>`, 3},
+		{"title style", `<w:pPr><w:pStyle w:val="Title"/></w:pPr>`, 1},
+		{"outline level", `<w:pPr><w:pStyle w:val="Custom"/><w:outlineLvl w:val="1"/></w:pPr>`, 2},
+		{"body outline level", `<w:pPr><w:outlineLvl w:val="9"/></w:pPr>`, 0},
+		{"normal", `<w:pPr><w:pStyle w:val="Normal"/></w:pPr>`, 0},
+	}
+
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An empty paragraph produces no line, so the tested paragraph is line 1
+			document := `<w:document xmlns:w="w"><w:body>` +
+				`<w:p><w:r><w:t>Intro</w:t></w:r></w:p><w:p></w:p>` +
+				`<w:p>` + tt.paragraph + `<w:r><w:t> Tested </w:t></w:r></w:p></w:body></w:document>`
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "-")+".docx")
+			var buf bytes.Buffer
+			archive := zip.NewWriter(&buf)
+			w, _ := archive.Create("word/document.xml")
+			w.Write([]byte(document))
+			archive.Close()
+			if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			headings, err := docxHeadings(path)
+			if err != nil {
+				t.Fatalf("docxHeadings() error = %v", err)
+			}
+			if tt.wantLevel == 0 {
+				if len(headings) != 0 {
+					t.Errorf("headings = %+v, want none", headings)
+				}
+				return
+			}
+			want := outlineHeading{title: "Tested", level: tt.wantLevel, line: 1, source: "style"}
+			if len(headings) != 1 || headings[0] != want {
+				t.Errorf("headings = %+v, want [%+v]", headings, want)
+			}
+		})
+	}
+}
+
+func TestNumberHeadings(t *testing.T) {
+	tests := []struct {
+		name   string
+		levels []int
+		want   []string
+	}{
+		{"nested", []int{1, 2, 2, 1, 2}, []string{"1", "1.1", "1.2", "2", "2.1"}},
+		{"skipped level", []int{1, 3, 3, 2}, []string{"1", "1.1", "1.2", "1.3"}},
+		{"deep then shallow", []int{1, 2, 3, 1}, []string{"1", "1.1", "1.1.1", "2"}},
+		{"starts below top", []int{2, 2, 1}, []string{"1", "2", "3"}},
+		{"level zero", []int{0, 1}, []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headings := make([]outlineHeading, len(tt.levels))
+			for i, level := range tt.levels {
+				headings[i] = outlineHeading{title: fmt.Sprintf("h%d", i), level: level}
+			}
+			nodes := numberHeadings(headings)
+			ids := make([]string, len(nodes))
+			for i, node := range nodes {
+				ids[i] = node.ID
+			}
+			if strings.Join(ids, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("IDs = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadDocumentSection(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "guide.md")
+	markdown := "# Intro\nintro text\n## Detail\ndetail text\n### Deep\ndeep text\n## More\nmore text\n# Ünïcode\nlast text"
+	if err := os.WriteFile(path, []byte(markdown), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		req           ReadDocumentSectionRequest
+		want          string
+		wantTruncated bool
+	}{
+		{"ends before next same level", ReadDocumentSectionRequest{Title: "detail"}, "## Detail\ndetail text\n### Deep\ndeep text", false},
+		{"ends before higher level", ReadDocumentSectionRequest{NodeID: "1.1.1"}, "### Deep\ndeep text", false},
+		{"includes subsections", ReadDocumentSectionRequest{NodeID: "1"}, "# Intro\nintro text\n## Detail\ndetail text\n### Deep\ndeep text\n## More\nmore text", false},
+		{"last section runs to the end", ReadDocumentSectionRequest{NodeID: "2"}, "# Ünïcode\nlast text", false},
+		{"truncated inside a character", ReadDocumentSectionRequest{NodeID: "2", MaxChars: 3}, "# ", true},
+		{"truncated after a character", ReadDocumentSectionRequest{NodeID: "2", MaxChars: 4}, "# Ü", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.FilePath = path
+			got, _ := ReadDocumentSection(t.Context(), &req)
+			if got.ErrorMessage != "" {
+				t.Fatalf("ErrorMessage = %s", got.ErrorMessage)
+			}
+			if got.Content != tt.want || got.IsTruncated != tt.wantTruncated {
+				t.Errorf("Content = %q (truncated %v), want %q (truncated %v)", got.Content, got.IsTruncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestDiffDocuments(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.md")
+	newPath := filepath.Join(dir, "new.md")
+	if err := os.WriteFile(oldPath, []byte("# Terms\n\nThe fee is 100 dollars.\nPayment is due monthly.\n\n# Notes\n\nObsolete clause.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(newPath, []byte("# Terms\n\nThe fee is 120 dollars.\nPayment is due monthly.\n\n# Notes\n\nNew clause.\nAnother clause.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	contextLines := 1
+	resp, err := DiffDocuments(t.Context(), &DiffDocumentsRequest{
+		OldFilePath:  oldPath,
+		NewFilePath:  newPath,
+		Granularity:  "word",
+		ContextLines: &contextLines,
+	})
+	if err != nil || resp.ErrorMessage != "" {
+		t.Fatalf("DiffDocuments() error = %v, %s", err, resp.ErrorMessage)
+	}
+
+	if resp.Summary.Identical {
+		t.Error("Summary.Identical = true, want false")
+	}
+	if resp.Summary.RemovedLines != 2 || resp.Summary.AddedLines != 3 {
+		t.Errorf("Summary lines = -%d +%d, want -2 +3", resp.Summary.RemovedLines, resp.Summary.AddedLines)
+	}
+	if !strings.Contains(resp.Diff, "[-100-]{+120+}") {
+		t.Errorf("Diff = %q, want an inline word change", resp.Diff)
+	}
+	if len(resp.Sections) != 2 || resp.Sections[1].Heading != "Notes" {
+		t.Errorf("Sections = %+v, want 2 sections with the second under Notes", resp.Sections)
+	}
+
+	// context_lines 0 shows only changed lines; absent shows the default 3
+	for _, tt := range []struct {
+		contextLines *int
+		wantContext  bool
+	}{
+		{new(int), false},
+		{nil, true},
+	} {
+		got, _ := DiffDocuments(t.Context(), &DiffDocumentsRequest{OldFilePath: oldPath, NewFilePath: newPath, ContextLines: tt.contextLines})
+		if hasContext := strings.Contains(got.Diff, "Payment is due monthly."); got.ErrorMessage != "" || hasContext != tt.wantContext {
+			t.Errorf("context_lines %v: Diff = %q, want context %v", tt.contextLines, got.Diff, tt.wantContext)
+		}
+	}
+
+	// Without a line break before max_chars the cut falls on a character boundary
+	accentedPath := filepath.Join(dir, "éé.md")
+	if err := os.WriteFile(accentedPath, []byte("# Terms\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	header := "--- " + dir + string(filepath.Separator)
+	short, _ := DiffDocuments(t.Context(), &DiffDocumentsRequest{OldFilePath: accentedPath, NewFilePath: newPath, MaxChars: len(header) + 1})
+	if !short.IsTruncated || short.Diff != header {
+		t.Errorf("truncated Diff = %q, want %q", short.Diff, header)
+	}
+
+	same, _ := DiffDocuments(t.Context(), &DiffDocumentsRequest{OldFilePath: oldPath, NewFilePath: oldPath})
+	if !same.Summary.Identical || same.Diff != "" {
+		t.Errorf("identical documents: Summary = %+v, Diff = %q", same.Summary, same.Diff)
+	}
+}
+
+func TestDiffSequences(t *testing.T) {
+	a := strings.Split("a b c a b b a", " ")
+	b := strings.Split("c b a b a c", " ")
+
+	edits := diffSequences(a, b)
+	var gotOld, gotNew []string
+	changes := 0
+	for _, edit := range edits {
+		switch edit.op {
+		case diffEqual:
+			gotOld = append(gotOld, a[edit.oldIndex])
+			gotNew = append(gotNew, b[edit.newIndex])
+		case diffDelete:
+			gotOld = append(gotOld, a[edit.oldIndex])
+			changes++
+		case diffInsert:
+			gotNew = append(gotNew, b[edit.newIndex])
+			changes++
+		}
+	}
+
+	if strings.Join(gotOld, " ") != strings.Join(a, " ") || strings.Join(gotNew, " ") != strings.Join(b, " ") {
+		t.Errorf("edit script does not reproduce inputs: old %v, new %v", gotOld, gotNew)
+	}
+	if changes != 5 {
+		t.Errorf("edit distance = %d, want 5", changes)
+	}
+}
+
+func TestConvertDocument(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prices.csv")
+	if err := os.WriteFile(path, []byte("item,price\napple,1.5\n\"pear, green\",2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	markdown, _ := ConvertDocument(t.Context(), &ConvertDocumentRequest{FilePath: path, Format: "markdown"})
+	if markdown.ErrorMessage != "" {
+		t.Fatalf("markdown ErrorMessage = %s", markdown.ErrorMessage)
+	}
+	if !strings.Contains(markdown.Content, "| item | price |\n| --- | --- |\n| apple | 1.5 |") {
+		t.Errorf("markdown Content = %q, want a table", markdown.Content)
+	}
+
+	// Writing is refused unless the server is writable
+	outputDir = filepath.Join(dir, "out")
+	defer func() { outputDir = "" }()
+	refused, _ := ConvertDocument(t.Context(), &ConvertDocumentRequest{FilePath: path, Format: "csv", OutputPath: "export/prices"})
+	if !strings.Contains(refused.ErrorMessage, "--doc-writable") {
+		t.Errorf("read-only ErrorMessage = %q, want a --doc-writable error", refused.ErrorMessage)
+	}
+	if _, err := os.Stat(outputDir); err == nil {
+		t.Error("read-only conversion created the output directory")
+	}
+
+	// Writing stays inside the output directory
+	writable = true
+	defer func() { writable = false }()
+
+	written, _ := ConvertDocument(t.Context(), &ConvertDocumentRequest{FilePath: path, Format: "csv", OutputPath: "export/prices"})
+	if written.ErrorMessage != "" || len(written.OutputFiles) != 1 {
+		t.Fatalf("csv response = %+v", written)
+	}
+	data, err := os.ReadFile(filepath.Join(outputDir, "export", "prices.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "item,price\napple,1.5\n\"pear, green\",2\n" {
+		t.Errorf("csv output = %q", data)
+	}
+
+	again, _ := ConvertDocument(t.Context(), &ConvertDocumentRequest{FilePath: path, Format: "csv", OutputPath: "export/prices"})
+	if again.ErrorMessage == "" {
+		t.Error("writing an existing file without overwrite should fail")
+	}
+
+	escaped, _ := ConvertDocument(t.Context(), &ConvertDocumentRequest{FilePath: path, Format: "json", OutputPath: "../escape.json"})
+	if escaped.ErrorMessage == "" {
+		t.Error("writing outside the output directory should fail")
+	}
+
+	// Inline content is cut on a character boundary
+	accented := filepath.Join(dir, "accented.txt")
+	if err := os.WriteFile(accented, []byte("ééééé"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cut, _ := ConvertDocument(t.Context(), &ConvertDocumentRequest{FilePath: accented, Format: "text", MaxChars: 5})
+	if cut.ErrorMessage != "" || !cut.IsTruncated || cut.Content != "éé" {
+		t.Errorf("truncated response = %+v, want content %q", cut, "éé")
+	}
+
+	text, _ := ConvertDocument(t.Context(), &ConvertDocumentRequest{FilePath: path, Format: "csv"})
+	if text.ErrorMessage != "" || !strings.HasPrefix(text.Content, "item,price") {
+		t.Errorf("inline csv response = %+v", text)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "escape.json")); err == nil {
+		t.Error("file written outside the output directory")
+	}
+}
+
+func TestWriteAndEditDocument(t *testing.T) {
+	outputDir = t.TempDir()
+	defer func() { outputDir = "" }()
+
+	docx, _ := WriteDocument(t.Context(), &WriteDocumentRequest{
+		OutputPath: "report.docx",
+		Content:    "# Summary\n\nSales grew by **12%** this quarter.\n\n- North region\n- South region\n\n| Region | Sales |\n| --- | --- |\n| North | 10 |\n",
+	})
+	if docx.ErrorMessage != "" {
+		t.Fatalf("docx ErrorMessage = %s", docx.ErrorMessage)
+	}
+	result, err := readDocumentWithConfig(docx.OutputPath, nil)
+	if err != nil {
+		t.Fatalf("reading written docx: %v", err)
+	}
+	for _, want := range []string{"Summary", "Sales grew by 12% this quarter.", "• North region", "North"} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("docx Content = %q, want to contain %q", result.Content, want)
+		}
+	}
+	if headings, err := docxHeadings(docx.OutputPath); err != nil || len(headings) != 1 || headings[0].title != "Summary" {
+		t.Errorf("docx headings = %+v, %v", headings, err)
+	}
+
+	xlsx, _ := WriteDocument(t.Context(), &WriteDocumentRequest{
+		OutputPath: "data/prices.xlsx",
+		Sheets:     []SheetData{{Name: "Fruit", Rows: [][]string{{"item", "price"}, {"apple", "1.5"}}}, {Name: "Veg", Rows: [][]string{{"leek"}}}},
+	})
+	if xlsx.ErrorMessage != "" {
+		t.Fatalf("xlsx ErrorMessage = %s", xlsx.ErrorMessage)
+	}
+	result, err = readDocumentWithConfig(xlsx.OutputPath, nil)
+	if err != nil {
+		t.Fatalf("reading written xlsx: %v", err)
+	}
+	if result.TotalPages != 2 || result.Pages[0].PageName != "Fruit" || !strings.Contains(result.Pages[0].Lines[1], "apple | 1.5") {
+		t.Errorf("xlsx pages = %+v", result.Pages)
+	}
+
+	if _, err := WriteDocument(t.Context(), &WriteDocumentRequest{OutputPath: "notes.md", Content: "one\ntwo\nthree\n"}); err != nil {
+		t.Fatal(err)
+	}
+	edit, _ := EditDocument(t.Context(), &EditDocumentRequest{OutputPath: "notes.md", Mode: "replace_lines", StartLine: 1, Content: "TWO\nTWO and a half"})
+	if edit.ErrorMessage != "" || edit.TotalLines != 4 {
+		t.Fatalf("replace_lines response = %+v", edit)
+	}
+	edit, _ = EditDocument(t.Context(), &EditDocumentRequest{OutputPath: "notes.md", Mode: "append", Content: "four"})
+	if edit.ErrorMessage != "" {
+		t.Fatalf("append ErrorMessage = %s", edit.ErrorMessage)
+	}
+	data, _ := os.ReadFile(filepath.Join(outputDir, "notes.md"))
+	if string(data) != "one\nTWO\nTWO and a half\nthree\nfour\n" {
+		t.Errorf("edited file = %q", data)
+	}
+
+	// Formats whose readers renumber lines are not editable
+	for _, name := range []string{"page.html", "data.json"} {
+		if edit, _ := EditDocument(t.Context(), &EditDocumentRequest{OutputPath: name, Mode: "append", Content: "x"}); !strings.Contains(edit.ErrorMessage, "Only plain-text documents") {
+			t.Errorf("editing %s ErrorMessage = %q", name, edit.ErrorMessage)
+		}
+	}
+	if edit, _ := EditDocument(t.Context(), &EditDocumentRequest{OutputPath: "report.docx", Mode: "append", Content: "x"}); edit.ErrorMessage == "" {
+		t.Error("editing a docx should fail")
+	}
+	if edit, _ := EditDocument(t.Context(), &EditDocumentRequest{OutputPath: "notes.md", Mode: "replace_lines", StartLine: 10}); edit.ErrorMessage == "" {
+		t.Error("replacing lines out of range should fail")
+	}
+}
+
+func TestOutputSandbox(t *testing.T) {
+	outputDir = t.TempDir()
+	defer func() { outputDir = "" }()
+
+	outside := t.TempDir()
+	victim := filepath.Join(outside, "victim.md")
+	if err := os.WriteFile(victim, []byte("original\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(outputDir, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	escape, err := filepath.Rel(outputDir, outside)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		dir  string
+	}{
+		{"parent directory", escape},
+		{"absolute path outside", outside},
+		{"symlink inside pointing outside", "link"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			write, _ := WriteDocument(t.Context(), &WriteDocumentRequest{OutputPath: filepath.Join(tt.dir, "written.md"), Content: "escaped\n"})
+			if write.ErrorMessage == "" {
+				t.Errorf("write_document accepted %s", filepath.Join(tt.dir, "written.md"))
+			}
+			overwrite, _ := WriteDocument(t.Context(), &WriteDocumentRequest{OutputPath: filepath.Join(tt.dir, "victim.md"), Content: "escaped\n", Overwrite: true})
+			if overwrite.ErrorMessage == "" {
+				t.Errorf("write_document overwrote %s", filepath.Join(tt.dir, "victim.md"))
+			}
+			edit, _ := EditDocument(t.Context(), &EditDocumentRequest{OutputPath: filepath.Join(tt.dir, "victim.md"), Mode: "append", Content: "escaped"})
+			if edit.ErrorMessage == "" {
+				t.Errorf("edit_document accepted %s", filepath.Join(tt.dir, "victim.md"))
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(outside, "written.md")); err == nil {
+		t.Error("a file was written outside the output directory")
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "original\n" {
+		t.Errorf("file outside the output directory = %q, want it unchanged", data)
+	}
+}
+
+func TestWritableTools(t *testing.T) {
+	defer func() { writable = false }()
+
+	for _, tt := range []struct {
+		writable bool
+		want     bool
+	}{
+		{false, false},
+		{true, true},
+	} {
+		writable = tt.writable
+		server := mcp.NewServer(&mcp.Implementation{Name: "doc-test", Version: "1.0.0"}, nil)
+		GetTools(server)
+
+		serverTransport, clientTransport := mcp.NewInMemoryTransports()
+		serverSession, err := server.Connect(t.Context(), serverTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		client := mcp.NewClient(&mcp.Implementation{Name: "doc-test-client", Version: "1.0.0"}, nil)
+		session, err := client.Connect(t.Context(), clientTransport, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tools, err := session.ListTools(t.Context(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.Close()
+		serverSession.Wait()
+
+		registered := map[string]bool{}
+		for _, tool := range tools.Tools {
+			registered[tool.Name] = true
+		}
+		for _, name := range []string{"write_document", "edit_document"} {
+			if registered[name] != tt.want {
+				t.Errorf("writable=%v: %s registered = %v, want %v", tt.writable, name, registered[name], tt.want)
+			}
+		}
+		if !registered["read_document_smart"] {
+			t.Errorf("writable=%v: read tools missing", tt.writable)
+		}
+	}
+}
+
+// testPNG Encode a solid-color PNG of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{200, 30, 30, 255}}, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testPDF Build a one-page PDF whose resources reference a single image XObject
+func testPDF(imageDict string, imageData []byte) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Resources << /XObject << /Im1 4 0 R >> >> /Contents 5 0 R >>",
+		fmt.Sprintf("<< /Type /XObject /Subtype /Image %s /Length %d >>\nstream\n%s\nendstream", imageDict, len(imageData), imageData),
+		"<< /Length 0 >>\nstream\n\nendstream",
+	}
+	return pdfFile(objects)
+}
+
+// corruptPDF Build a PDF whose cross-reference entry for the page tree points at a number
+// instead of an object, which makes the PDF library panic when pages are loaded
+func corruptPDF() []byte {
+	data := testTextPDF([]string{"damaged"})
+	xref := bytes.Index(data, []byte("\nxref\n")) + 1
+	lines := bytes.Split(data[xref:], []byte("\n"))
+	lines[4] = []byte(fmt.Sprintf("%010d 00000 n ", xref+5))
+	return append(data[:xref:xref], bytes.Join(lines, []byte("\n"))...)
+}
+
+// pdfFile Assemble numbered objects into a PDF with a cross-reference table; object 1 is the catalog
+func pdfFile(objects []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// testTextPDF Build a PDF with one page per argument, each line of text drawn below the previous one
+func testTextPDF(pages ...[]string) []byte {
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	}
+	for i, lines := range pages {
+		var stream strings.Builder
+		stream.WriteString("BT /F1 12 Tf 20 180 Td")
+		for j, line := range lines {
+			if j > 0 {
+				stream.WriteString(" 0 -20 Td")
+			}
+			fmt.Fprintf(&stream, " (%s) Tj", line)
+		}
+		stream.WriteString(" ET")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 200 200] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", stream.Len(), stream.String()))
+	}
+	return pdfFile(objects)
+}
+
+func TestDocumentImages(t *testing.T) {
+	dir := t.TempDir()
+
+	// DOCX with one image after the first paragraph
+	docxPath := filepath.Join(dir, "figures.docx")
+	var docx bytes.Buffer
+	archive := zip.NewWriter(&docx)
+	parts := map[string][]byte{
+		"word/document.xml": []byte(`<w:document xmlns:w="w" xmlns:r="r"><w:body>` +
+			`<w:p><w:r><w:t>Intro</w:t></w:r></w:p>` +
+			`<w:p><w:r><w:drawing><a:blip r:embed="rId5"/></w:drawing></w:r></w:p>` +
+			`<w:p><w:r><w:t>Caption</w:t></w:r></w:p></w:body></w:document>`),
+		"word/_rels/document.xml.rels": []byte(`<Relationships><Relationship Id="rId5" Target="media/image1.png"/></Relationships>`),
+		"word/media/image1.png":        testPNG(t, 3000, 1000),
+		"word/media/logo.png":          testPNG(t, 10, 10),
+	}
+	for name, data := range parts {
+		w, _ := archive.Create(name)
+		w.Write(data)
+	}
+	archive.Close()
+	if err := os.WriteFile(docxPath, docx.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	list, _ := ListDocumentImages(t.Context(), &ListDocumentImagesRequest{FilePath: docxPath})
+	if list.ErrorMessage != "" || list.TotalImages != 2 {
+		t.Fatalf("docx list = %+v", list)
+	}
+	first := list.Images[0]
+	if first.Name != "word/media/image1.png" || first.PageNumber != 0 || first.LineNumber != 1 || first.Width != 3000 {
+		t.Errorf("first image = %+v, want image1.png before line 1", first)
+	}
+
+	got, _ := GetDocumentImage(t.Context(), &GetDocumentImageRequest{FilePath: docxPath, ImageID: first.ImageID, MaxDimension: 300})
+	if got.ErrorMessage != "" || !got.Downscaled || got.ReturnedWidth != 300 || got.ReturnedHeight != 100 {
+		t.Fatalf("get image = %+v", got)
+	}
+	content := got.ToolContent()
+	if len(content) != 1 {
+		t.Fatalf("ToolContent() = %v", content)
+	}
+	if img, ok := content[0].(*mcp.ImageContent); !ok || img.MIMEType != "image/png" || len(img.Data) != got.ReturnedSize {
+		t.Errorf("image content = %+v", content[0])
+	}
+
+	// PDF with a stored JPEG and one with raw RGB samples
+	var jpegData bytes.Buffer
+	if err := jpeg.Encode(&jpegData, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	jpegPDF := filepath.Join(dir, "photo.pdf")
+	os.WriteFile(jpegPDF, testPDF("/Width 40 /Height 20 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /DCTDecode", jpegData.Bytes()), 0o644)
+
+	rawPDF := filepath.Join(dir, "raw.pdf")
+	os.WriteFile(rawPDF, testPDF("/Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8", []byte{255, 0, 0, 0, 0, 255}), 0o644)
+
+	for _, tt := range []struct {
+		path     string
+		mimeType string
+		width    int
+	}{
+		{jpegPDF, "image/jpeg", 40},
+		{rawPDF, "image/png", 2},
+	} {
+		list, _ := ListDocumentImages(t.Context(), &ListDocumentImagesRequest{FilePath: tt.path})
+		if list.ErrorMessage != "" || list.TotalImages != 1 || list.Images[0].PageNumber != 0 {
+			t.Fatalf("%s list = %+v", tt.path, list)
+		}
+		got, _ := GetDocumentImage(t.Context(), &GetDocumentImageRequest{FilePath: tt.path, ImageID: "img-1"})
+		if got.ErrorMessage != "" || got.MIMEType != tt.mimeType || got.ReturnedWidth != tt.width {
+			t.Errorf("%s get = %+v", tt.path, got)
+		}
+	}
+}
+
+func TestDamagedPDF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.pdf")
+	if err := os.WriteFile(path, corruptPDF(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each tool reports the damage instead of panicking
+	calls := map[string]func() string{
+		"list_document_images": func() string {
+			resp, _ := ListDocumentImages(t.Context(), &ListDocumentImagesRequest{FilePath: path})
+			return resp.ErrorMessage
+		},
+		"get_document_image": func() string {
+			resp, _ := GetDocumentImage(t.Context(), &GetDocumentImageRequest{FilePath: path, ImageID: "img-1"})
+			return resp.ErrorMessage
+		},
+		"get_document_outline": func() string {
+			resp, _ := GetDocumentOutline(t.Context(), &GetDocumentOutlineRequest{FilePath: path})
+			return resp.ErrorMessage
+		},
+		"read_document_section": func() string {
+			resp, _ := ReadDocumentSection(t.Context(), &ReadDocumentSectionRequest{FilePath: path, NodeID: "1"})
+			return resp.ErrorMessage
+		},
+		"read_document_smart": func() string {
+			resp, _ := ReadDocumentSmart(t.Context(), &ReadDocumentSmartRequest{FilePath: path})
+			return resp.ErrorMessage
+		},
+	}
+	for name, call := range calls {
+		if msg := call(); !strings.Contains(msg, "damaged") {
+			t.Errorf("%s ErrorMessage = %q, want a damaged document error", name, msg)
+		}
+	}
+
+	// The outline is guarded on its own too, for documents whose text parses; the catalog is
+	// rewritten at the same length so the damaged object is also its outline
+	outlined := bytes.Replace(corruptPDF(), []byte("<< /Type /Catalog /Pages 2 0 R >>"), []byte("<< /Outlines 2 0 R /Pages 2 0 R>>"), 1)
+	if err := os.WriteFile(path, outlined, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	result := &docreader.DocumentResult{Pages: []docreader.PageContent{{Lines: []string{"damaged"}}}}
+	if _, err := pdfHeadings(path, result); err == nil || !strings.Contains(err.Error(), "damaged PDF") {
+		t.Errorf("pdfHeadings() error = %v, want a damaged PDF error", err)
+	}
+}
+
+func TestImageDecompressionLimits(t *testing.T) {
+	// A PNG whose header claims more pixels than the cap is refused before decoding
+	huge := testPNG(t, 4, 4)
+	binary.BigEndian.PutUint32(huge[16:], 100_000)
+	binary.BigEndian.PutUint32(huge[20:], 100_000)
+	binary.BigEndian.PutUint32(huge[29:], crc32.ChecksumIEEE(huge[12:29]))
+	if _, _, _, _, _, err := fitImage(huge, "image/png", DefaultImageBytes, DefaultImageDimension); err == nil || !strings.Contains(err.Error(), "pixel limit") {
+		t.Errorf("fitImage(100000x100000) error = %v, want pixel limit", err)
+	}
+
+	deflate := func(data []byte) []byte {
+		var buf bytes.Buffer
+		w := zlib.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+		return buf.Bytes()
+	}
+
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		name    string
+		samples []byte
+		wantErr string
+	}{
+		{"exact", []byte{255, 0, 0, 0, 0, 255}, ""},
+		{"bomb", make([]byte, 8*1024*1024), "larger than its declared"},
+	} {
+		path := filepath.Join(dir, tt.name+".pdf")
+		data := deflate(tt.samples)
+		os.WriteFile(path, testPDF("/Width 2 /Height 1 /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", data), 0o644)
+
+		got, _ := GetDocumentImage(t.Context(), &GetDocumentImageRequest{FilePath: path, ImageID: "img-1"})
+		switch {
+		case tt.wantErr == "" && (got.ErrorMessage != "" || got.ReturnedWidth != 2):
+			t.Errorf("%s get = %+v", tt.name, got)
+		case tt.wantErr != "" && !strings.Contains(got.ErrorMessage, tt.wantErr):
+			t.Errorf("%s ErrorMessage = %q, want %q", tt.name, got.ErrorMessage, tt.wantErr)
+		}
+	}
+
+	// Zip members larger than the cap are refused before they are inflated
+	zeros := make([]byte, MaxZipEntrySize+1)
+
+	var docx bytes.Buffer
+	archive := zip.NewWriter(&docx)
+	parts := map[string][]byte{
+		"word/document.xml":            []byte(`<w:document xmlns:w="w" xmlns:r="r"><w:body><w:p><w:r><w:drawing><a:blip r:embed="rId5"/></w:drawing></w:r></w:p></w:body></w:document>`),
+		"word/_rels/document.xml.rels": []byte(`<Relationships><Relationship Id="rId5" Target="media/huge.bmp"/></Relationships>`),
+		"word/media/huge.bmp":          zeros,
+	}
+	for name, data := range parts {
+		w, _ := archive.Create(name)
+		w.Write(data)
+	}
+	archive.Close()
+	docxPath := filepath.Join(dir, "bomb.docx")
+	os.WriteFile(docxPath, docx.Bytes(), 0o644)
+
+	if _, err := readZipEntry(docxPath, "word/media/huge.bmp"); err == nil || !strings.Contains(err.Error(), "is too large") {
+		t.Errorf("readZipEntry() error = %v, want a size error", err)
+	}
+	got, _ := GetDocumentImage(t.Context(), &GetDocumentImageRequest{FilePath: docxPath, ImageID: "img-1"})
+	if !strings.Contains(got.ErrorMessage, "is too large") {
+		t.Errorf("embedded image ErrorMessage = %q, want a size error", got.ErrorMessage)
+	}
+}
+
+func TestGlobalLineAddressing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mail.mbox")
+	mbox := "From a@b Mon Jan  1 00:00:00 2024\nSubject: one\n\nfirst body\n\nFrom c@d Mon Jan  1 00:00:00 2024\nSubject: two\n\nsecond body\n"
+	if err := os.WriteFile(path, []byte(mbox), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, _ := GetDocumentInfo(t.Context(), &GetDocumentInfoRequest{FilePath: path, OneBased: true})
+	if info.ErrorMessage != "" || len(info.Pages) != 2 || info.Pages[0].FirstLine != 1 || info.Pages[1].PageNumber != 2 {
+		t.Fatalf("info = %+v", info)
+	}
+	firstPageLines := info.Pages[0].LineCount
+
+	// The last line of page one and the first line of page two, 0-based
+	lines, _ := ReadDocumentByLines(t.Context(), &ReadDocumentByLinesRequest{
+		FilePath:  path,
+		StartLine: firstPageLines - 1,
+		EndLine:   firstPageLines,
+		Global:    true,
+	})
+	if lines.ErrorMessage != "" || lines.ReadLines != 2 || lines.PageIndex != -1 || len(lines.Segments) != 2 {
+		t.Fatalf("global read = %+v", lines)
+	}
+	if seg := lines.Segments[1]; seg.PageNumber != 1 || seg.StartLine != 0 || seg.GlobalStartLine != firstPageLines {
+		t.Errorf("second segment = %+v", seg)
+	}
+	if lines.TotalLines != info.TotalLines {
+		t.Errorf("TotalLines = %d, want %d", lines.TotalLines, info.TotalLines)
+	}
+
+	// page_index -1 reads across pages too, and one_based shifts every number
+	oneBased, _ := ReadDocumentByLines(t.Context(), &ReadDocumentByLinesRequest{
+		FilePath:  path,
+		StartLine: firstPageLines,
+		EndLine:   firstPageLines + 1,
+		PageIndex: -1,
+		OneBased:  true,
+	})
+	if oneBased.ReadLines != 2 || oneBased.Segments[0].PageNumber != 1 || oneBased.Segments[0].GlobalStartLine != firstPageLines {
+		t.Errorf("one-based global read = %+v", oneBased)
+	}
+
+	page, _ := ReadDocumentByLines(t.Context(), &ReadDocumentByLinesRequest{FilePath: path, PageIndex: 2, StartLine: 1, EndLine: 1, OneBased: true})
+	if page.PageIndex != 2 || page.ReadLines != 1 || page.Segments[0].GlobalStartLine != firstPageLines+1 {
+		t.Errorf("one-based page read = %+v", page)
+	}
+
+	pages, _ := ReadDocumentByPages(t.Context(), &ReadDocumentByPagesRequest{FilePath: path, StartPage: 2, EndPage: 2, OneBased: true})
+	if pages.ReadPages != 1 || pages.Pages[0].PageNumber != 2 || pages.Pages[0].FirstLine != firstPageLines+1 {
+		t.Errorf("one-based page range = %+v", pages)
+	}
+}
+
+func TestListDocuments(t *testing.T) {
+	dir := t.TempDir()
+	documentRoots = []string{dir}
+	defer func() { documentRoots = nil }()
+
+	workbook, err := sheetsToXLSX([]SheetData{{Name: "A", Rows: [][]string{{"1"}}}, {Name: "B", Rows: [][]string{{"2"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"notes.md":              []byte("# Notes\n"),
+		"reports/q1/sales.xlsx": workbook,
+		"reports/summary.txt":   []byte("a longer summary file\n"),
+		"reports/photo.bin":     {0, 1, 2},
+		".hidden/secret.md":     []byte("hidden\n"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(resp *ListDocumentsResponse) []string {
+		var out []string
+		for _, entry := range resp.Entries {
+			out = append(out, entry.Name)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		req  ListDocumentsRequest
+		want []string
+	}{
+		{"all supported", ListDocumentsRequest{}, []string{"notes.md", "reports/q1/sales.xlsx", "reports/summary.txt"}},
+		{"base name glob", ListDocumentsRequest{Pattern: "*.xlsx"}, []string{"reports/q1/sales.xlsx"}},
+		{"double star glob", ListDocumentsRequest{Pattern: "reports/**/*.t*"}, []string{"reports/summary.txt"}},
+		{"depth", ListDocumentsRequest{MaxDepth: 1}, []string{"notes.md", "reports/summary.txt"}},
+		{"include all in subdirectory", ListDocumentsRequest{Root: filepath.Join(dir, "reports"), IncludeAll: true}, []string{"photo.bin", "q1/sales.xlsx", "summary.txt"}},
+		{"include hidden", ListDocumentsRequest{Pattern: "secret.*", IncludeHidden: true}, []string{".hidden/secret.md"}},
+		{"size descending", ListDocumentsRequest{SortBy: "size", Descending: true, Limit: 2}, []string{"reports/q1/sales.xlsx", "reports/summary.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := ListDocuments(t.Context(), &tt.req)
+			if resp.ErrorMessage != "" {
+				t.Fatalf("ErrorMessage = %s", resp.ErrorMessage)
+			}
+			if got := names(resp); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	page, _ := ListDocuments(t.Context(), &ListDocumentsRequest{Limit: 1, Offset: 1})
+	if page.Total != 3 || !page.HasMore || page.NextOffset != 2 || len(page.Entries) != 1 {
+		t.Errorf("pagination = %+v", page)
+	}
+	if entry := page.Entries[0]; entry.PageHint != 2 || entry.FileType != "XLSX" || !filepath.IsAbs(entry.Path) {
+		t.Errorf("xlsx entry = %+v", entry)
+	}
+
+	if resp, _ := ListDocuments(t.Context(), &ListDocumentsRequest{Root: filepath.Dir(dir)}); resp.ErrorMessage == "" {
+		t.Error("root outside the document roots was accepted")
+	}
+
+	// A damaged PDF is listed without a page hint instead of crashing the server
+	damaged := filepath.Join(dir, "damaged")
+	if err := os.MkdirAll(damaged, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(damaged, "broken.pdf"), corruptPDF(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	resp, _ := ListDocuments(t.Context(), &ListDocumentsRequest{Root: damaged})
+	if resp.ErrorMessage != "" || len(resp.Entries) != 1 || resp.Entries[0].PageHint != 0 {
+		t.Errorf("damaged PDF listing = %+v", resp)
+	}
+}
+
+func TestFetchedDocuments(t *testing.T) {
+	outputDir = t.TempDir()
+	defer func() { outputDir = "" }()
+
+	docx, _ := WriteDocument(t.Context(), &WriteDocumentRequest{OutputPath: "notes.docx", Content: "# Notes\n\nFetched through the doc readers."})
+	xlsx, _ := WriteDocument(t.Context(), &WriteDocumentRequest{OutputPath: "book.xlsx", Sheets: []SheetData{
+		{Name: "Fruit", Rows: [][]string{{"name", "price"}, {"apple", "1.5"}}},
+		{Name: "Veg", Rows: [][]string{{"name", "price"}, {"leek", "0.8"}}},
+	}})
+	if docx.ErrorMessage != "" || xlsx.ErrorMessage != "" {
+		t.Fatalf("writing fixtures: %s %s", docx.ErrorMessage, xlsx.ErrorMessage)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download":
+			w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+			http.ServeFile(w, r, docx.OutputPath)
+		case "/files/book.xlsx":
+			// A generic type leaves the URL extension to identify the sniffed zip
+			w.Header().Set("Content-Type", "application/octet-stream")
+			data, _ := os.ReadFile(xlsx.OutputPath)
+			w.Write(data)
+		}
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	fetch.Configure(&fetch.Config{AllowPrivate: true, AllowedPorts: []int{port}})
+	defer fetch.Configure(nil)
+
+	resp, _ := fetch.Fetch(t.Context(), &fetch.FetchRequest{URL: server.URL + "/download"})
+	if resp.ErrorMessage != "" || resp.Document == nil || resp.Document.Format != ".docx" || !strings.Contains(resp.Content, "Fetched through the doc readers.") {
+		t.Errorf("docx response = %+v", resp)
+	}
+
+	resp, _ = fetch.Fetch(t.Context(), &fetch.FetchRequest{URL: server.URL + "/files/book.xlsx", Format: "markdown"})
+	if resp.ErrorMessage != "" || resp.Document == nil || resp.Document.TotalPages != 2 {
+		t.Fatalf("xlsx response = %+v", resp)
+	}
+	veg := resp.Document.Pages[1]
+	if veg.Name != "Veg" || !strings.HasPrefix(string([]rune(resp.Content)[veg.StartIndex:]), "## Veg") || !strings.Contains(resp.Content, "leek") {
+		t.Errorf("xlsx pages = %+v, Content = %q", resp.Document.Pages, resp.Content)
+	}
+}
+
+func TestDocumentURLPolicy(t *testing.T) {
+	defer fetch.Configure(nil)
+
+	tests := []struct {
+		config *fetch.Config
+		url    string
+		want   string
+	}{
+		{&fetch.Config{DenyHosts: []string{"example.com"}}, "https://docs.example.com/report.pdf", "deny list"},
+		{&fetch.Config{AllowHosts: []string{"go.dev"}}, "https://example.org/report.pdf", "allow list"},
+		{&fetch.Config{}, "http://example.com:6379/report.pdf", "port 6379"},
+		{&fetch.Config{}, "http://127.0.0.1/report.pdf", "private or reserved"},
+	}
+	for _, tt := range tests {
+		fetch.Configure(tt.config)
+		resp, _ := ReadDocumentSmart(t.Context(), &ReadDocumentSmartRequest{FilePath: tt.url})
+		if !strings.Contains(resp.ErrorMessage, "not allowed") || !strings.Contains(resp.ErrorMessage, tt.want) {
+			t.Errorf("ReadDocumentSmart(%s) ErrorMessage = %q, want to contain %q", tt.url, resp.ErrorMessage, tt.want)
+		}
+	}
+}
+
+func TestDownloadDocument(t *testing.T) {
+	// Keep the download cache inside the test
+	t.Setenv("TMPDIR", t.TempDir())
+
+	hits := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		switch r.URL.Path {
+		case "/notes.txt":
+			w.Write([]byte("downloaded notes"))
+		case "/export":
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Write([]byte("a,b\n1,2\n"))
+		case "/declared-large.pdf":
+			w.Header().Set("Content-Length", strconv.Itoa(MaxCachedFileSize+1))
+			w.WriteHeader(http.StatusOK)
+		case "/streamed-large.txt":
+			chunk := bytes.Repeat([]byte("x"), 1024*1024)
+			for range MaxCachedFileSize/len(chunk) + 1 {
+				if _, err := w.Write(chunk); err != nil {
+					return
+				}
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	fetch.Configure(&fetch.Config{AllowPrivate: true, AllowedPorts: []int{port}})
+	defer fetch.Configure(nil)
+
+	tests := []struct {
+		name    string
+		path    string
+		wantExt string
+		wantErr string
+	}{
+		{"extension from the URL", "/notes.txt", ".txt", ""},
+		{"extension from the content type", "/export", ".csv", ""},
+		{"not found", "/missing.pdf", "", "status code: 404"},
+		{"declared size over the limit", "/declared-large.pdf", "", "too large"},
+		{"streamed size over the limit", "/streamed-large.txt", "", "exceeds the size limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, err := resolveDocumentPath(t.Context(), server.URL+tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveDocumentPath() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveDocumentPath() error = %v", err)
+			}
+			if filepath.Ext(local) != tt.wantExt {
+				t.Errorf("cached file %s, want extension %s", local, tt.wantExt)
+			}
+		})
+	}
+
+	// A recent download is reused without another request
+	first, _ := resolveDocumentPath(t.Context(), server.URL+"/notes.txt")
+	second, err := resolveDocumentPath(t.Context(), server.URL+"/notes.txt")
+	if err != nil || second != first || hits["/notes.txt"] != 1 {
+		t.Errorf("second download = %s, %v (hits %d), want the cached %s", second, err, hits["/notes.txt"], first)
+	}
+	if data, _ := os.ReadFile(second); string(data) != "downloaded notes" {
+		t.Errorf("cached content = %q", data)
+	}
+}
+
+func TestArchiveMember(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "bundle.zip")
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, _ := archive.Create("docs/inner.txt")
+	w.Write([]byte("inside the archive"))
+	archive.Close()
+	if err := os.WriteFile(archivePath, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{"member", "zip://" + archivePath + "!/docs/inner.txt", "inside the archive", ""},
+		{"missing member", "zip://" + archivePath + "!/docs/other.txt", "", `"docs/other.txt" not found in archive`},
+		{"missing archive", "zip://" + filepath.Join(dir, "none.zip") + "!/docs/inner.txt", "", "archive access failed"},
+		{"no member", "zip://" + archivePath, "", "invalid archive path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			local, err := resolveDocumentPath(t.Context(), tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("resolveDocumentPath() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveDocumentPath() error = %v", err)
+			}
+			if data, _ := os.ReadFile(local); string(data) != tt.want || filepath.Ext(local) != ".txt" {
+				t.Errorf("extracted %s = %q, want %q", local, data, tt.want)
+			}
+		})
+	}
+
+	// Archive members are readable through the tools
+	resp, _ := ReadDocumentSmart(t.Context(), &ReadDocumentSmartRequest{FilePath: tests[0].path})
+	if resp.ErrorMessage != "" || !strings.Contains(resp.Content, "inside the archive") {
+		t.Errorf("ReadDocumentSmart() = %+v", resp)
+	}
+}
@@ -0,0 +1,30 @@
+package doc
+
+import (
+	"fkmcps/tools/fetch"
+)
+
+func init() {
+	fetch.RegisterDocumentReader(fetchedDocumentReader{})
+}
+
+// fetchedDocumentReader Reads documents returned by the fetch tool through the doc readers
+type fetchedDocumentReader struct{}
+
+func (fetchedDocumentReader) Extensions() []string {
+	return SupportedFormats()
+}
+
+// ReadPages Parse the whole document without the parsed-document cache; fetched files are temporary
+func (fetchedDocumentReader) ReadPages(filePath string) ([]fetch.DocumentPage, error) {
+	result, err := parseDocument(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make([]fetch.DocumentPage, len(result.Pages))
+	for i, page := range result.Pages {
+		pages[i] = fetch.DocumentPage{Name: page.PageName, Lines: page.Lines}
+	}
+	return pages, nil
+}
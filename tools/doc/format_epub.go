@@ -0,0 +1,131 @@
+package doc
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/wsshow/docreader"
+)
+
+// epubReader Reads EPUB books, one page per spine item (chapter)
+type epubReader struct{}
+
+// epubContainer META-INF/container.xml structure
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage OPF package document structure
+type epubPackage struct {
+	Metadata struct {
+		Title    []string `xml:"title"`
+		Creator  []string `xml:"creator"`
+		Language []string `xml:"language"`
+		Date     []string `xml:"date"`
+	} `xml:"metadata"`
+	Manifest []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+func (r *epubReader) Extensions() []string {
+	return []string{".epub"}
+}
+
+func (r *epubReader) Read(filePath string) (*docreader.DocumentResult, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, docreader.WrapError("epubReader.Read", filePath, docreader.ErrFileOpen)
+	}
+	defer zipReader.Close()
+
+	files := make(map[string]*zip.File, len(zipReader.File))
+	for _, file := range zipReader.File {
+		files[file.Name] = file
+	}
+
+	var container epubContainer
+	if err := unmarshalZipFile(files["META-INF/container.xml"], &container); err != nil || len(container.Rootfiles) == 0 {
+		return nil, docreader.WrapError("epubReader.Read", filePath, docreader.ErrInvalidFormat)
+	}
+
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := unmarshalZipFile(files[opfPath], &pkg); err != nil {
+		return nil, docreader.WrapError("epubReader.Read", filePath, docreader.ErrFileParse)
+	}
+
+	metadata := fileMetadata(filePath)
+	setFirst := func(key string, values []string) {
+		if len(values) > 0 && strings.TrimSpace(values[0]) != "" {
+			metadata[key] = strings.TrimSpace(values[0])
+		}
+	}
+	setFirst("title", pkg.Metadata.Title)
+	setFirst("creator", pkg.Metadata.Creator)
+	setFirst("language", pkg.Metadata.Language)
+	setFirst("date", pkg.Metadata.Date)
+
+	hrefs := make(map[string]string, len(pkg.Manifest))
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+
+	result := &docreader.DocumentResult{Metadata: metadata}
+	baseDir := path.Dir(opfPath)
+
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		data, err := readZipFile(files[path.Join(baseDir, href)])
+		if err != nil {
+			continue
+		}
+
+		lines, chapterMeta, err := htmlToLines(data)
+		if err != nil {
+			continue
+		}
+
+		result.Pages = append(result.Pages, docreader.PageContent{
+			PageNumber: len(result.Pages),
+			PageName:   chapterMeta["title"],
+			Lines:      lines,
+			TotalLines: len(lines),
+		})
+	}
+
+	result.TotalPages = len(result.Pages)
+	metadata["chapters"] = fmt.Sprintf("%d", result.TotalPages)
+
+	return result, nil
+}
+
+// readZipFile Read the full content of a zip entry
+func readZipFile(file *zip.File) ([]byte, error) {
+	if file == nil {
+		return nil, docreader.ErrFileNotFound
+	}
+	return readZipMember(file)
+}
+
+// unmarshalZipFile Decode an XML zip entry into v
+func unmarshalZipFile(file *zip.File, v any) error {
+	data, err := readZipFile(file)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, v)
+}
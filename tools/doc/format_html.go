@@ -0,0 +1,74 @@
+package doc
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	md "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/wsshow/docreader"
+)
+
+// htmlReader Reads HTML pages as Markdown so headings, lists and tables keep their structure
+type htmlReader struct{}
+
+func (r *htmlReader) Extensions() []string {
+	return []string{".html", ".htm", ".xhtml"}
+}
+
+func (r *htmlReader) Read(filePath string) (*docreader.DocumentResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, docreader.WrapError("htmlReader.Read", filePath, docreader.ErrFileRead)
+	}
+
+	lines, metadata, err := htmlToLines(data)
+	if err != nil {
+		return nil, docreader.WrapError("htmlReader.Read", filePath, err)
+	}
+
+	for key, value := range fileMetadata(filePath) {
+		metadata[key] = value
+	}
+
+	return singlePageResult(lines, metadata), nil
+}
+
+// htmlToLines Convert an HTML document to Markdown lines and extract its head metadata
+func htmlToLines(data []byte) ([]string, map[string]string, error) {
+	metadata := make(map[string]string)
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	if title := strings.TrimSpace(doc.Find("head title").First().Text()); title != "" {
+		metadata["title"] = title
+	}
+	doc.Find("head meta[name]").Each(func(_ int, s *goquery.Selection) {
+		name := strings.ToLower(s.AttrOr("name", ""))
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		switch name {
+		case "author", "description", "keywords":
+			if content != "" {
+				metadata[name] = content
+			}
+		}
+	})
+
+	doc.Find("script, style, noscript").Remove()
+	body, err := doc.Find("body").Html()
+	if err != nil || strings.TrimSpace(body) == "" {
+		body = string(data)
+	}
+
+	markdown, err := md.ConvertString(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to convert HTML to markdown: %w", err)
+	}
+
+	return splitLines(markdown), metadata, nil
+}
@@ -0,0 +1,193 @@
+package doc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/wsshow/docreader"
+)
+
+// mailReader Reads .eml messages (one page) and .mbox mailboxes (one page per message)
+type mailReader struct{}
+
+func (r *mailReader) Extensions() []string {
+	return []string{".eml", ".mbox"}
+}
+
+func (r *mailReader) Read(filePath string) (*docreader.DocumentResult, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, docreader.WrapError("mailReader.Read", filePath, docreader.ErrFileRead)
+	}
+
+	var messages [][]byte
+	if strings.ToLower(filepath.Ext(filePath)) == ".mbox" {
+		messages = splitMbox(data)
+	} else {
+		messages = [][]byte{data}
+	}
+
+	metadata := fileMetadata(filePath)
+	result := &docreader.DocumentResult{Metadata: metadata}
+
+	for _, raw := range messages {
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		if err != nil {
+			continue
+		}
+		lines, subject := mailMessageLines(msg)
+		result.Pages = append(result.Pages, docreader.PageContent{
+			PageNumber: len(result.Pages),
+			PageName:   subject,
+			Lines:      lines,
+			TotalLines: len(lines),
+		})
+
+		// A single message exposes its headers as document metadata
+		if len(messages) == 1 {
+			for _, key := range []string{"From", "To", "Date", "Subject"} {
+				if value := decodeMailHeader(msg.Header.Get(key)); value != "" {
+					metadata[strings.ToLower(key)] = value
+				}
+			}
+		}
+	}
+
+	if len(result.Pages) == 0 {
+		return nil, docreader.WrapError("mailReader.Read", filePath, docreader.ErrFileParse)
+	}
+
+	result.TotalPages = len(result.Pages)
+	metadata["messages"] = fmt.Sprintf("%d", result.TotalPages)
+
+	return result, nil
+}
+
+// splitMbox Split an mbox file on "From " separator lines
+func splitMbox(data []byte) [][]byte {
+	var messages [][]byte
+	var current bytes.Buffer
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "From ") {
+			if current.Len() > 0 {
+				messages = append(messages, bytes.Clone(current.Bytes()))
+				current.Reset()
+			}
+			continue
+		}
+		// Undo mboxrd quoting of body lines starting with "From "
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") && strings.HasPrefix(line, ">") {
+			line = line[1:]
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	if current.Len() > 0 {
+		messages = append(messages, current.Bytes())
+	}
+
+	return messages
+}
+
+// mailMessageLines Render headers and the readable body of a message as lines
+func mailMessageLines(msg *mail.Message) ([]string, string) {
+	subject := decodeMailHeader(msg.Header.Get("Subject"))
+
+	var lines []string
+	for _, key := range []string{"From", "To", "Cc", "Date", "Subject"} {
+		if value := decodeMailHeader(msg.Header.Get(key)); value != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", key, value))
+		}
+	}
+	lines = append(lines, "")
+
+	plain, html, attachments := mailBody(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	switch {
+	case plain != "":
+		lines = append(lines, splitLines(plain)...)
+	case html != "":
+		if htmlLines, _, err := htmlToLines([]byte(html)); err == nil {
+			lines = append(lines, htmlLines...)
+		}
+	}
+
+	for _, attachment := range attachments {
+		lines = append(lines, fmt.Sprintf("[Attachment: %s]", attachment))
+	}
+
+	return lines, subject
+}
+
+// mailBody Walk a (possibly multipart) body and return its first text/plain and text/html parts plus attachment names
+func mailBody(contentType, transferEncoding string, body io.Reader) (plain, html string, attachments []string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			if name := part.FileName(); name != "" {
+				attachments = append(attachments, fmt.Sprintf("%s (%s)", decodeMailHeader(name), part.Header.Get("Content-Type")))
+				continue
+			}
+			p, h, a := mailBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if plain == "" {
+				plain = p
+			}
+			if html == "" {
+				html = h
+			}
+			attachments = append(attachments, a...)
+		}
+		return plain, html, attachments
+	}
+
+	switch strings.ToLower(transferEncoding) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", "", attachments
+	}
+
+	switch mediaType {
+	case "text/plain":
+		return string(data), "", attachments
+	case "text/html":
+		return "", string(data), attachments
+	}
+	return "", "", attachments
+}
+
+// decodeMailHeader Decode RFC 2047 encoded words in a header value
+func decodeMailHeader(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
@@ -0,0 +1,250 @@
+package doc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/wsshow/docreader"
+)
+
+// maxRepeatedCells Upper bound for table:number-columns-repeated, which spreadsheets use to pad rows to 1024 columns
+const maxRepeatedCells = 256
+
+// odfReader Reads OpenDocument text (.odt), spreadsheets (.ods, one page per sheet) and presentations (.odp, one page per slide)
+type odfReader struct{}
+
+// odfMeta meta.xml structure
+type odfMeta struct {
+	Meta struct {
+		Title          string `xml:"title"`
+		Subject        string `xml:"subject"`
+		Creator        string `xml:"creator"`
+		InitialCreator string `xml:"initial-creator"`
+		CreationDate   string `xml:"creation-date"`
+		Date           string `xml:"date"`
+	} `xml:"meta"`
+}
+
+func (r *odfReader) Extensions() []string {
+	return []string{".odt", ".ods", ".odp"}
+}
+
+func (r *odfReader) Read(filePath string) (*docreader.DocumentResult, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, docreader.WrapError("odfReader.Read", filePath, docreader.ErrFileOpen)
+	}
+	defer zipReader.Close()
+
+	var contentFile, metaFile *zip.File
+	for _, file := range zipReader.File {
+		switch file.Name {
+		case "content.xml":
+			contentFile = file
+		case "meta.xml":
+			metaFile = file
+		}
+	}
+
+	data, err := readZipFile(contentFile)
+	if err != nil {
+		return nil, docreader.WrapError("odfReader.Read", filePath, docreader.ErrInvalidFormat)
+	}
+
+	pages, err := parseODFContent(data, strings.ToLower(filepath.Ext(filePath)))
+	if err != nil {
+		return nil, docreader.WrapError("odfReader.Read", filePath, docreader.ErrFileParse)
+	}
+
+	metadata := fileMetadata(filePath)
+	var meta odfMeta
+	if err := unmarshalZipFile(metaFile, &meta); err == nil {
+		for key, value := range map[string]string{
+			"title":           meta.Meta.Title,
+			"subject":         meta.Meta.Subject,
+			"creator":         meta.Meta.Creator,
+			"initial_creator": meta.Meta.InitialCreator,
+			"created":         meta.Meta.CreationDate,
+			"modified":        meta.Meta.Date,
+		} {
+			if value != "" {
+				metadata[key] = value
+			}
+		}
+	}
+
+	return &docreader.DocumentResult{
+		Pages:      pages,
+		TotalPages: len(pages),
+		Metadata:   metadata,
+	}, nil
+}
+
+// parseODFContent Walk content.xml and collect lines per page.
+// Text documents are a single page with headings rendered as Markdown headings;
+// spreadsheet sheets and presentation slides each become a page.
+func parseODFContent(data []byte, ext string) ([]docreader.PageContent, error) {
+	var (
+		pages        []docreader.PageContent
+		lines        []string
+		pageName     string
+		paraDepth    int
+		para         strings.Builder
+		headingLevel int
+		inCell       bool
+		cell         strings.Builder
+		cellRepeat   int
+		row          []string
+		rowRepeat    int
+		rowIndex     int
+	)
+
+	multiPage := ext == ".ods" || ext == ".odp"
+
+	startPage := func(name string) {
+		lines = make([]string, 0)
+		pageName = name
+		rowIndex = 0
+	}
+	endPage := func() {
+		pages = append(pages, docreader.PageContent{
+			PageNumber: len(pages),
+			PageName:   pageName,
+			Lines:      lines,
+			TotalLines: len(lines),
+		})
+		lines = nil
+	}
+	attr := func(el xml.StartElement, name string) string {
+		for _, a := range el.Attr {
+			if a.Name.Local == name {
+				return a.Value
+			}
+		}
+		return ""
+	}
+	repeat := func(el xml.StartElement, name string) int {
+		n, err := strconv.Atoi(attr(el, name))
+		if err != nil || n < 1 {
+			return 1
+		}
+		return n
+	}
+	write := func(text string) {
+		if paraDepth > 0 {
+			para.WriteString(text)
+		}
+	}
+
+	if !multiPage {
+		startPage("")
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse content.xml: %w", err)
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "page":
+				if ext == ".odp" {
+					startPage(attr(el, "name"))
+				}
+			case "table":
+				if ext == ".ods" {
+					startPage(attr(el, "name"))
+				}
+			case "table-row":
+				row = row[:0]
+				rowRepeat = repeat(el, "number-rows-repeated")
+			case "table-cell", "covered-table-cell":
+				inCell = true
+				cell.Reset()
+				cellRepeat = min(repeat(el, "number-columns-repeated"), maxRepeatedCells)
+			case "p", "h":
+				paraDepth++
+				if paraDepth == 1 {
+					para.Reset()
+					headingLevel = 0
+					if el.Name.Local == "h" {
+						headingLevel = repeat(el, "outline-level")
+					}
+				}
+			case "s":
+				write(strings.Repeat(" ", repeat(el, "c")))
+			case "tab":
+				write("\t")
+			case "line-break":
+				write(" ")
+			}
+
+		case xml.CharData:
+			write(string(el))
+
+		case xml.EndElement:
+			switch el.Name.Local {
+			case "p", "h":
+				paraDepth--
+				if paraDepth > 0 {
+					continue
+				}
+				text := para.String()
+				if inCell {
+					if cell.Len() > 0 {
+						cell.WriteString(" ")
+					}
+					cell.WriteString(text)
+				} else if strings.TrimSpace(text) != "" && lines != nil {
+					if headingLevel > 0 && ext == ".odt" {
+						text = strings.Repeat("#", min(headingLevel, 6)) + " " + text
+					}
+					lines = append(lines, text)
+				}
+			case "table-cell", "covered-table-cell":
+				for range cellRepeat {
+					row = append(row, cell.String())
+				}
+				inCell = false
+			case "table-row":
+				for len(row) > 0 && strings.TrimSpace(row[len(row)-1]) == "" {
+					row = row[:len(row)-1]
+				}
+				if len(row) > 0 && lines != nil {
+					if ext == ".ods" {
+						lines = append(lines, fmt.Sprintf("Row %d: %s", rowIndex, strings.Join(row, " | ")))
+					} else {
+						lines = append(lines, strings.Join(row, "\t"))
+					}
+				}
+				rowIndex += rowRepeat
+			case "table":
+				if ext == ".ods" {
+					endPage()
+				}
+			case "page":
+				if ext == ".odp" {
+					endPage()
+				}
+			}
+		}
+	}
+
+	if !multiPage {
+		endPage()
+	}
+
+	return pages, nil
+}
@@ -0,0 +1,142 @@
+package doc
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wsshow/docreader"
+)
+
+// structuredTextReader Reads JSON, YAML and XML files. JSON is re-indented so each value lands on its own line.
+type structuredTextReader struct{}
+
+func (r *structuredTextReader) Extensions() []string {
+	return []string{".json", ".yaml", ".yml", ".xml"}
+}
+
+func (r *structuredTextReader) Read(filePath string) (*docreader.DocumentResult, error) {
+	data, err := readTextFile(filePath)
+	if err != nil {
+		return nil, docreader.WrapError("structuredTextReader.Read", filePath, err)
+	}
+
+	metadata := fileMetadata(filePath)
+	ext := strings.ToLower(filepath.Ext(filePath))
+
+	switch ext {
+	case ".json":
+		metadata["format"] = "json"
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, data, "", "  "); err == nil {
+			data = indented.Bytes()
+			metadata["valid"] = "true"
+		} else {
+			metadata["valid"] = "false"
+		}
+	case ".xml":
+		metadata["format"] = "xml"
+		if root := xmlRootElement(data); root != "" {
+			metadata["root_element"] = root
+		}
+	default:
+		metadata["format"] = "yaml"
+	}
+
+	return singlePageResult(splitLines(string(data)), metadata), nil
+}
+
+// sourceCodeReader Reads source code and configuration files as plain text
+type sourceCodeReader struct{}
+
+// sourceLanguages Source file extensions and their language names
+var sourceLanguages = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".java":  "java",
+	".kt":    "kotlin",
+	".scala": "scala",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".rs":    "rust",
+	".rb":    "ruby",
+	".php":   "php",
+	".swift": "swift",
+	".lua":   "lua",
+	".pl":    "perl",
+	".r":     "r",
+	".sh":    "shell",
+	".bash":  "shell",
+	".ps1":   "powershell",
+	".sql":   "sql",
+	".css":   "css",
+	".scss":  "scss",
+	".vue":   "vue",
+	".proto": "protobuf",
+	".toml":  "toml",
+	".ini":   "ini",
+	".cfg":   "ini",
+	".conf":  "conf",
+	".log":   "log",
+}
+
+func (r *sourceCodeReader) Extensions() []string {
+	exts := make([]string, 0, len(sourceLanguages))
+	for ext := range sourceLanguages {
+		exts = append(exts, ext)
+	}
+	return exts
+}
+
+func (r *sourceCodeReader) Read(filePath string) (*docreader.DocumentResult, error) {
+	data, err := readTextFile(filePath)
+	if err != nil {
+		return nil, docreader.WrapError("sourceCodeReader.Read", filePath, err)
+	}
+
+	metadata := fileMetadata(filePath)
+	metadata["language"] = sourceLanguages[strings.ToLower(filepath.Ext(filePath))]
+
+	return singlePageResult(splitLines(string(data)), metadata), nil
+}
+
+// readTextFile Read a file that is expected to be UTF-8 text
+func readTextFile(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, docreader.ErrFileRead
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: content is not valid UTF-8 text", docreader.ErrInvalidFormat)
+	}
+	return data, nil
+}
+
+// xmlRootElement Return the name of the first element in an XML document
+func xmlRootElement(data []byte) string {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if el, ok := token.(xml.StartElement); ok {
+			return el.Name.Local
+		}
+	}
+}
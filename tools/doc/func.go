@@ -12,7 +12,8 @@ import (
 
 // GetDocumentInfoRequest Document information request
 type GetDocumentInfoRequest struct {
-	FilePath string `json:"file_path" jsonschema:"required,description:Document file path (supports .docx, .pdf, .xlsx, .pptx, .txt, .csv, .md, .rtf)"`
+	FilePath string `json:"file_path" jsonschema:"required,description:Document file path (supports office documents, PDF, OpenDocument, EPUB, HTML, mail, structured text and source code files)"`
+	OneBased bool   `json:"one_based,omitempty" jsonschema:"description:Use 1-based page and line numbers in the request and response (default false, 0-based)"`
 }
 
 // GetDocumentInfoResponse Document information response
@@ -24,6 +25,9 @@ type GetDocumentInfoResponse struct {
 	TotalSheets   int               `json:"total_sheets,omitempty" jsonschema:"description:Total number of sheets (XLSX only)"`
 	SheetNames    []string          `json:"sheet_names,omitempty" jsonschema:"description:Sheet name list (XLSX only)"`
 	EstimatedSize string            `json:"estimated_size" jsonschema:"description:Estimated text size (for evaluating whether it fits in one read)"`
+	TotalLines    int               `json:"total_lines,omitempty" jsonschema:"description:Total number of lines across all pages"`
+	Pages         []PageLineRange   `json:"pages,omitempty" jsonschema:"description:Global line range of each page, for reading with read_document_by_line in global mode"`
+	IndexBase     int               `json:"index_base" jsonschema:"description:Number of the first page and line (0 or 1)"`
 	Metadata      map[string]string `json:"metadata" jsonschema:"description:Document metadata (title, author, etc.)"`
 	ErrorMessage  string            `json:"error_message,omitempty" jsonschema:"description:Error message"`
 }
@@ -31,8 +35,9 @@ type GetDocumentInfoResponse struct {
 // ReadDocumentByPagesRequest Read document by pages request
 type ReadDocumentByPagesRequest struct {
 	FilePath  string `json:"file_path" jsonschema:"required,description:Document file path"`
-	StartPage int    `json:"start_page,omitempty" jsonschema:"description:Starting page number (0-based, default 0)"`
+	StartPage int    `json:"start_page,omitempty" jsonschema:"description:Starting page number (default first page)"`
 	EndPage   int    `json:"end_page,omitempty" jsonschema:"description:Ending page number (inclusive, -1 means to end, default -1)"`
+	OneBased  bool   `json:"one_based,omitempty" jsonschema:"description:Use 1-based page and line numbers in the request and response (default false, 0-based)"`
 }
 
 // ReadDocumentByPagesResponse Read document by pages response
@@ -41,31 +46,37 @@ type ReadDocumentByPagesResponse struct {
 	Pages        []PageContentDetail `json:"pages" jsonschema:"description:Page content details"`
 	TotalPages   int                 `json:"total_pages" jsonschema:"description:Total number of pages in document"`
 	ReadPages    int                 `json:"read_pages" jsonschema:"description:Actual number of pages read"`
+	IndexBase    int                 `json:"index_base" jsonschema:"description:Number of the first page and line (0 or 1)"`
 	Metadata     map[string]string   `json:"metadata" jsonschema:"description:Document metadata"`
 	ErrorMessage string              `json:"error_message,omitempty" jsonschema:"description:Error message"`
 }
 
 // PageContentDetail Page content detail
 type PageContentDetail struct {
-	PageNumber int    `json:"page_number" jsonschema:"description:Page number (0-based)"`
+	PageNumber int    `json:"page_number" jsonschema:"description:Page number"`
 	PageName   string `json:"page_name,omitempty" jsonschema:"description:Page name (e.g., sheet name)"`
 	LineCount  int    `json:"line_count" jsonschema:"description:Number of lines in this page"`
+	FirstLine  int    `json:"first_line" jsonschema:"description:Global line number of the page's first line"`
 }
 
 // ReadDocumentByLinesRequest Read document by lines request
 type ReadDocumentByLinesRequest struct {
 	FilePath  string `json:"file_path" jsonschema:"required,description:Document file path"`
-	StartLine int    `json:"start_line,omitempty" jsonschema:"description:Starting line number (0-based, default 0)"`
+	StartLine int    `json:"start_line,omitempty" jsonschema:"description:Starting line number (default first line)"`
 	EndLine   int    `json:"end_line,omitempty" jsonschema:"description:Ending line number (inclusive, -1 means to end, default -1)"`
-	PageIndex int    `json:"page_index,omitempty" jsonschema:"description:Specified page index (0-based, only valid for multi-page documents, -1 means first page, default -1)"`
+	PageIndex int    `json:"page_index,omitempty" jsonschema:"description:Page to read lines from (default first page); -1 addresses lines across the whole document, like global"`
+	Global    bool   `json:"global,omitempty" jsonschema:"description:Treat start_line and end_line as line numbers across all pages (default false)"`
+	OneBased  bool   `json:"one_based,omitempty" jsonschema:"description:Use 1-based page and line numbers in the request and response (default false, 0-based)"`
 }
 
 // ReadDocumentByLinesResponse Read document by lines response
 type ReadDocumentByLinesResponse struct {
 	Content      string            `json:"content" jsonschema:"description:Read document content"`
-	TotalLines   int               `json:"total_lines" jsonschema:"description:Total number of lines in this page"`
+	TotalLines   int               `json:"total_lines" jsonschema:"description:Total number of lines in this page, or in the whole document in global mode"`
 	ReadLines    int               `json:"read_lines" jsonschema:"description:Actual number of lines read"`
-	PageIndex    int               `json:"page_index" jsonschema:"description:Page index read"`
+	PageIndex    int               `json:"page_index" jsonschema:"description:Page index read (-1 in global mode)"`
+	Segments     []LineSegment     `json:"segments,omitempty" jsonschema:"description:Page and line coordinates of the lines read, per page"`
+	IndexBase    int               `json:"index_base" jsonschema:"description:Number of the first page and line (0 or 1)"`
 	Metadata     map[string]string `json:"metadata" jsonschema:"description:Document metadata"`
 	ErrorMessage string            `json:"error_message,omitempty" jsonschema:"description:Error message"`
 }
@@ -76,6 +87,7 @@ type ReadDocumentSmartRequest struct {
 	MaxChars     int    `json:"max_chars,omitempty" jsonschema:"description:Maximum character limit (default 50000, recommended between 10000-100000)"`
 	SampleMode   bool   `json:"sample_mode,omitempty" jsonschema:"description:Sampling mode (true for uniform sampling throughout, false for reading from start, default false)"`
 	CleanContent bool   `json:"clean_content,omitempty" jsonschema:"description:Whether to clean text (remove extra spaces, blank lines, etc., default true)"`
+	OneBased     bool   `json:"one_based,omitempty" jsonschema:"description:Use 1-based page and line numbers in the request and response (default false, 0-based)"`
 }
 
 // ReadDocumentSmartResponse Smart document reading response
@@ -85,6 +97,9 @@ type ReadDocumentSmartResponse struct {
 	OriginalSize int               `json:"original_size" jsonschema:"description:Original text size (character count)"`
 	ReturnedSize int               `json:"returned_size" jsonschema:"description:Returned text size (character count)"`
 	Strategy     string            `json:"strategy" jsonschema:"description:Reading strategy used"`
+	TotalPages   int               `json:"total_pages,omitempty" jsonschema:"description:Total number of pages in document"`
+	TotalLines   int               `json:"total_lines,omitempty" jsonschema:"description:Total number of lines across all pages"`
+	IndexBase    int               `json:"index_base" jsonschema:"description:Number of the first page and line (0 or 1)"`
 	Metadata     map[string]string `json:"metadata" jsonschema:"description:Document metadata"`
 	ErrorMessage string            `json:"error_message,omitempty" jsonschema:"description:Error message"`
 	Suggestion   string            `json:"suggestion,omitempty" jsonschema:"description:Suggestion (how to better read this document)"`
@@ -92,8 +107,15 @@ type ReadDocumentSmartResponse struct {
 
 // GetDocumentInfo Get document basic information
 func GetDocumentInfo(ctx context.Context, req *GetDocumentInfoRequest) (*GetDocumentInfoResponse, error) {
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &GetDocumentInfoResponse{
+			ErrorMessage: fmt.Sprintf("Failed to access document: %v", err),
+		}, nil
+	}
+
 	// Check if file exists
-	fileInfo, err := os.Stat(req.FilePath)
+	fileInfo, err := os.Stat(filePath)
 	if err != nil {
 		return &GetDocumentInfoResponse{
 			ErrorMessage: fmt.Sprintf("File access failed: %v", err),
@@ -101,7 +123,7 @@ func GetDocumentInfo(ctx context.Context, req *GetDocumentInfoRequest) (*GetDocu
 	}
 
 	// Get file type
-	ext := strings.ToLower(filepath.Ext(req.FilePath))
+	ext := strings.ToLower(filepath.Ext(filePath))
 	fileType := strings.TrimPrefix(ext, ".")
 	fileType = strings.ToUpper(fileType)
 
@@ -109,7 +131,7 @@ func GetDocumentInfo(ctx context.Context, req *GetDocumentInfoRequest) (*GetDocu
 	fileSize := formatFileSize(fileInfo.Size())
 
 	// Get metadata
-	doc, err := docreader.ReadDocument(req.FilePath)
+	doc, err := readDocument(filePath)
 	if err != nil {
 		return &GetDocumentInfoResponse{
 			FilePath:     req.FilePath,
@@ -124,9 +146,21 @@ func GetDocumentInfo(ctx context.Context, req *GetDocumentInfoRequest) (*GetDocu
 		FileType:      fileType,
 		FileSize:      fileSize,
 		EstimatedSize: fmt.Sprintf("Approx %d characters", len(doc.Content)),
+		IndexBase:     indexBase(req.OneBased),
 		Metadata:      doc.Metadata,
 	}
 
+	// Page layout in whole-document line numbers
+	layout, layoutErr := readDocumentWithConfig(filePath, nil)
+	if layoutErr == nil {
+		response.TotalLines = layout.TotalLines
+		response.Pages = pageLineRanges(layout)
+		for i := range response.Pages {
+			response.Pages[i].PageNumber += response.IndexBase
+			response.Pages[i].FirstLine += response.IndexBase
+		}
+	}
+
 	// Get additional information based on file type
 	switch ext {
 	case ".pdf":
@@ -142,6 +176,16 @@ func GetDocumentInfo(ctx context.Context, req *GetDocumentInfoRequest) (*GetDocu
 			response.SheetNames = strings.Split(sheets, ",")
 			response.TotalSheets = len(response.SheetNames)
 		}
+	default:
+		if _, ok := lookupFormatReader(filePath); ok && layoutErr == nil {
+			response.TotalPages = layout.TotalPages
+			if ext == ".ods" {
+				for _, page := range layout.Pages {
+					response.SheetNames = append(response.SheetNames, page.PageName)
+				}
+				response.TotalSheets = len(response.SheetNames)
+			}
+		}
 	}
 
 	return response, nil
@@ -149,27 +193,41 @@ func GetDocumentInfo(ctx context.Context, req *GetDocumentInfoRequest) (*GetDocu
 
 // ReadDocumentByPages Read document by page range
 func ReadDocumentByPages(ctx context.Context, req *ReadDocumentByPagesRequest) (*ReadDocumentByPagesResponse, error) {
-	// Set default values
-	if req.EndPage < 0 {
-		req.EndPage = 999999 // Set a large value to indicate reading to the end
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &ReadDocumentByPagesResponse{
+			ErrorMessage: fmt.Sprintf("Failed to access document: %v", err),
+		}, nil
 	}
 
-	// Create read configuration
-	config := docreader.NewReadConfig().WithPageRange(req.StartPage, req.EndPage)
+	// Convert to 0-based page numbers
+	base := indexBase(req.OneBased)
+	startPage := max(req.StartPage-base, 0)
+	endPage := req.EndPage - base
+	if req.EndPage < 0 || (req.OneBased && req.EndPage == 0) {
+		endPage = 999999 // Set a large value to indicate reading to the end
+	}
 
-	// Read document
-	result, err := docreader.ReadDocumentWithConfig(req.FilePath, config)
+	// Read the whole document once; the page layout gives each page's global first line
+	full, err := readDocumentWithConfig(filePath, nil)
 	if err != nil {
 		return &ReadDocumentByPagesResponse{
 			ErrorMessage: fmt.Sprintf("Failed to read document: %v", err),
 		}, nil
 	}
+	firstLines := make(map[int]int, len(full.Pages))
+	for _, r := range pageLineRanges(full) {
+		firstLines[r.PageNumber] = r.FirstLine
+	}
+
+	result := applyReadConfig(full, docreader.NewReadConfig().WithPageRange(startPage, endPage))
 
 	// Build response
 	response := &ReadDocumentByPagesResponse{
 		Content:    result.Content,
 		TotalPages: result.TotalPages,
 		ReadPages:  len(result.Pages),
+		IndexBase:  base,
 		Metadata:   result.Metadata,
 	}
 
@@ -177,9 +235,10 @@ func ReadDocumentByPages(ctx context.Context, req *ReadDocumentByPagesRequest) (
 	response.Pages = make([]PageContentDetail, len(result.Pages))
 	for i, page := range result.Pages {
 		response.Pages[i] = PageContentDetail{
-			PageNumber: page.PageNumber,
+			PageNumber: page.PageNumber + base,
 			PageName:   page.PageName,
 			LineCount:  page.TotalLines,
+			FirstLine:  firstLines[page.PageNumber] + base,
 		}
 	}
 
@@ -188,41 +247,76 @@ func ReadDocumentByPages(ctx context.Context, req *ReadDocumentByPagesRequest) (
 
 // ReadDocumentByLines Read document by line range
 func ReadDocumentByLines(ctx context.Context, req *ReadDocumentByLinesRequest) (*ReadDocumentByLinesResponse, error) {
-	pageIndex := req.PageIndex
-	if pageIndex < 0 {
-		pageIndex = 0
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &ReadDocumentByLinesResponse{
+			ErrorMessage: fmt.Sprintf("Failed to access document: %v", err),
+		}, nil
 	}
 
-	// Set default values
-	endLine := req.EndLine
-	if endLine < 0 {
+	// Convert to 0-based line numbers
+	base := indexBase(req.OneBased)
+	startLine := max(req.StartLine-base, 0)
+	endLine := req.EndLine - base
+	if req.EndLine < 0 || (req.OneBased && req.EndLine == 0) {
 		endLine = 999999 // Set a large value to indicate reading to the end
 	}
 
-	// Create read configuration
-	config := docreader.NewReadConfig().
-		AddPageLineRange(pageIndex, req.StartLine, endLine)
-
-	// Read document
-	result, err := docreader.ReadDocumentWithConfig(req.FilePath, config)
+	full, err := readDocumentWithConfig(filePath, nil)
 	if err != nil {
 		return &ReadDocumentByLinesResponse{
 			ErrorMessage: fmt.Sprintf("Failed to read document: %v", err),
 		}, nil
 	}
 
-	// Build response
 	response := &ReadDocumentByLinesResponse{
-		Content:   result.Content,
-		PageIndex: pageIndex,
-		Metadata:  result.Metadata,
+		IndexBase: base,
+		Metadata:  full.Metadata,
+	}
+
+	if req.Global || req.PageIndex < 0 {
+		// Global mode: line numbers run across all pages
+		config, segments := globalLineConfig(full, startLine, endLine)
+		response.TotalLines = full.TotalLines
+		response.PageIndex = -1
+		if len(segments) == 0 {
+			return response, nil
+		}
+
+		result := applyReadConfig(full, config)
+		response.Content = result.Content
+		response.ReadLines = result.TotalLines
+		response.Segments = shiftSegments(segments, base)
+		return response, nil
 	}
 
+	pageIndex := max(req.PageIndex-base, 0)
+	result := applyReadConfig(full, docreader.NewReadConfig().AddPageLineRange(pageIndex, startLine, endLine))
+
+	response.Content = result.Content
+	response.PageIndex = pageIndex + base
+
 	// Get line information for this page
 	if len(result.Pages) > 0 {
 		page := result.Pages[0]
 		response.TotalLines = page.TotalLines
 		response.ReadLines = len(page.Lines)
+
+		if response.ReadLines > 0 {
+			for _, r := range pageLineRanges(full) {
+				if r.PageNumber != pageIndex {
+					continue
+				}
+				response.Segments = shiftSegments([]LineSegment{{
+					PageNumber:      pageIndex,
+					PageName:        page.PageName,
+					StartLine:       startLine,
+					EndLine:         startLine + response.ReadLines - 1,
+					GlobalStartLine: r.FirstLine + startLine,
+					GlobalEndLine:   r.FirstLine + startLine + response.ReadLines - 1,
+				}}, base)
+			}
+		}
 	}
 
 	return response, nil
@@ -230,6 +324,13 @@ func ReadDocumentByLines(ctx context.Context, req *ReadDocumentByLinesRequest) (
 
 // ReadDocumentSmart Smart document reading (automatically adapts to context limitations)
 func ReadDocumentSmart(ctx context.Context, req *ReadDocumentSmartRequest) (*ReadDocumentSmartResponse, error) {
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &ReadDocumentSmartResponse{
+			ErrorMessage: fmt.Sprintf("Failed to access document: %v", err),
+		}, nil
+	}
+
 	// Set default values
 	maxChars := req.MaxChars
 	if maxChars <= 0 {
@@ -243,12 +344,11 @@ func ReadDocumentSmart(ctx context.Context, req *ReadDocumentSmartRequest) (*Rea
 
 	// First read the complete document
 	var doc *docreader.Document
-	var err error
 
 	if cleanContent {
-		doc, err = docreader.ReadDocumentWithClean(req.FilePath)
+		doc, err = readDocumentWithClean(filePath)
 	} else {
-		doc, err = docreader.ReadDocument(req.FilePath)
+		doc, err = readDocument(filePath)
 	}
 
 	if err != nil {
@@ -261,8 +361,13 @@ func ReadDocumentSmart(ctx context.Context, req *ReadDocumentSmartRequest) (*Rea
 
 	response := &ReadDocumentSmartResponse{
 		OriginalSize: originalSize,
+		IndexBase:    indexBase(req.OneBased),
 		Metadata:     doc.Metadata,
 	}
+	if layout, err := readDocumentWithConfig(filePath, nil); err == nil {
+		response.TotalPages = layout.TotalPages
+		response.TotalLines = layout.TotalLines
+	}
 
 	// If document size is within limit, return all content directly
 	if originalSize <= maxChars {
@@ -286,6 +391,9 @@ func ReadDocumentSmart(ctx context.Context, req *ReadDocumentSmartRequest) (*Rea
 		response.Content = doc.Content[:maxChars]
 		response.Strategy = "Truncate from start"
 		response.Suggestion = fmt.Sprintf("Document is large (%d characters), only returning first %d characters. Recommend using ReadDocumentByPages or ReadDocumentByLines to read as needed", originalSize, maxChars)
+		if response.TotalLines > 0 {
+			response.Suggestion += fmt.Sprintf(" (ReadDocumentByLines with global=true addresses lines %d-%d across all pages)", response.IndexBase, response.TotalLines-1+response.IndexBase)
+		}
 	}
 
 	response.ReturnedSize = len(response.Content)
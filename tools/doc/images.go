@@ -0,0 +1,534 @@
+package doc
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// MaxImageBytes Upper bound on the size of an image returned by get_document_image (5MB)
+	MaxImageBytes = 5 * 1024 * 1024
+	// DefaultImageBytes Default size limit of a returned image (1MB)
+	DefaultImageBytes = 1024 * 1024
+	// DefaultImageDimension Default limit on the longest side of a returned image, in pixels
+	DefaultImageDimension = 1568
+	// MaxImagePixels Largest image decoded for downscaling (40 megapixels, 160MB decoded)
+	MaxImagePixels = 40_000_000
+	// MaxZipEntrySize Largest part of a zip-based document read into memory, such as an embedded image (50MB)
+	MaxZipEntrySize = 50 * 1024 * 1024
+)
+
+// imageEmbedPattern Relationship ID of an embedded picture in DrawingML
+var imageEmbedPattern = regexp.MustCompile(`r:embed="([^"]+)"`)
+
+// imageExtensions Extensions of media files treated as images
+var imageExtensions = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".bmp":  "image/bmp",
+	".tif":  "image/tiff",
+	".tiff": "image/tiff",
+	".webp": "image/webp",
+	".svg":  "image/svg+xml",
+	".emf":  "image/emf",
+	".wmf":  "image/wmf",
+}
+
+// ListDocumentImagesRequest List embedded images request
+type ListDocumentImagesRequest struct {
+	FilePath string `json:"file_path" jsonschema:"required,description:Document file path (DOCX, PPTX, XLSX, PDF, OpenDocument or EPUB)"`
+}
+
+// ListDocumentImagesResponse List embedded images response
+type ListDocumentImagesResponse struct {
+	FilePath     string              `json:"file_path" jsonschema:"description:File path"`
+	Images       []DocumentImageInfo `json:"images" jsonschema:"description:Embedded images in document order"`
+	TotalImages  int                 `json:"total_images" jsonschema:"description:Number of embedded images"`
+	ErrorMessage string              `json:"error_message,omitempty" jsonschema:"description:Error message"`
+}
+
+// DocumentImageInfo An embedded image and its position
+type DocumentImageInfo struct {
+	ImageID    string `json:"image_id" jsonschema:"description:Image ID to pass to get_document_image"`
+	PageNumber int    `json:"page_number" jsonschema:"description:Page or slide containing the image (0-based, -1 when unknown)"`
+	LineNumber int    `json:"line_number" jsonschema:"description:Approximate line near which the image appears (0-based, -1 when unknown)"`
+	Name       string `json:"name" jsonschema:"description:Name of the image inside the document"`
+	MIMEType   string `json:"mime_type" jsonschema:"description:Image MIME type"`
+	Size       int64  `json:"size" jsonschema:"description:Stored image size in bytes"`
+	Width      int    `json:"width,omitempty" jsonschema:"description:Width in pixels, when known"`
+	Height     int    `json:"height,omitempty" jsonschema:"description:Height in pixels, when known"`
+}
+
+// GetDocumentImageRequest Get embedded image request
+type GetDocumentImageRequest struct {
+	FilePath     string `json:"file_path" jsonschema:"required,description:Document file path"`
+	ImageID      string `json:"image_id" jsonschema:"required,description:Image ID returned by list_document_images"`
+	MaxBytes     int    `json:"max_bytes,omitempty" jsonschema:"description:Maximum size of the returned image in bytes (default 1048576, at most 5242880); larger images are downscaled"`
+	MaxDimension int    `json:"max_dimension,omitempty" jsonschema:"description:Maximum width or height in pixels (default 1568); larger images are downscaled"`
+}
+
+// GetDocumentImageResponse Get embedded image response. The image itself is returned as MCP image content.
+type GetDocumentImageResponse struct {
+	Image          DocumentImageInfo `json:"image" jsonschema:"description:Image information"`
+	MIMEType       string            `json:"mime_type" jsonschema:"description:MIME type of the returned image"`
+	ReturnedSize   int               `json:"returned_size" jsonschema:"description:Returned image size in bytes"`
+	ReturnedWidth  int               `json:"returned_width,omitempty" jsonschema:"description:Returned width in pixels"`
+	ReturnedHeight int               `json:"returned_height,omitempty" jsonschema:"description:Returned height in pixels"`
+	Downscaled     bool              `json:"downscaled" jsonschema:"description:Whether the image was downscaled to fit the limits"`
+	ErrorMessage   string            `json:"error_message,omitempty" jsonschema:"description:Error message"`
+
+	data []byte
+}
+
+// ToolContent Return the image as MCP image content
+func (r *GetDocumentImageResponse) ToolContent() []mcp.Content {
+	if r == nil || len(r.data) == 0 {
+		return nil
+	}
+	return []mcp.Content{&mcp.ImageContent{Data: r.data, MIMEType: r.MIMEType}}
+}
+
+// documentImage An enumerated image with a loader for its bytes
+type documentImage struct {
+	info DocumentImageInfo
+	load func() ([]byte, string, error)
+}
+
+// ListDocumentImages List embedded images with their positions
+func ListDocumentImages(ctx context.Context, req *ListDocumentImagesRequest) (*ListDocumentImagesResponse, error) {
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &ListDocumentImagesResponse{ErrorMessage: fmt.Sprintf("Failed to access document: %v", err)}, nil
+	}
+
+	images, err := collectDocumentImages(filePath)
+	if err != nil {
+		return &ListDocumentImagesResponse{FilePath: req.FilePath, ErrorMessage: fmt.Sprintf("Failed to list images: %v", err)}, nil
+	}
+
+	response := &ListDocumentImagesResponse{
+		FilePath:    req.FilePath,
+		Images:      make([]DocumentImageInfo, len(images)),
+		TotalImages: len(images),
+	}
+	for i, img := range images {
+		response.Images[i] = img.info
+	}
+
+	return response, nil
+}
+
+// GetDocumentImage Return one embedded image as image content, downscaled to fit the limits
+func GetDocumentImage(ctx context.Context, req *GetDocumentImageRequest) (*GetDocumentImageResponse, error) {
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &GetDocumentImageResponse{ErrorMessage: fmt.Sprintf("Failed to access document: %v", err)}, nil
+	}
+
+	images, err := collectDocumentImages(filePath)
+	if err != nil {
+		return &GetDocumentImageResponse{ErrorMessage: fmt.Sprintf("Failed to list images: %v", err)}, nil
+	}
+
+	var selected *documentImage
+	for i := range images {
+		if images[i].info.ImageID == req.ImageID {
+			selected = &images[i]
+			break
+		}
+	}
+	if selected == nil {
+		return &GetDocumentImageResponse{ErrorMessage: fmt.Sprintf("Image %q not found, use list_document_images to get valid IDs", req.ImageID)}, nil
+	}
+
+	response := &GetDocumentImageResponse{Image: selected.info}
+
+	data, mimeType, err := selected.load()
+	if err != nil {
+		response.ErrorMessage = fmt.Sprintf("Failed to extract image: %v", err)
+		return response, nil
+	}
+
+	maxBytes := req.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultImageBytes
+	}
+	maxBytes = min(maxBytes, MaxImageBytes)
+
+	maxDimension := req.MaxDimension
+	if maxDimension <= 0 {
+		maxDimension = DefaultImageDimension
+	}
+
+	data, mimeType, width, height, downscaled, err := fitImage(data, mimeType, maxBytes, maxDimension)
+	if err != nil {
+		response.ErrorMessage = err.Error()
+		return response, nil
+	}
+
+	response.data = data
+	response.MIMEType = mimeType
+	response.ReturnedSize = len(data)
+	response.ReturnedWidth = width
+	response.ReturnedHeight = height
+	response.Downscaled = downscaled
+
+	return response, nil
+}
+
+// collectDocumentImages Enumerate the images of a document in order and assign IDs
+func collectDocumentImages(filePath string) ([]documentImage, error) {
+	var images []documentImage
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".docx":
+		images, err = docxImages(filePath)
+	case ".pptx":
+		images, err = pptxImages(filePath)
+	case ".pdf":
+		images, err = pdfImages(filePath)
+	case ".xlsx", ".odt", ".ods", ".odp", ".epub":
+		images, err = zipMediaImages(filePath, nil)
+	default:
+		return nil, fmt.Errorf("image extraction is not supported for %s files", filepath.Ext(filePath))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range images {
+		images[i].info.ImageID = fmt.Sprintf("img-%d", i+1)
+	}
+	return images, nil
+}
+
+// docxImages Images referenced from the document body, then any other media in the package
+func docxImages(filePath string) ([]documentImage, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	defer zipReader.Close()
+
+	files := zipFileIndex(&zipReader.Reader)
+	rels, err := zipRelationships(files, "word/_rels/document.xml.rels", "word")
+	if err != nil {
+		return nil, err
+	}
+
+	var doc struct {
+		Body struct {
+			Paragraphs []struct {
+				Runs []struct {
+					Text string `xml:"t"`
+				} `xml:"r"`
+				Inner string `xml:",innerxml"`
+			} `xml:"p"`
+			Tables []struct {
+				Inner string `xml:",innerxml"`
+			} `xml:"tbl"`
+		} `xml:"body"`
+	}
+	if err := unmarshalZipFile(files["word/document.xml"], &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse document.xml: %w", err)
+	}
+
+	var images []documentImage
+	seen := make(map[string]bool)
+	add := func(inner string, line int) {
+		for _, match := range imageEmbedPattern.FindAllStringSubmatch(inner, -1) {
+			target, ok := rels[match[1]]
+			if !ok || files[target] == nil {
+				continue
+			}
+			if img, ok := zipImage(filePath, files[target], 0, line); ok {
+				images = append(images, img)
+				seen[target] = true
+			}
+		}
+	}
+
+	// Line numbers follow docreader: non-empty body paragraphs first, then tables
+	line := 0
+	for _, para := range doc.Body.Paragraphs {
+		add(para.Inner, line)
+		for _, run := range para.Runs {
+			if run.Text != "" {
+				line++
+				break
+			}
+		}
+	}
+	for _, table := range doc.Body.Tables {
+		add(table.Inner, line)
+	}
+
+	// Headers, footers and unreferenced media
+	rest, err := zipMediaImages(filePath, func(name string) bool {
+		return strings.HasPrefix(name, "word/media/") && !seen[name]
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(images, rest...), nil
+}
+
+// pptxImages Images of each slide in slide order
+func pptxImages(filePath string) ([]documentImage, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	defer zipReader.Close()
+
+	files := zipFileIndex(&zipReader.Reader)
+
+	slidePattern := regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)
+	type slide struct {
+		number int
+		name   string
+	}
+	var slides []slide
+	for name := range files {
+		if match := slidePattern.FindStringSubmatch(name); match != nil {
+			number, _ := strconv.Atoi(match[1])
+			slides = append(slides, slide{number, name})
+		}
+	}
+	sort.Slice(slides, func(i, j int) bool { return slides[i].number < slides[j].number })
+
+	var images []documentImage
+	for page, s := range slides {
+		rels, err := zipRelationships(files, "ppt/slides/_rels/"+path.Base(s.name)+".rels", "ppt/slides")
+		if err != nil {
+			return nil, err
+		}
+		data, err := readZipFile(files[s.name])
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range imageEmbedPattern.FindAllStringSubmatch(string(data), -1) {
+			target, ok := rels[match[1]]
+			if !ok || files[target] == nil {
+				continue
+			}
+			if img, ok := zipImage(filePath, files[target], page, -1); ok {
+				images = append(images, img)
+			}
+		}
+	}
+
+	return images, nil
+}
+
+// zipMediaImages All image files in a zip-based document, optionally filtered by name
+func zipMediaImages(filePath string, include func(name string) bool) ([]documentImage, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	defer zipReader.Close()
+
+	var images []documentImage
+	for _, file := range zipReader.File {
+		if include != nil && !include(file.Name) {
+			continue
+		}
+		if img, ok := zipImage(filePath, file, -1, -1); ok {
+			images = append(images, img)
+		}
+	}
+	return images, nil
+}
+
+// zipImage Describe an image zip entry; ok is false for non-image entries
+func zipImage(filePath string, file *zip.File, page, line int) (documentImage, bool) {
+	mimeType, ok := imageExtensions[strings.ToLower(path.Ext(file.Name))]
+	if !ok {
+		return documentImage{}, false
+	}
+
+	info := DocumentImageInfo{
+		PageNumber: page,
+		LineNumber: line,
+		Name:       file.Name,
+		MIMEType:   mimeType,
+		Size:       int64(file.UncompressedSize64),
+	}
+	if rc, err := file.Open(); err == nil {
+		if config, _, err := image.DecodeConfig(rc); err == nil {
+			info.Width, info.Height = config.Width, config.Height
+		}
+		rc.Close()
+	}
+
+	name := file.Name
+	return documentImage{
+		info: info,
+		load: func() ([]byte, string, error) {
+			data, err := readZipEntry(filePath, name)
+			return data, mimeType, err
+		},
+	}, true
+}
+
+// zipFileIndex Index zip entries by name
+func zipFileIndex(reader *zip.Reader) map[string]*zip.File {
+	files := make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		files[file.Name] = file
+	}
+	return files
+}
+
+// zipRelationships Map relationship IDs to package paths, resolving targets relative to baseDir
+func zipRelationships(files map[string]*zip.File, relsName, baseDir string) (map[string]string, error) {
+	rels := make(map[string]string)
+	file := files[relsName]
+	if file == nil {
+		return rels, nil
+	}
+
+	var parsed struct {
+		Relationships []struct {
+			ID         string `xml:"Id,attr"`
+			Target     string `xml:"Target,attr"`
+			TargetMode string `xml:"TargetMode,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := unmarshalZipFile(file, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", relsName, err)
+	}
+
+	for _, rel := range parsed.Relationships {
+		if rel.TargetMode == "External" {
+			continue
+		}
+		target := rel.Target
+		if strings.HasPrefix(target, "/") {
+			target = strings.TrimPrefix(target, "/")
+		} else {
+			target = path.Join(baseDir, target)
+		}
+		rels[rel.ID] = target
+	}
+	return rels, nil
+}
+
+// fitImage Downscale an image until it fits maxDimension and maxBytes.
+// Images that cannot be decoded (e.g. SVG, EMF) are returned as-is when they fit maxBytes.
+func fitImage(data []byte, mimeType string, maxBytes, maxDimension int) ([]byte, string, int, int, bool, error) {
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		if len(data) > maxBytes {
+			return nil, "", 0, 0, false, fmt.Errorf("image is %s and %s images cannot be downscaled; raise max_bytes (limit %s)", formatFileSize(int64(len(data))), mimeType, formatFileSize(MaxImageBytes))
+		}
+		return data, mimeType, 0, 0, false, nil
+	}
+	// The header is checked before decoding so a small file cannot expand into a huge bitmap
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return nil, "", 0, 0, false, fmt.Errorf("image is %dx%d pixels, larger than the %d pixel limit", config.Width, config.Height, MaxImagePixels)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", 0, 0, false, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if len(data) <= maxBytes && max(width, height) <= maxDimension {
+		if mimeType == "" || mimeType == "application/octet-stream" {
+			mimeType = "image/" + format
+		}
+		return data, mimeType, width, height, false, nil
+	}
+
+	scale := 1.0
+	if longest := max(width, height); longest > maxDimension {
+		scale = float64(maxDimension) / float64(longest)
+	}
+
+	for attempt := 0; attempt < 6; attempt++ {
+		scaled := downscaleImage(img, max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1))
+		encoded, encodedType, err := encodeImage(scaled, format)
+		if err != nil {
+			return nil, "", 0, 0, false, fmt.Errorf("failed to encode image: %w", err)
+		}
+		if len(encoded) <= maxBytes {
+			b := scaled.Bounds()
+			return encoded, encodedType, b.Dx(), b.Dy(), true, nil
+		}
+		scale *= 0.7
+	}
+
+	return nil, "", 0, 0, false, fmt.Errorf("image could not be reduced below %s, raise max_bytes", formatFileSize(int64(maxBytes)))
+}
+
+// encodeImage Encode photos as JPEG and everything else as PNG
+func encodeImage(img image.Image, format string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// downscaleImage Resize by averaging the source pixels covered by each target pixel
+func downscaleImage(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(bounds.Min.Y+(y+1)*bounds.Dy()/height, y0+1)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(bounds.Min.X+(x+1)*bounds.Dx()/width, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBAModel.Convert(src.At(sx, sy)).(color.NRGBA)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: uint8(a / n)})
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,294 @@
+package doc
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// maxPDFFormDepth How deep image lookup descends into nested form XObjects
+const maxPDFFormDepth = 3
+
+// pdfImages Image XObjects used on each page, in page order.
+// JPEG (DCTDecode) images are returned as stored; Flate-compressed or raw 8-bit
+// Gray/RGB/CMYK images are converted to PNG. Other encodings are listed but cannot be extracted.
+func pdfImages(filePath string) (images []documentImage, err error) {
+	f, reader, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	// The PDF library panics on damaged page trees and resources
+	defer func() {
+		if r := recover(); r != nil {
+			images, err = nil, fmt.Errorf("damaged PDF: %v", r)
+		}
+	}()
+
+	for pageNumber := 1; pageNumber <= reader.NumPage(); pageNumber++ {
+		page := reader.Page(pageNumber)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, ref := range pdfPageImages(page.Resources(), nil, 0) {
+			images = append(images, pdfImage(filePath, reader, pageNumber, ref))
+		}
+	}
+
+	return images, nil
+}
+
+// pdfImagePath Names of the XObjects leading from a page's resources to an image
+type pdfImagePath []string
+
+// pdfPageImages Find image XObjects in resources, descending into form XObjects
+func pdfPageImages(resources pdf.Value, prefix pdfImagePath, depth int) []pdfImagePath {
+	xobjects := resources.Key("XObject")
+	keys := xobjects.Keys()
+	sort.Strings(keys)
+
+	var paths []pdfImagePath
+	for _, name := range keys {
+		xobject := xobjects.Key(name)
+		current := append(append(pdfImagePath{}, prefix...), name)
+		switch xobject.Key("Subtype").Name() {
+		case "Image":
+			paths = append(paths, current)
+		case "Form":
+			if depth < maxPDFFormDepth {
+				paths = append(paths, pdfPageImages(xobject.Key("Resources"), current, depth+1)...)
+			}
+		}
+	}
+	return paths
+}
+
+// lookupPDFImage Follow an image path on a page
+func lookupPDFImage(reader *pdf.Reader, pageNumber int, ref pdfImagePath) pdf.Value {
+	value := reader.Page(pageNumber).Resources()
+	var xobject pdf.Value
+	for i, name := range ref {
+		xobject = value.Key("XObject").Key(name)
+		if i < len(ref)-1 {
+			value = xobject.Key("Resources")
+		}
+	}
+	return xobject
+}
+
+// pdfImage Describe an image XObject and build its loader
+func pdfImage(filePath string, reader *pdf.Reader, pageNumber int, ref pdfImagePath) documentImage {
+	xobject := lookupPDFImage(reader, pageNumber, ref)
+	info := DocumentImageInfo{
+		PageNumber: pageNumber - 1,
+		LineNumber: -1,
+		Name:       fmt.Sprintf("page %d /%s", pageNumber, joinImagePath(ref)),
+		MIMEType:   "image/png",
+		Size:       xobject.Key("Length").Int64(),
+		Width:      int(xobject.Key("Width").Int64()),
+		Height:     int(xobject.Key("Height").Int64()),
+	}
+	switch pdfImageFilter(xobject) {
+	case "DCTDecode":
+		info.MIMEType = "image/jpeg"
+	case "JPXDecode":
+		info.MIMEType = "image/jp2"
+	}
+
+	return documentImage{
+		info: info,
+		load: func() ([]byte, string, error) {
+			return extractPDFImage(filePath, pageNumber, ref)
+		},
+	}
+}
+
+// joinImagePath Render an image path as /Form/Image
+func joinImagePath(ref pdfImagePath) string {
+	var buf bytes.Buffer
+	for i, name := range ref {
+		if i > 0 {
+			buf.WriteString("/")
+		}
+		buf.WriteString(name)
+	}
+	return buf.String()
+}
+
+// pdfImageFilter The last filter of a stream, which determines the image encoding
+func pdfImageFilter(xobject pdf.Value) string {
+	filter := xobject.Key("Filter")
+	switch filter.Kind() {
+	case pdf.Name:
+		return filter.Name()
+	case pdf.Array:
+		if filter.Len() > 0 {
+			return filter.Index(filter.Len() - 1).Name()
+		}
+	}
+	return ""
+}
+
+// extractPDFImage Read one image XObject as JPEG or PNG bytes
+func extractPDFImage(filePath string, pageNumber int, ref pdfImagePath) (data []byte, mimeType string, err error) {
+	f, reader, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	// The PDF library panics on stream filters it does not implement
+	defer func() {
+		if r := recover(); r != nil {
+			data, mimeType, err = nil, "", fmt.Errorf("unsupported image encoding: %v", r)
+		}
+	}()
+
+	xobject := lookupPDFImage(reader, pageNumber, ref)
+	width := int(xobject.Key("Width").Int64())
+	height := int(xobject.Key("Height").Int64())
+
+	switch filter := pdfImageFilter(xobject); filter {
+	case "DCTDecode":
+		data, err := findStoredJPEG(filePath, xobject.Key("Length").Int64(), width, height)
+		return data, "image/jpeg", err
+	case "", "FlateDecode":
+		// Raw samples
+	default:
+		return nil, "", fmt.Errorf("unsupported image encoding %s", filter)
+	}
+
+	components, err := pdfImageComponents(xobject)
+	if err != nil {
+		return nil, "", err
+	}
+	if width <= 0 || height <= 0 || int64(width)*int64(height) > MaxImagePixels {
+		return nil, "", fmt.Errorf("image is %dx%d pixels, outside the %d pixel limit", width, height, MaxImagePixels)
+	}
+
+	// Read no more than the declared size so a compressed stream cannot expand without bound
+	size := int64(width) * int64(height) * int64(components)
+	rc := xobject.Reader()
+	defer rc.Close()
+	samples, err := io.ReadAll(io.LimitReader(rc, size+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image data: %w", err)
+	}
+	if int64(len(samples)) > size {
+		return nil, "", fmt.Errorf("image data is larger than its declared %dx%d size", width, height)
+	}
+
+	img, err := pdfSamplesToImage(samples, width, height, components)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), "image/png", nil
+}
+
+// pdfImageComponents Number of 8-bit components per pixel of a Gray, RGB or CMYK image
+func pdfImageComponents(xobject pdf.Value) (int, error) {
+	if bits := xobject.Key("BitsPerComponent").Int64(); bits != 8 {
+		return 0, fmt.Errorf("unsupported image depth of %d bits per component", bits)
+	}
+
+	colorSpace := xobject.Key("ColorSpace")
+	components := 0
+	switch colorSpace.Kind() {
+	case pdf.Name:
+		switch colorSpace.Name() {
+		case "DeviceGray", "CalGray":
+			components = 1
+		case "DeviceRGB", "CalRGB":
+			components = 3
+		case "DeviceCMYK":
+			components = 4
+		}
+	case pdf.Array:
+		if colorSpace.Index(0).Name() == "ICCBased" {
+			components = int(colorSpace.Index(1).Key("N").Int64())
+		}
+	}
+	if components == 0 {
+		return 0, fmt.Errorf("unsupported image color space %v", colorSpace)
+	}
+	return components, nil
+}
+
+// pdfSamplesToImage Build an image from 8-bit samples with the given number of components
+func pdfSamplesToImage(samples []byte, width, height, components int) (image.Image, error) {
+	if len(samples) < width*height*components {
+		return nil, fmt.Errorf("image data is truncated")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < width*height; i++ {
+		s := samples[i*components : (i+1)*components]
+		var c color.NRGBA
+		switch components {
+		case 1:
+			c = color.NRGBA{s[0], s[0], s[0], 255}
+		case 3:
+			c = color.NRGBA{s[0], s[1], s[2], 255}
+		case 4:
+			r, g, b := color.CMYKToRGB(s[0], s[1], s[2], s[3])
+			c = color.NRGBA{r, g, b, 255}
+		}
+		img.SetNRGBA(i%width, i/width, c)
+	}
+	return img, nil
+}
+
+// findStoredJPEG Locate a DCTDecode stream in the raw file. The PDF library cannot return
+// undecoded stream bytes, so candidates are found by their JPEG signature right after a
+// stream keyword and matched on length and dimensions.
+func findStoredJPEG(filePath string, length int64, width, height int) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyword := []byte("stream")
+	for offset := 0; ; {
+		index := bytes.Index(data[offset:], keyword)
+		if index < 0 {
+			break
+		}
+		start := offset + index + len(keyword)
+		offset = start
+
+		// The keyword is followed by CRLF or LF
+		if start < len(data) && data[start] == '\r' {
+			start++
+		}
+		if start < len(data) && data[start] == '\n' {
+			start++
+		}
+		if !bytes.HasPrefix(data[start:], []byte{0xFF, 0xD8, 0xFF}) {
+			continue
+		}
+
+		end := start + int(length)
+		if length <= 0 || end > len(data) {
+			continue
+		}
+		candidate := data[start:end]
+		if config, _, err := image.DecodeConfig(bytes.NewReader(candidate)); err == nil && config.Width == width && config.Height == height {
+			return candidate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("JPEG data not found (the PDF may be encrypted)")
+}
@@ -0,0 +1,383 @@
+package doc
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// maxListLimit Upper bound on entries returned by one list_documents call
+	maxListLimit = 1000
+	// maxListScan Upper bound on files examined by one list_documents call
+	maxListScan = 50000
+)
+
+// ListDocumentsRequest Document discovery request
+type ListDocumentsRequest struct {
+	Root          string `json:"root,omitempty" jsonschema:"description:Directory to list; must be one of the configured document roots or inside one (default all roots)"`
+	Pattern       string `json:"pattern,omitempty" jsonschema:"description:Glob on the path relative to the root, e.g. *.pdf or reports/**/*.docx (without a slash the pattern matches file names at any depth)"`
+	MaxDepth      int    `json:"max_depth,omitempty" jsonschema:"description:How many directory levels below the root to descend (default 5, -1 for unlimited)"`
+	SortBy        string `json:"sort_by,omitempty" jsonschema:"description:Sort order: name (default), mtime or size"`
+	Descending    bool   `json:"descending,omitempty" jsonschema:"description:Reverse the sort order (default false)"`
+	IncludeAll    bool   `json:"include_all,omitempty" jsonschema:"description:Also list files in formats the doc tools cannot read (default false)"`
+	IncludeHidden bool   `json:"include_hidden,omitempty" jsonschema:"description:Include files and directories starting with a dot (default false)"`
+	Offset        int    `json:"offset,omitempty" jsonschema:"description:Number of entries to skip (default 0)"`
+	Limit         int    `json:"limit,omitempty" jsonschema:"description:Maximum entries to return (default 100, at most 1000)"`
+}
+
+// ListDocumentsResponse Document discovery response
+type ListDocumentsResponse struct {
+	Roots        []string        `json:"roots" jsonschema:"description:Directories that were listed"`
+	Entries      []DocumentEntry `json:"entries" jsonschema:"description:Matching documents"`
+	Total        int             `json:"total" jsonschema:"description:Number of matching documents"`
+	Offset       int             `json:"offset" jsonschema:"description:Offset of the first returned entry"`
+	HasMore      bool            `json:"has_more" jsonschema:"description:Whether more entries follow"`
+	NextOffset   int             `json:"next_offset,omitempty" jsonschema:"description:Offset to request the next page"`
+	IsTruncated  bool            `json:"is_truncated" jsonschema:"description:Whether the scan stopped early because the tree is very large; narrow root or pattern"`
+	ErrorMessage string          `json:"error_message,omitempty" jsonschema:"description:Error message"`
+}
+
+// DocumentEntry A discovered document
+type DocumentEntry struct {
+	Path      string `json:"path" jsonschema:"description:Absolute path, usable as file_path in other doc tools"`
+	Name      string `json:"name" jsonschema:"description:Path relative to its root"`
+	FileType  string `json:"file_type" jsonschema:"description:File type (e.g., PDF, DOCX, XLSX)"`
+	FileSize  string `json:"file_size" jsonschema:"description:File size (formatted string)"`
+	SizeBytes int64  `json:"size_bytes" jsonschema:"description:File size in bytes"`
+	Modified  string `json:"modified" jsonschema:"description:Modification time (RFC 3339)"`
+	PageHint  int    `json:"page_hint,omitempty" jsonschema:"description:Page, slide or sheet count from document properties, when available"`
+	Supported bool   `json:"supported" jsonschema:"description:Whether the doc tools can read this format"`
+
+	modTime time.Time
+}
+
+// ListDocuments List documents under the configured roots
+func ListDocuments(ctx context.Context, req *ListDocumentsRequest) (*ListDocumentsResponse, error) {
+	roots, err := listRoots(req.Root)
+	if err != nil {
+		return &ListDocumentsResponse{ErrorMessage: err.Error()}, nil
+	}
+
+	maxDepth := req.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = 5
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	limit = min(limit, maxListLimit)
+
+	var match func(rel string) bool
+	if req.Pattern != "" {
+		match, err = globMatcher(req.Pattern)
+		if err != nil {
+			return &ListDocumentsResponse{Roots: roots, ErrorMessage: fmt.Sprintf("Invalid pattern: %v", err)}, nil
+		}
+	}
+
+	supported := make(map[string]bool)
+	for _, ext := range SupportedFormats() {
+		supported[ext] = true
+	}
+
+	response := &ListDocumentsResponse{Roots: roots}
+	var entries []DocumentEntry
+	scanned := 0
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				// Unreadable directories are skipped rather than failing the listing
+				if entry != nil && entry.IsDir() && path != root {
+					return fs.SkipDir
+				}
+				return nil
+			}
+
+			rel, _ := filepath.Rel(root, path)
+			if rel == "." {
+				return nil
+			}
+			if !req.IncludeHidden && strings.HasPrefix(entry.Name(), ".") {
+				if entry.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+
+			if entry.IsDir() {
+				if maxDepth >= 0 && strings.Count(rel, string(filepath.Separator)) >= maxDepth {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+
+			scanned++
+			if scanned > maxListScan {
+				response.IsTruncated = true
+				return fs.SkipAll
+			}
+
+			ext := strings.ToLower(filepath.Ext(path))
+			if !req.IncludeAll && !supported[ext] {
+				return nil
+			}
+			if match != nil && !match(filepath.ToSlash(rel)) {
+				return nil
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return nil
+			}
+			entries = append(entries, DocumentEntry{
+				Path:      path,
+				Name:      filepath.ToSlash(rel),
+				FileType:  strings.ToUpper(strings.TrimPrefix(ext, ".")),
+				FileSize:  formatFileSize(info.Size()),
+				SizeBytes: info.Size(),
+				Modified:  info.ModTime().Format(time.RFC3339),
+				Supported: supported[ext],
+				modTime:   info.ModTime(),
+			})
+			return nil
+		})
+		if err != nil && ctx.Err() != nil {
+			return &ListDocumentsResponse{Roots: roots, ErrorMessage: fmt.Sprintf("Listing cancelled: %v", err)}, nil
+		}
+	}
+
+	sortDocumentEntries(entries, req.SortBy, req.Descending)
+
+	response.Total = len(entries)
+	response.Offset = max(req.Offset, 0)
+	end := min(response.Offset+limit, len(entries))
+	if response.Offset < end {
+		response.Entries = entries[response.Offset:end]
+	} else {
+		response.Entries = []DocumentEntry{}
+	}
+	if end < len(entries) {
+		response.HasMore = true
+		response.NextOffset = end
+	}
+
+	// Page hints only for the returned page, so large listings stay cheap
+	for i := range response.Entries {
+		response.Entries[i].PageHint = pageCountHint(response.Entries[i].Path)
+	}
+
+	return response, nil
+}
+
+// listRoots Resolve the roots to list, restricting root to the configured document roots
+func listRoots(root string) ([]string, error) {
+	configured := documentRoots
+	if len(configured) == 0 {
+		configured = []string{"."}
+	}
+
+	var roots []string
+	for _, dir := range configured {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid document root %s: %w", dir, err)
+		}
+		roots = append(roots, abs)
+	}
+
+	if root == "" {
+		return roots, nil
+	}
+
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("invalid root %s: %w", root, err)
+	}
+	for _, allowed := range roots {
+		rel, err := filepath.Rel(allowed, abs)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+				return nil, fmt.Errorf("%s is not a directory", root)
+			}
+			return []string{abs}, nil
+		}
+	}
+	return nil, fmt.Errorf("%s is outside the document roots (%s)", root, strings.Join(roots, ", "))
+}
+
+// globMatcher Compile a glob into a matcher on slash-separated relative paths.
+// "**" matches any number of directories; a pattern without "/" matches the base name.
+func globMatcher(pattern string) (func(string) bool, error) {
+	if !strings.Contains(pattern, "/") {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, err
+		}
+		return func(rel string) bool {
+			ok, _ := filepath.Match(pattern, rel[strings.LastIndex(rel, "/")+1:])
+			return ok
+		}, nil
+	}
+
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			if strings.HasPrefix(pattern[i:], "**/") {
+				expr.WriteString("(?:.*/)?")
+				i += 2
+			} else if strings.HasPrefix(pattern[i:], "**") {
+				expr.WriteString(".*")
+				i++
+			} else {
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expr.WriteString("$")
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// sortDocumentEntries Sort by name, mtime or size
+func sortDocumentEntries(entries []DocumentEntry, sortBy string, descending bool) {
+	less := func(a, b DocumentEntry) bool { return a.Name < b.Name }
+	switch sortBy {
+	case "mtime":
+		less = func(a, b DocumentEntry) bool { return a.modTime.Before(b.modTime) }
+	case "size":
+		less = func(a, b DocumentEntry) bool { return a.SizeBytes < b.SizeBytes }
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if descending {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+}
+
+// pageCountHint Read a page count from document properties without parsing the content; 0 when unknown
+func pageCountHint(filePath string) (count int) {
+	// The PDF library panics on damaged files; one bad file must not stop a listing
+	defer func() {
+		if recover() != nil {
+			count = 0
+		}
+	}()
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".pdf":
+		f, reader, err := pdf.Open(filePath)
+		if err != nil {
+			return 0
+		}
+		defer f.Close()
+		return reader.NumPage()
+	case ".docx":
+		return officeAppProperty(filePath, "Pages")
+	case ".pptx":
+		return officeAppProperty(filePath, "Slides")
+	case ".xlsx":
+		return xlsxSheetCount(filePath)
+	case ".odt", ".ods", ".odp":
+		return odfPageCount(filePath)
+	}
+	return 0
+}
+
+// officeAppProperty Read an integer from docProps/app.xml (written by Office when saving)
+func officeAppProperty(filePath, name string) int {
+	data, err := readZipEntry(filePath, "docProps/app.xml")
+	if err != nil {
+		return 0
+	}
+
+	decoder := xml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0
+		}
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == name {
+			var value string
+			if err := decoder.DecodeElement(&value, &start); err != nil {
+				return 0
+			}
+			count, _ := strconv.Atoi(strings.TrimSpace(value))
+			return count
+		}
+	}
+}
+
+// xlsxSheetCount Count the sheets declared in the workbook
+func xlsxSheetCount(filePath string) int {
+	data, err := readZipEntry(filePath, "xl/workbook.xml")
+	if err != nil {
+		return 0
+	}
+	var workbook struct {
+		Sheets []struct{} `xml:"sheets>sheet"`
+	}
+	if err := xml.Unmarshal(data, &workbook); err != nil {
+		return 0
+	}
+	return len(workbook.Sheets)
+}
+
+// odfPageCount Read page, table or object counts from meta.xml document statistics
+func odfPageCount(filePath string) int {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return 0
+	}
+	defer zipReader.Close()
+
+	var meta struct {
+		Meta struct {
+			Statistic struct {
+				PageCount  string `xml:"page-count,attr"`
+				TableCount string `xml:"table-count,attr"`
+			} `xml:"document-statistic"`
+		} `xml:"meta"`
+	}
+	if err := unmarshalZipFile(zipFileIndex(&zipReader.Reader)["meta.xml"], &meta); err != nil {
+		return 0
+	}
+
+	count := meta.Meta.Statistic.PageCount
+	if strings.EqualFold(filepath.Ext(filePath), ".ods") {
+		count = meta.Meta.Statistic.TableCount
+	}
+	n, _ := strconv.Atoi(count)
+	return n
+}
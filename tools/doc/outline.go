@@ -0,0 +1,562 @@
+package doc
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+	"github.com/wsshow/docreader"
+)
+
+// OutlineNode A single heading in the document outline
+type OutlineNode struct {
+	ID         string `json:"id" jsonschema:"description:Outline node ID (hierarchical, e.g. 1, 1.2, 1.2.3), usable with read_document_section"`
+	Title      string `json:"title" jsonschema:"description:Heading title"`
+	Level      int    `json:"level" jsonschema:"description:Heading level (1 is the top level)"`
+	PageNumber int    `json:"page_number" jsonschema:"description:Page number where the heading is located (0-based, -1 if the position could not be determined)"`
+	LineNumber int    `json:"line_number" jsonschema:"description:Line number of the heading within its page (0-based, -1 if the position could not be determined)"`
+	Source     string `json:"source" jsonschema:"description:Where the heading was detected (style, markdown, bookmark, slide)"`
+}
+
+// GetDocumentOutlineRequest Document outline request
+type GetDocumentOutlineRequest struct {
+	FilePath string `json:"file_path" jsonschema:"required,description:Document file path (headings are detected for .docx, .md, .html, .epub, .odt, .pdf with bookmarks and .pptx)"`
+	MaxLevel int    `json:"max_level,omitempty" jsonschema:"description:Maximum heading level to return (default 0 means all levels)"`
+}
+
+// GetDocumentOutlineResponse Document outline response
+type GetDocumentOutlineResponse struct {
+	FilePath     string        `json:"file_path" jsonschema:"description:File path"`
+	Nodes        []OutlineNode `json:"nodes" jsonschema:"description:Outline nodes in document order"`
+	TotalPages   int           `json:"total_pages" jsonschema:"description:Total number of pages in document"`
+	Message      string        `json:"message,omitempty" jsonschema:"description:Status message"`
+	ErrorMessage string        `json:"error_message,omitempty" jsonschema:"description:Error message"`
+}
+
+// ReadDocumentSectionRequest Read document section request
+type ReadDocumentSectionRequest struct {
+	FilePath string `json:"file_path" jsonschema:"required,description:Document file path"`
+	NodeID   string `json:"node_id,omitempty" jsonschema:"description:Outline node ID returned by get_document_outline (takes precedence over title)"`
+	Title    string `json:"title,omitempty" jsonschema:"description:Heading title to read (case-insensitive, exact match preferred, otherwise first partial match)"`
+	MaxChars int    `json:"max_chars,omitempty" jsonschema:"description:Maximum character limit (default 50000)"`
+}
+
+// ReadDocumentSectionResponse Read document section response
+type ReadDocumentSectionResponse struct {
+	Content      string            `json:"content" jsonschema:"description:Section content, including the heading line and all subsections"`
+	Node         *OutlineNode      `json:"node,omitempty" jsonschema:"description:Outline node that was read"`
+	StartPage    int               `json:"start_page" jsonschema:"description:Page where the section starts (0-based)"`
+	StartLine    int               `json:"start_line" jsonschema:"description:Line where the section starts within the start page (0-based)"`
+	EndPage      int               `json:"end_page" jsonschema:"description:Page where the section ends (0-based, inclusive)"`
+	EndLine      int               `json:"end_line" jsonschema:"description:Line where the section ends within the end page (0-based, inclusive)"`
+	ReadLines    int               `json:"read_lines" jsonschema:"description:Number of lines returned"`
+	IsTruncated  bool              `json:"is_truncated" jsonschema:"description:Whether content is truncated by max_chars"`
+	Metadata     map[string]string `json:"metadata" jsonschema:"description:Document metadata"`
+	ErrorMessage string            `json:"error_message,omitempty" jsonschema:"description:Error message"`
+}
+
+// GetDocumentOutline Get document heading structure
+func GetDocumentOutline(ctx context.Context, req *GetDocumentOutlineRequest) (*GetDocumentOutlineResponse, error) {
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &GetDocumentOutlineResponse{
+			ErrorMessage: fmt.Sprintf("Failed to access document: %v", err),
+		}, nil
+	}
+
+	result, err := readDocumentWithConfig(filePath, nil)
+	if err != nil {
+		return &GetDocumentOutlineResponse{
+			ErrorMessage: fmt.Sprintf("Failed to read document: %v", err),
+		}, nil
+	}
+
+	nodes, err := buildOutline(filePath, result)
+	if err != nil {
+		return &GetDocumentOutlineResponse{
+			FilePath:     req.FilePath,
+			TotalPages:   result.TotalPages,
+			ErrorMessage: fmt.Sprintf("Failed to extract outline: %v", err),
+		}, nil
+	}
+
+	if req.MaxLevel > 0 {
+		filtered := make([]OutlineNode, 0, len(nodes))
+		for _, node := range nodes {
+			if node.Level <= req.MaxLevel {
+				filtered = append(filtered, node)
+			}
+		}
+		nodes = filtered
+	}
+
+	response := &GetDocumentOutlineResponse{
+		FilePath:   req.FilePath,
+		Nodes:      nodes,
+		TotalPages: result.TotalPages,
+	}
+
+	if len(nodes) == 0 {
+		response.Message = "No headings found. Use read_document_by_page or read_document_by_line to navigate this document"
+	} else {
+		response.Message = fmt.Sprintf("Found %d headings", len(nodes))
+	}
+
+	return response, nil
+}
+
+// ReadDocumentSection Read a document section by heading title or outline node ID
+func ReadDocumentSection(ctx context.Context, req *ReadDocumentSectionRequest) (*ReadDocumentSectionResponse, error) {
+	if req.NodeID == "" && req.Title == "" {
+		return &ReadDocumentSectionResponse{
+			ErrorMessage: "Either node_id or title is required",
+		}, nil
+	}
+
+	maxChars := req.MaxChars
+	if maxChars <= 0 {
+		maxChars = 50000
+	}
+
+	filePath, err := resolveDocumentPath(ctx, req.FilePath)
+	if err != nil {
+		return &ReadDocumentSectionResponse{
+			ErrorMessage: fmt.Sprintf("Failed to access document: %v", err),
+		}, nil
+	}
+
+	result, err := readDocumentWithConfig(filePath, nil)
+	if err != nil {
+		return &ReadDocumentSectionResponse{
+			ErrorMessage: fmt.Sprintf("Failed to read document: %v", err),
+		}, nil
+	}
+
+	nodes, err := buildOutline(filePath, result)
+	if err != nil {
+		return &ReadDocumentSectionResponse{
+			ErrorMessage: fmt.Sprintf("Failed to extract outline: %v", err),
+		}, nil
+	}
+
+	index := findOutlineNode(nodes, req.NodeID, req.Title)
+	if index < 0 {
+		return &ReadDocumentSectionResponse{
+			Metadata:     result.Metadata,
+			ErrorMessage: "Section not found. Use get_document_outline to list available headings",
+		}, nil
+	}
+
+	node := nodes[index]
+	if node.PageNumber < 0 {
+		return &ReadDocumentSectionResponse{
+			Node:         &node,
+			Metadata:     result.Metadata,
+			ErrorMessage: "The position of this heading could not be determined in the document text",
+		}, nil
+	}
+
+	// The section ends right before the next located heading of the same or higher level
+	endPage, endLine := result.TotalPages, 0
+	for _, next := range nodes[index+1:] {
+		if next.PageNumber < 0 || next.Level > node.Level {
+			continue
+		}
+		endPage, endLine = next.PageNumber, next.LineNumber
+		break
+	}
+
+	var lines []string
+	lastPage, lastLine := node.PageNumber, node.LineNumber
+	for _, page := range result.Pages {
+		for i, line := range page.Lines {
+			if !positionBefore(node.PageNumber, node.LineNumber, page.PageNumber, i+1) {
+				continue
+			}
+			if !positionBefore(page.PageNumber, i, endPage, endLine) {
+				break
+			}
+			lines = append(lines, line)
+			lastPage, lastLine = page.PageNumber, i
+		}
+	}
+
+	content := strings.Join(lines, "\n")
+	response := &ReadDocumentSectionResponse{
+		Node:      &node,
+		StartPage: node.PageNumber,
+		StartLine: node.LineNumber,
+		EndPage:   lastPage,
+		EndLine:   lastLine,
+		ReadLines: len(lines),
+		Metadata:  result.Metadata,
+	}
+
+	if len(content) > maxChars {
+		content = truncateRunes(content, maxChars)
+		response.IsTruncated = true
+	}
+	response.Content = content
+
+	return response, nil
+}
+
+// outlineHeading Heading detected in a document before position resolution and numbering
+type outlineHeading struct {
+	title  string
+	level  int
+	page   int
+	line   int
+	source string
+}
+
+// buildOutline Detect headings according to file type and assign hierarchical IDs
+func buildOutline(filePath string, result *docreader.DocumentResult) ([]OutlineNode, error) {
+	var headings []outlineHeading
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".md", ".markdown", ".html", ".htm", ".xhtml", ".epub", ".odt":
+		// HTML, EPUB and ODT readers render headings as Markdown
+		headings = markdownHeadings(result)
+	case ".docx":
+		headings, err = docxHeadings(filePath)
+	case ".pdf":
+		headings, err = pdfHeadings(filePath, result)
+	case ".pptx":
+		headings = slideHeadings(result)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return numberHeadings(headings), nil
+}
+
+// numberHeadings Assign hierarchical IDs (1, 1.1, 1.2, 2, ...) to headings
+func numberHeadings(headings []outlineHeading) []OutlineNode {
+	nodes := make([]OutlineNode, 0, len(headings))
+	// counters and levels hold the number and heading level of each node on the current path
+	var counters, levels []int
+
+	for _, h := range headings {
+		level := h.level
+		if level < 1 {
+			level = 1
+		}
+		// The parent is the nearest preceding heading of a lower level, so skipped levels
+		// (e.g. H1 followed by H3) are attached directly under it
+		depth := len(levels)
+		for depth > 0 && levels[depth-1] >= level {
+			depth--
+		}
+		number := 1
+		if len(counters) > depth {
+			number = counters[depth] + 1
+		}
+		counters = append(counters[:depth], number)
+		levels = append(levels[:depth], level)
+
+		parts := make([]string, len(counters))
+		for i, c := range counters {
+			parts[i] = strconv.Itoa(c)
+		}
+
+		nodes = append(nodes, OutlineNode{
+			ID:         strings.Join(parts, "."),
+			Title:      h.title,
+			Level:      level,
+			PageNumber: h.page,
+			LineNumber: h.line,
+			Source:     h.source,
+		})
+	}
+
+	return nodes
+}
+
+// findOutlineNode Find a node by ID, then by exact title, then by partial title
+func findOutlineNode(nodes []OutlineNode, nodeID, title string) int {
+	if nodeID != "" {
+		for i, node := range nodes {
+			if node.ID == nodeID {
+				return i
+			}
+		}
+		return -1
+	}
+
+	title = strings.ToLower(strings.TrimSpace(title))
+	for i, node := range nodes {
+		if strings.ToLower(node.Title) == title {
+			return i
+		}
+	}
+	for i, node := range nodes {
+		if strings.Contains(strings.ToLower(node.Title), title) {
+			return i
+		}
+	}
+	return -1
+}
+
+var markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.+?)\s*#*\s*$`)
+
+// markdownHeadings Detect ATX headings, ignoring fenced code blocks
+func markdownHeadings(result *docreader.DocumentResult) []outlineHeading {
+	var headings []outlineHeading
+	for _, page := range result.Pages {
+		inFence := false
+		for i, line := range page.Lines {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				inFence = !inFence
+				continue
+			}
+			if inFence {
+				continue
+			}
+			match := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			if match == nil {
+				continue
+			}
+			headings = append(headings, outlineHeading{
+				title:  match[2],
+				level:  len(match[1]),
+				page:   page.PageNumber,
+				line:   i,
+				source: "markdown",
+			})
+		}
+	}
+	return headings
+}
+
+// docxParagraphs Minimal WordprocessingML structure with paragraph styles
+type docxParagraphs struct {
+	Body struct {
+		Paragraphs []struct {
+			Properties struct {
+				Style struct {
+					Val string `xml:"val,attr"`
+				} `xml:"pStyle"`
+				OutlineLevel *struct {
+					Val int `xml:"val,attr"`
+				} `xml:"outlineLvl"`
+			} `xml:"pPr"`
+			Runs []struct {
+				Text string `xml:"t"`
+			} `xml:"r"`
+		} `xml:"p"`
+	} `xml:"body"`
+}
+
+var docxHeadingStylePattern = regexp.MustCompile(`(?i)^heading\s*([1-9])$`)
+
+// docxHeadings Detect headings from paragraph styles (Title, Heading1-9) and outline levels.
+// Line numbers follow docreader, which emits one line per non-empty body paragraph.
+func docxHeadings(filePath string) ([]outlineHeading, error) {
+	data, err := readZipEntry(filePath, "word/document.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	var doc docxParagraphs
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse document.xml: %w", err)
+	}
+
+	var headings []outlineHeading
+	line := 0
+	for _, para := range doc.Body.Paragraphs {
+		var builder strings.Builder
+		for _, run := range para.Runs {
+			builder.WriteString(run.Text)
+		}
+		text := builder.String()
+		if text == "" {
+			continue
+		}
+
+		level := 0
+		style := para.Properties.Style.Val
+		if match := docxHeadingStylePattern.FindStringSubmatch(style); match != nil {
+			level, _ = strconv.Atoi(match[1])
+		} else if strings.EqualFold(style, "Title") {
+			level = 1
+		} else if para.Properties.OutlineLevel != nil && para.Properties.OutlineLevel.Val < 9 {
+			level = para.Properties.OutlineLevel.Val + 1
+		}
+
+		if level > 0 && strings.TrimSpace(text) != "" {
+			headings = append(headings, outlineHeading{
+				title:  strings.TrimSpace(text),
+				level:  level,
+				page:   0,
+				line:   line,
+				source: "style",
+			})
+		}
+		line++
+	}
+
+	return headings, nil
+}
+
+// pdfHeadings Use PDF bookmarks and locate each title in the extracted page text
+func pdfHeadings(filePath string, result *docreader.DocumentResult) (headings []outlineHeading, err error) {
+	f, reader, err := pdf.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open PDF: %w", err)
+	}
+	defer f.Close()
+
+	// The PDF library panics on damaged outline and page objects
+	defer func() {
+		if r := recover(); r != nil {
+			headings, err = nil, fmt.Errorf("damaged PDF: %v", r)
+		}
+	}()
+
+	var walk func(items []pdf.Outline, level int)
+	walk = func(items []pdf.Outline, level int) {
+		for _, item := range items {
+			if title := strings.TrimSpace(item.Title); title != "" {
+				headings = append(headings, outlineHeading{
+					title:  title,
+					level:  level,
+					page:   -1,
+					line:   -1,
+					source: "bookmark",
+				})
+			}
+			walk(item.Child, level+1)
+		}
+	}
+	walk(reader.Outline().Child, 1)
+
+	// Bookmarks are in reading order, so each search continues from the previous hit
+	fromPage, fromLine := 0, 0
+	for i := range headings {
+		page, line := locateLine(result, headings[i].title, fromPage, fromLine)
+		if page < 0 {
+			continue
+		}
+		headings[i].page, headings[i].line = page, line
+		fromPage, fromLine = page, line+1
+	}
+
+	return headings, nil
+}
+
+// slideHeadings Use the first non-empty line of each slide as its title
+func slideHeadings(result *docreader.DocumentResult) []outlineHeading {
+	var headings []outlineHeading
+	for _, page := range result.Pages {
+		for i, line := range page.Lines {
+			if title := strings.TrimSpace(line); title != "" {
+				headings = append(headings, outlineHeading{
+					title:  title,
+					level:  1,
+					page:   page.PageNumber,
+					line:   i,
+					source: "slide",
+				})
+				break
+			}
+		}
+	}
+	return headings
+}
+
+// locateLine Find the first line at or after (fromPage, fromLine) containing text, ignoring case and spacing
+func locateLine(result *docreader.DocumentResult, text string, fromPage, fromLine int) (int, int) {
+	needle := normalizeSpace(text)
+	if needle == "" {
+		return -1, -1
+	}
+	for _, page := range result.Pages {
+		if page.PageNumber < fromPage {
+			continue
+		}
+		for i, line := range page.Lines {
+			if page.PageNumber == fromPage && i < fromLine {
+				continue
+			}
+			if strings.Contains(normalizeSpace(line), needle) {
+				return page.PageNumber, i
+			}
+		}
+	}
+	return -1, -1
+}
+
+// positionBefore Report whether (page1, line1) comes strictly before (page2, line2)
+func positionBefore(page1, line1, page2, line2 int) bool {
+	return page1 < page2 || (page1 == page2 && line1 < line2)
+}
+
+// normalizeSpace Lowercase text and collapse whitespace
+func normalizeSpace(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+// truncateRunes Cut text to at most maxBytes without splitting a multi-byte character
+func truncateRunes(text string, maxBytes int) string {
+	if len(text) <= maxBytes {
+		return text
+	}
+	for maxBytes > 0 && !utf8.RuneStart(text[maxBytes]) {
+		maxBytes--
+	}
+	return text[:maxBytes]
+}
+
+// readZipEntry Read a single entry from a zip-based document (DOCX, PPTX, XLSX)
+func readZipEntry(filePath, name string) ([]byte, error) {
+	zipReader, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+	defer zipReader.Close()
+
+	for _, file := range zipReader.File {
+		if file.Name != name {
+			continue
+		}
+		return readZipMember(file)
+	}
+
+	return nil, fmt.Errorf("%s not found in document", name)
+}
+
+// readZipMember Read a zip entry of at most MaxZipEntrySize bytes; the declared size is checked
+// first and the read is bounded, since the header can understate what the entry inflates to
+func readZipMember(file *zip.File) ([]byte, error) {
+	if file.UncompressedSize64 > MaxZipEntrySize {
+		return nil, fmt.Errorf("%s is too large (%s, limit %s)", file.Name, formatFileSize(int64(file.UncompressedSize64)), formatFileSize(MaxZipEntrySize))
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, MaxZipEntrySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	if len(data) > MaxZipEntrySize {
+		return nil, fmt.Errorf("%s exceeds the size limit of %s", file.Name, formatFileSize(MaxZipEntrySize))
+	}
+	return data, nil
+}
@@ -0,0 +1,100 @@
+package doc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// openOutputRoot Open the configured output directory as a root that paths cannot escape
+func openOutputRoot() (*os.Root, error) {
+	if outputDir == "" {
+		return nil, errors.New("no output directory is configured, start the server with --doc-output-dir")
+	}
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+	return os.OpenRoot(outputDir)
+}
+
+// outputRelativePath Turn an output_path argument into a path relative to the output directory.
+// Absolute paths are accepted only when they point inside the output directory.
+func outputRelativePath(outputPath string) (string, error) {
+	if outputPath == "" {
+		return "", errors.New("output path is empty")
+	}
+	if !filepath.IsAbs(outputPath) {
+		return filepath.Clean(outputPath), nil
+	}
+
+	base, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(base, filepath.Clean(outputPath))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the output directory %s", outputPath, outputDir)
+	}
+	return rel, nil
+}
+
+// writeOutputFile Write data to a path inside the output directory and return its absolute path
+func writeOutputFile(outputPath string, data []byte, overwrite bool) (string, error) {
+	rel, err := outputRelativePath(outputPath)
+	if err != nil {
+		return "", err
+	}
+
+	root, err := openOutputRoot()
+	if err != nil {
+		return "", err
+	}
+	defer root.Close()
+
+	if dir := filepath.Dir(rel); dir != "." {
+		if err := root.MkdirAll(dir, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create directory: %w", err)
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags |= os.O_EXCL
+	}
+	f, err := root.OpenFile(rel, flags, 0o644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("%s already exists, set overwrite to replace it", rel)
+		}
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	return filepath.Abs(filepath.Join(outputDir, rel))
+}
+
+// readOutputFile Read a file inside the output directory
+func readOutputFile(outputPath string) ([]byte, error) {
+	rel, err := outputRelativePath(outputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := openOutputRoot()
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+
+	return root.ReadFile(rel)
+}
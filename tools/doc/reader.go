@@ -0,0 +1,320 @@
+package doc
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/wsshow/docreader"
+)
+
+// FormatReader Reads one or more document formats into the pages/lines model shared by all doc tools.
+// Readers always parse the whole document; page and line selection is applied afterwards.
+type FormatReader interface {
+	// Extensions returns the lowercase file extensions (with leading dot) handled by this reader
+	Extensions() []string
+
+	// Read parses the document into pages of lines, filling Pages, TotalPages and Metadata
+	Read(filePath string) (*docreader.DocumentResult, error)
+}
+
+// formatReaders Registered readers by file extension
+var formatReaders = make(map[string]FormatReader)
+
+func init() {
+	RegisterFormatReader(&htmlReader{})
+	RegisterFormatReader(&epubReader{})
+	RegisterFormatReader(&odfReader{})
+	RegisterFormatReader(&structuredTextReader{})
+	RegisterFormatReader(&sourceCodeReader{})
+	RegisterFormatReader(&mailReader{})
+}
+
+// RegisterFormatReader Register a reader for its extensions, replacing any previous registration
+func RegisterFormatReader(reader FormatReader) {
+	for _, ext := range reader.Extensions() {
+		formatReaders[strings.ToLower(ext)] = reader
+	}
+}
+
+// SupportedFormats Return all supported file extensions, sorted
+func SupportedFormats() []string {
+	formats := docreader.GetSupportedFormats()
+	for ext := range formatReaders {
+		if !slices.Contains(formats, ext) {
+			formats = append(formats, ext)
+		}
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// lookupFormatReader Find the registered reader for a file, if any
+func lookupFormatReader(filePath string) (FormatReader, bool) {
+	reader, ok := formatReaders[strings.ToLower(filepath.Ext(filePath))]
+	return reader, ok
+}
+
+// readDocumentWithConfig Read a document into pages/lines through the parsed-document cache, then apply page and line selection
+func readDocumentWithConfig(filePath string, config *docreader.ReadConfig) (*docreader.DocumentResult, error) {
+	result, err := documentCache.result(filePath, parseDocument)
+	if err != nil {
+		return nil, err
+	}
+	return applyReadConfig(result, config), nil
+}
+
+// parseDocument Parse a whole document, dispatching to registered readers before docreader
+func parseDocument(filePath string) (result *docreader.DocumentResult, err error) {
+	defer recoverParse(&err)
+
+	reader, ok := lookupFormatReader(filePath)
+	if !ok {
+		return docreader.ReadDocumentWithConfig(filePath, nil)
+	}
+
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, docreader.WrapError("ReadDocumentWithConfig", filePath, docreader.ErrFileNotFound)
+	}
+
+	result, err = reader.Read(filePath)
+	if err != nil {
+		return nil, err
+	}
+	result.FilePath = filePath
+
+	return result, nil
+}
+
+// recoverParse Turn a panic in a document parser into an error; the PDF library panics on
+// damaged files, and one bad file must not stop the server. Deferred directly by parsers.
+func recoverParse(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("damaged document: %v", r)
+	}
+}
+
+// readDocument Read the full text and metadata of a document
+func readDocument(filePath string) (*docreader.Document, error) {
+	return documentCache.document(filePath, false, loadDocument)
+}
+
+// readDocumentWithClean Read a document and apply the default text cleaning
+func readDocumentWithClean(filePath string) (*docreader.Document, error) {
+	return documentCache.document(filePath, true, loadDocument)
+}
+
+// loadDocument Read the full text of a document, optionally cleaned
+func loadDocument(filePath string, clean bool) (doc *docreader.Document, err error) {
+	defer recoverParse(&err)
+
+	if _, ok := lookupFormatReader(filePath); ok {
+		result, err := readDocumentWithConfig(filePath, nil)
+		if err != nil {
+			return nil, err
+		}
+		doc = &docreader.Document{
+			FilePath: filePath,
+			Content:  result.Content,
+			Metadata: result.Metadata,
+		}
+	} else {
+		doc, err = docreader.ReadDocument(filePath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if clean {
+		doc.CleanContent()
+	}
+	return doc, nil
+}
+
+// applyReadConfig Apply page and line selection to a fully parsed document.
+// The parsed document is shared through the cache, so a new result is always returned.
+func applyReadConfig(parsed *docreader.DocumentResult, config *docreader.ReadConfig) *docreader.DocumentResult {
+	result := &docreader.DocumentResult{
+		FilePath:   parsed.FilePath,
+		TotalPages: parsed.TotalPages,
+		Metadata:   make(map[string]string, len(parsed.Metadata)),
+	}
+	for key, value := range parsed.Metadata {
+		result.Metadata[key] = value
+	}
+	if result.TotalPages == 0 {
+		result.TotalPages = len(parsed.Pages)
+	}
+
+	pageLines := make(map[int]*docreader.Selector)
+	selectPage := func(int) bool { return true }
+
+	if config != nil && len(config.PageConfigs) > 0 {
+		// Per-page configuration restricts reading to the configured pages
+		for i := range config.PageConfigs {
+			pageLines[config.PageConfigs[i].PageIndex] = &config.PageConfigs[i].LineSelector
+		}
+		selectPage = func(page int) bool {
+			_, ok := pageLines[page]
+			return ok
+		}
+	} else if config != nil && !selectorEmpty(config.PageSelector) {
+		selectPage = func(page int) bool { return selectorContains(config.PageSelector, page) }
+	}
+
+	pages := make([]docreader.PageContent, 0, len(parsed.Pages))
+	totalLines := 0
+
+	for _, page := range parsed.Pages {
+		if !selectPage(page.PageNumber) {
+			continue
+		}
+
+		lineSelector := pageLines[page.PageNumber]
+		if lineSelector == nil && config != nil {
+			lineSelector = &config.LineSelector
+		}
+
+		lines := page.Lines
+		if lineSelector != nil && !selectorEmpty(*lineSelector) {
+			lines = make([]string, 0)
+			for i, line := range page.Lines {
+				if selectorContains(*lineSelector, i) {
+					lines = append(lines, line)
+				}
+			}
+		}
+
+		pages = append(pages, docreader.PageContent{
+			PageNumber: page.PageNumber,
+			PageName:   page.PageName,
+			Lines:      lines,
+			TotalLines: len(page.Lines),
+		})
+		totalLines += len(lines)
+	}
+
+	result.Pages = pages
+	result.TotalLines = totalLines
+	if _, ok := lookupFormatReader(parsed.FilePath); ok {
+		result.Content = pagedContent(pages, result.TotalPages)
+	} else {
+		result.Content = docreaderContent(parsed.FilePath, pages)
+	}
+
+	return result
+}
+
+// pagedContent Content of registered formats: pages joined by blank lines, headed in multi-page documents
+func pagedContent(pages []docreader.PageContent, totalPages int) string {
+	var content strings.Builder
+	for _, page := range pages {
+		if content.Len() > 0 {
+			content.WriteString("\n\n")
+		}
+		if totalPages > 1 {
+			content.WriteString(pageHeading(page))
+			content.WriteString("\n\n")
+		}
+		content.WriteString(strings.Join(page.Lines, "\n"))
+	}
+	return content.String()
+}
+
+// docreaderContent Content of built-in formats, rendered exactly as docreader renders a read with
+// the same selection so cached reads match uncached ones
+func docreaderContent(filePath string, pages []docreader.PageContent) string {
+	var content strings.Builder
+	writeLines := func(lines []string) {
+		for _, line := range lines {
+			content.WriteString(line)
+			content.WriteString("\n")
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".pdf":
+		for _, page := range pages {
+			writeLines(page.Lines)
+			content.WriteString(fmt.Sprintf("\n--- 第 %d 页 ---\n\n", page.PageNumber))
+		}
+	case ".xlsx":
+		for _, page := range pages {
+			content.WriteString(fmt.Sprintf("\n=== 工作表: %s ===\n\n", page.PageName))
+			writeLines(page.Lines)
+			content.WriteString("\n")
+		}
+	case ".pptx":
+		for _, page := range pages {
+			content.WriteString(fmt.Sprintf("\n=== 幻灯片 %d ===\n\n", page.PageNumber))
+			writeLines(page.Lines)
+		}
+	default:
+		// Single-page formats (DOCX, TXT, CSV, Markdown, RTF) are their lines
+		var lines []string
+		for _, page := range pages {
+			lines = append(lines, page.Lines...)
+		}
+		return strings.Join(lines, "\n")
+	}
+	return content.String()
+}
+
+// pageHeading Separator line written before each page of a multi-page document
+func pageHeading(page docreader.PageContent) string {
+	if page.PageName != "" {
+		return fmt.Sprintf("--- Page %d: %s ---", page.PageNumber, page.PageName)
+	}
+	return fmt.Sprintf("--- Page %d ---", page.PageNumber)
+}
+
+// selectorEmpty Report whether a selector selects nothing explicitly (meaning everything)
+func selectorEmpty(selector docreader.Selector) bool {
+	return len(selector.Indexes) == 0 && len(selector.Ranges) == 0
+}
+
+// selectorContains Report whether a selector includes the index
+func selectorContains(selector docreader.Selector, index int) bool {
+	if slices.Contains(selector.Indexes, index) {
+		return true
+	}
+	for _, r := range selector.Ranges {
+		if index >= r[0] && index <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// singlePageResult Build a one-page result from lines
+func singlePageResult(lines []string, metadata map[string]string) *docreader.DocumentResult {
+	return &docreader.DocumentResult{
+		Pages:      []docreader.PageContent{{PageNumber: 0, Lines: lines, TotalLines: len(lines)}},
+		TotalPages: 1,
+		Metadata:   metadata,
+	}
+}
+
+// splitLines Split text into lines, normalizing line endings and dropping the trailing empty line
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(text, "\n")
+}
+
+// fileMetadata Basic metadata shared by plain-text based readers
+func fileMetadata(filePath string) map[string]string {
+	metadata := make(map[string]string)
+	if info, err := os.Stat(filePath); err == nil {
+		metadata["size"] = fmt.Sprintf("%d", info.Size())
+		metadata["modified"] = info.ModTime().String()
+	}
+	return metadata
+}
@@ -0,0 +1,316 @@
+package doc
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fkmcps/tools/fetch"
+)
+
+const (
+	// MaxCachedFileSize Maximum size of a single downloaded or extracted document (50MB)
+	MaxCachedFileSize = 50 * 1024 * 1024
+	// MaxCacheSize Maximum total size of the temporary document cache (500MB)
+	MaxCacheSize = 500 * 1024 * 1024
+	// CacheTTL How long a downloaded document is reused before it is downloaded again
+	CacheTTL = 10 * time.Minute
+)
+
+// zipScheme Prefix of archive member paths, e.g. zip://archive.zip!/inner/report.pdf
+const zipScheme = "zip://"
+
+// contentTypeExtensions Extensions for common document content types, checked before the system MIME table
+var contentTypeExtensions = map[string]string{
+	"application/pdf": ".pdf",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   ".docx",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/vnd.oasis.opendocument.text":                                   ".odt",
+	"application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+	"application/vnd.oasis.opendocument.presentation":                           ".odp",
+	"application/epub+zip": ".epub",
+	"application/rtf":      ".rtf",
+	"application/json":     ".json",
+	"application/xml":      ".xml",
+	"text/xml":             ".xml",
+	"text/html":            ".html",
+	"text/markdown":        ".md",
+	"text/csv":             ".csv",
+	"text/plain":           ".txt",
+	"message/rfc822":       ".eml",
+}
+
+// isRemotePath Report whether a file path is an http(s) URL
+func isRemotePath(filePath string) bool {
+	return strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://")
+}
+
+// resolveDocumentPath Turn a file_path argument into a local file path.
+// URLs are downloaded and archive members are extracted into the temporary document cache;
+// plain paths are returned unchanged.
+func resolveDocumentPath(ctx context.Context, filePath string) (string, error) {
+	switch {
+	case strings.HasPrefix(filePath, zipScheme):
+		return extractArchiveMember(ctx, filePath)
+	case isRemotePath(filePath):
+		return downloadDocument(ctx, filePath)
+	default:
+		return filePath, nil
+	}
+}
+
+// extractArchiveMember Extract zip://archive!/member into the cache. The archive itself may be a URL.
+func extractArchiveMember(ctx context.Context, filePath string) (string, error) {
+	archivePath, member, ok := strings.Cut(strings.TrimPrefix(filePath, zipScheme), "!/")
+	if !ok || archivePath == "" || member == "" {
+		return "", fmt.Errorf("invalid archive path %q, expected zip://archive.zip!/path/in/archive", filePath)
+	}
+
+	localArchive, err := resolveDocumentPath(ctx, archivePath)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(localArchive)
+	if err != nil {
+		return "", fmt.Errorf("archive access failed: %w", err)
+	}
+
+	// Key on archive identity so a modified archive is extracted again
+	key := fmt.Sprintf("%s|%d|%d|%s", localArchive, info.Size(), info.ModTime().UnixNano(), member)
+	target, err := cachePath(key, path.Ext(member))
+	if err != nil {
+		return "", err
+	}
+	if cacheFresh(target, 0) {
+		return target, nil
+	}
+
+	zipReader, err := zip.OpenReader(localArchive)
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer zipReader.Close()
+
+	for _, file := range zipReader.File {
+		if file.Name != member {
+			continue
+		}
+		if file.UncompressedSize64 > MaxCachedFileSize {
+			return "", fmt.Errorf("archive member is too large (%s, limit %s)", formatFileSize(int64(file.UncompressedSize64)), formatFileSize(MaxCachedFileSize))
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return "", fmt.Errorf("failed to open archive member: %w", err)
+		}
+		defer rc.Close()
+
+		if err := writeCacheFile(target, rc); err != nil {
+			return "", err
+		}
+		return target, nil
+	}
+
+	return "", fmt.Errorf("%q not found in archive", member)
+}
+
+// downloadDocument Download a URL into the cache, reusing a recent download of the same URL
+func downloadDocument(ctx context.Context, rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if err := fetch.CheckURL(parsed); err != nil {
+		return "", fmt.Errorf("URL is not allowed: %w", err)
+	}
+
+	// Reuse a fresh download regardless of the extension it was stored with
+	if cached := findCachedDownload(rawURL); cached != "" {
+		return cached, nil
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("User-Agent", "FKTEAMS/1.0")
+
+	resp, err := fetch.CreateHTTPClient(fetch.MaxTimeout).Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to download document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed with status code: %d", resp.StatusCode)
+	}
+	if resp.ContentLength > MaxCachedFileSize {
+		return "", fmt.Errorf("remote document is too large (%s, limit %s)", formatFileSize(resp.ContentLength), formatFileSize(MaxCachedFileSize))
+	}
+
+	ext := strings.ToLower(path.Ext(parsed.Path))
+	if ext == "" || !isSupportedExtension(ext) {
+		if typeExt := extensionForContentType(resp.Header.Get("Content-Type")); typeExt != "" {
+			ext = typeExt
+		}
+	}
+
+	target, err := cachePath(rawURL, ext)
+	if err != nil {
+		return "", err
+	}
+	if err := writeCacheFile(target, resp.Body); err != nil {
+		return "", err
+	}
+
+	return target, nil
+}
+
+// cacheDir Return the temporary document cache directory, creating it if needed
+func cacheDir() (string, error) {
+	dir := filepath.Join(os.TempDir(), "fkmcps-doc-cache")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create document cache: %w", err)
+	}
+	return dir, nil
+}
+
+// cacheKey Hash a source identifier into a cache file name prefix
+func cacheKey(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:16])
+}
+
+// cachePath Cache file path for a source, keeping the extension so readers can dispatch on it
+func cachePath(source, ext string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, cacheKey(source)+strings.ToLower(ext)), nil
+}
+
+// findCachedDownload Find a download of the URL that is younger than CacheTTL
+func findCachedDownload(rawURL string) string {
+	dir, err := cacheDir()
+	if err != nil {
+		return ""
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, cacheKey(rawURL)+"*"))
+	for _, match := range matches {
+		if cacheFresh(match, CacheTTL) {
+			return match
+		}
+	}
+	return ""
+}
+
+// cacheFresh Report whether a cache file exists and, when ttl > 0, is younger than ttl
+func cacheFresh(target string, ttl time.Duration) bool {
+	info, err := os.Stat(target)
+	if err != nil {
+		return false
+	}
+	return ttl <= 0 || time.Since(info.ModTime()) < ttl
+}
+
+// writeCacheFile Copy at most MaxCachedFileSize bytes into target atomically, then trim the cache
+func writeCacheFile(target string, r io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".download-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, io.LimitReader(r, MaxCachedFileSize+1))
+	closeErr := tmp.Close()
+	if err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to write cache file: %w", closeErr)
+	}
+	if written > MaxCachedFileSize {
+		return fmt.Errorf("document exceeds the size limit of %s", formatFileSize(MaxCachedFileSize))
+	}
+
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return fmt.Errorf("failed to store cache file: %w", err)
+	}
+
+	trimCache(filepath.Dir(target), "*", target, MaxCacheSize)
+	return nil
+}
+
+// trimCache Remove the oldest files in dir matching pattern until they fit in limit bytes, keeping keep.
+// Files not matching pattern are never counted or removed.
+func trimCache(dir, pattern, keep string, limit int64) {
+	matches, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return
+	}
+
+	type cacheFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []cacheFile
+	var total int64
+	for _, match := range matches {
+		info, err := os.Lstat(match)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, cacheFile{match, info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, file := range files {
+		if total <= limit {
+			break
+		}
+		if file.path == keep {
+			continue
+		}
+		if os.Remove(file.path) == nil {
+			total -= file.size
+		}
+	}
+}
+
+// isSupportedExtension Report whether any reader handles the extension
+func isSupportedExtension(ext string) bool {
+	return slices.Contains(SupportedFormats(), ext)
+}
+
+// extensionForContentType Map a Content-Type header to a file extension
+func extensionForContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if ext, ok := contentTypeExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
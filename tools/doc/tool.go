@@ -9,7 +9,15 @@ import (
 func GetTools(s *mcp.Server) {
 	mcp.AddTool(s, &mcp.Tool{
 		Name: "get_document_info",
-		Description: `Get basic information about a document, including file type, size, page count, and metadata. Supported formats: .docx, .pdf, .xlsx, .pptx, .txt, .csv, .md, .rtf.
+		Description: `Get basic information about a document, including file type, size, page count, and metadata. Supported formats:
+- Office and PDF: .docx, .pdf, .xlsx, .pptx, .rtf
+- OpenDocument: .odt, .ods, .odp
+- Web and books: .html, .htm, .epub
+- Mail: .eml, .mbox (one page per message)
+- Text and data: .txt, .csv, .md, .json, .yaml, .xml, and source code files (.go, .py, .js, .java, ...)
+file_path also accepts http(s):// URLs and archive members (zip://archive.zip!/inner/report.pdf) in every doc tool.
+Also returns the total line count and each page's first line in whole-document numbering, for global line reads.
+All four reading tools accept one_based=true to use 1-based page and line numbers (default 0-based).
 This is the first step before reading a document, helping you understand document structure and decide how to read it.`,
 	}, structs.WarpToolFunc(GetDocumentInfo))
 
@@ -27,19 +35,131 @@ Best for: First-time document reading, quick content overview`,
 		Name: "read_document_by_page",
 		Description: `Read document content by page range. Supports multi-page documents like PDF, PPTX.
 Parameters:
-- start_page: Starting page (0-based)
+- start_page: Starting page (0-based, or 1-based with one_based=true)
 - end_page: Ending page (-1 means to end)
-Returns detailed information for each page (page number, line count).
+Returns detailed information for each page (page number, line count, first line in whole-document numbering).
 Best for: Reading specific pages or sections`,
 	}, structs.WarpToolFunc(ReadDocumentByPages))
 
 	mcp.AddTool(s, &mcp.Tool{
 		Name: "read_document_by_line",
-		Description: `Read document content by line range. Supports specifying page and line range.
+		Description: `Read document content by line range, within one page or across the whole document.
 Parameters:
-- start_line: Starting line (0-based)
+- start_line: Starting line (0-based, or 1-based with one_based=true)
 - end_line: Ending line (-1 means to end)
-- page_index: Page index (-1 means first page)
+- page_index: Page to read within (default first page)
+- global: Number lines across all pages, e.g. lines 40-120 of the whole document (also used when page_index is -1)
+Returns segments mapping the lines read back to page and in-page line numbers.
 Best for: Reading specific lines or paragraphs`,
 	}, structs.WarpToolFunc(ReadDocumentByLines))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "get_document_outline",
+		Description: `Get the heading structure of a document before reading it. Headings are detected from:
+- DOCX: paragraph styles (Title, Heading 1-9) and outline levels
+- Markdown, HTML, EPUB and ODT: headings
+- PDF: bookmarks, when present
+- PPTX: slide titles
+Returns each heading with its node ID, level, page number and line number.
+Best for: Navigating long documents, locating chapters before reading them`,
+	}, structs.WarpToolFunc(GetDocumentOutline))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "read_document_section",
+		Description: `Read one section of a document, from its heading up to the next heading of the same or higher level (subsections included).
+Parameters:
+- node_id: Outline node ID returned by get_document_outline (e.g. 2.1)
+- title: Heading title, used when node_id is not given
+- max_chars: Maximum character limit (default 50000)
+Best for: Reading a specific chapter or section found with get_document_outline`,
+	}, structs.WarpToolFunc(ReadDocumentSection))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "diff_documents",
+		Description: `Compare the text of two documents (any supported formats, e.g. two versions of a contract).
+Parameters:
+- old_file_path / new_file_path: The documents to compare
+- format: unified (default) or side_by_side
+- granularity: line (default) or word; word shows changed lines once, prefixed with ~, marking changes as [-removed-]{+added+}
+- context_lines: Unchanged lines shown around each change (default 3, 0 for none)
+- ignore_whitespace: Ignore spacing differences (default false)
+- max_chars: Maximum character limit of the diff output (default 50000)
+Returns the diff with page and line positions for each change, a list of added/removed/changed sections and summary counts.
+Best for: Reviewing what changed between document revisions`,
+	}, structs.WarpToolFunc(DiffDocuments))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "convert_document",
+		Description: `Convert a document (any supported format) into another shape for downstream use.
+Formats:
+- markdown: Page or sheet headings; spreadsheets become markdown tables
+- text: Plain text, as returned by read_document_by_page
+- json: Pages with their lines, plus metadata
+- csv: Spreadsheets only (XLSX, ODS, CSV); one file per sheet when writing several sheets
+Parameters:
+- start_page / end_page: Convert a page or sheet range (default all)
+- output_path: Write into the server output directory instead of returning the content inline (requires --doc-writable)
+- overwrite: Replace an existing output file (default false)
+- max_chars: Maximum inline character limit (default 50000)
+Best for: Handing a document to another system, extracting spreadsheet data`,
+	}, structs.WarpToolFunc(ConvertDocument))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "list_document_images",
+		Description: `List images embedded in a document (diagrams, charts, photos) with their positions.
+Supported formats: DOCX, PPTX, PDF, XLSX, ODT, ODS, ODP, EPUB
+Returns each image's ID, page (slide) number, approximate line number, MIME type, stored size and dimensions.
+Best for: Finding figures that text reading skips, before fetching them with get_document_image`,
+	}, structs.WarpToolFunc(ListDocumentImages))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "get_document_image",
+		Description: `Return one embedded image as image content so it can be viewed.
+Parameters:
+- image_id: ID returned by list_document_images (e.g. img-1)
+- max_bytes: Maximum image size (default 1MB, at most 5MB)
+- max_dimension: Maximum width or height in pixels (default 1568)
+PNG, JPEG and GIF images larger than the limits are downscaled; other formats (e.g. SVG, EMF) are returned as stored when they fit.
+Best for: Looking at a chart or diagram referenced in a document`,
+	}, structs.WarpToolFunc(GetDocumentImage))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "list_documents",
+		Description: `List documents under the server's document roots, to discover what can be read.
+Parameters:
+- root: Directory to list, inside a document root (default all roots)
+- pattern: Glob such as *.pdf or reports/**/*.docx (a pattern without / matches file names at any depth)
+- max_depth: Directory levels to descend (default 5, -1 for unlimited)
+- sort_by: name (default), mtime or size; descending reverses the order
+- include_all: Also list files in unsupported formats (default false)
+- include_hidden: Include dot files and directories (default false)
+- offset / limit: Pagination (default limit 100, at most 1000)
+Returns each document's absolute path, type, size, modification time and a page (slide, sheet) count hint read from document properties.
+Best for: Finding the right file before calling the other document tools`,
+	}, structs.WarpToolFunc(ListDocuments))
+
+	if !writable {
+		return
+	}
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "write_document",
+		Description: `Create a document in the server output directory. The output_path extension selects the format:
+- .md / .txt: Written from content as-is
+- .docx: content is Markdown (headings, paragraphs, lists, tables, code blocks, **bold**, *italic*) converted to Word
+- .csv: Written from rows
+- .xlsx: Written from rows (one sheet) or sheets (several named sheets); numeric values become number cells
+Existing files are only replaced when overwrite is true.
+Best for: Saving reports, exports and tables produced by the agent`,
+	}, structs.WarpToolFunc(WriteDocument))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name: "edit_document",
+		Description: `Edit a plain-text document (.md, .txt, .csv, .yaml, .xml) in the server output directory.
+Modes:
+- append: Add content to the end of the file
+- replace_lines: Replace lines start_line..end_line (0-based, inclusive) with content; empty content deletes them
+Use read_document_by_line to find line numbers before replacing.
+Best for: Incrementally building or correcting a report written with write_document`,
+	}, structs.WarpToolFunc(EditDocument))
 }
@@ -0,0 +1,222 @@
+package doc
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// writeFormats Output formats of write_document by file extension
+var writeFormats = map[string]string{
+	".md":       "markdown",
+	".markdown": "markdown",
+	".txt":      "txt",
+	".csv":      "csv",
+	".docx":     "docx",
+	".xlsx":     "xlsx",
+}
+
+// editableExtensions Plain-text formats that edit_document can modify line by line. Only formats
+// whose readers keep the file's line numbering are listed, so read_document_by_line positions
+// address the same lines; HTML is converted to Markdown and JSON re-indented when read.
+var editableExtensions = map[string]bool{
+	".md":       true,
+	".markdown": true,
+	".txt":      true,
+	".csv":      true,
+	".yaml":     true,
+	".yml":      true,
+	".xml":      true,
+}
+
+// SheetData Rows of one worksheet
+type SheetData struct {
+	Name string     `json:"name" jsonschema:"description:Sheet name"`
+	Rows [][]string `json:"rows" jsonschema:"required,description:Rows of cell values; the first row is usually the header"`
+}
+
+// WriteDocumentRequest Document creation request
+type WriteDocumentRequest struct {
+	OutputPath string      `json:"output_path" jsonschema:"required,description:File path inside the server output directory; the extension selects the format (.md, .txt, .csv, .docx, .xlsx)"`
+	Content    string      `json:"content,omitempty" jsonschema:"description:Text content for .md and .txt, or Markdown converted to Word for .docx"`
+	Rows       [][]string  `json:"rows,omitempty" jsonschema:"description:Row data for .csv and single-sheet .xlsx"`
+	Sheets     []SheetData `json:"sheets,omitempty" jsonschema:"description:Worksheets for .xlsx with several sheets"`
+	Overwrite  bool        `json:"overwrite,omitempty" jsonschema:"description:Replace the file if it already exists (default false)"`
+}
+
+// WriteDocumentResponse Document creation response
+type WriteDocumentResponse struct {
+	OutputPath   string `json:"output_path" jsonschema:"description:Absolute path of the written file"`
+	Format       string `json:"format" jsonschema:"description:Written format"`
+	FileSize     string `json:"file_size" jsonschema:"description:File size (formatted string)"`
+	ErrorMessage string `json:"error_message,omitempty" jsonschema:"description:Error message"`
+}
+
+// EditDocumentRequest Text document editing request
+type EditDocumentRequest struct {
+	OutputPath string `json:"output_path" jsonschema:"required,description:Text file inside the server output directory (.md, .txt, .csv, .yaml, .xml)"`
+	Mode       string `json:"mode" jsonschema:"required,description:Edit mode: append (add content at the end) or replace_lines (replace start_line..end_line with content)"`
+	Content    string `json:"content,omitempty" jsonschema:"description:Text to append or to put in place of the replaced lines; empty with replace_lines deletes the lines"`
+	StartLine  int    `json:"start_line,omitempty" jsonschema:"description:First line to replace (0-based, replace_lines only)"`
+	EndLine    int    `json:"end_line,omitempty" jsonschema:"description:Last line to replace (inclusive, replace_lines only; defaults to start_line)"`
+}
+
+// EditDocumentResponse Text document editing response
+type EditDocumentResponse struct {
+	OutputPath   string `json:"output_path" jsonschema:"description:Absolute path of the edited file"`
+	TotalLines   int    `json:"total_lines" jsonschema:"description:Number of lines after the edit"`
+	ErrorMessage string `json:"error_message,omitempty" jsonschema:"description:Error message"`
+}
+
+// WriteDocument Create a document in the output directory
+func WriteDocument(ctx context.Context, req *WriteDocumentRequest) (*WriteDocumentResponse, error) {
+	format, ok := writeFormats[strings.ToLower(filepath.Ext(req.OutputPath))]
+	if !ok {
+		return &WriteDocumentResponse{ErrorMessage: "output_path must end with one of: .md, .txt, .csv, .docx, .xlsx"}, nil
+	}
+
+	var data []byte
+	var err error
+
+	switch format {
+	case "markdown", "txt":
+		data = []byte(req.Content)
+	case "docx":
+		data, err = markdownToDOCX(req.Content)
+	case "csv":
+		data, err = rowsToCSV(req.Rows)
+	case "xlsx":
+		sheets := req.Sheets
+		if len(sheets) == 0 {
+			sheets = []SheetData{{Name: "Sheet1", Rows: req.Rows}}
+		}
+		data, err = sheetsToXLSX(sheets)
+	}
+	if err != nil {
+		return &WriteDocumentResponse{Format: format, ErrorMessage: fmt.Sprintf("Failed to build document: %v", err)}, nil
+	}
+
+	written, err := writeOutputFile(req.OutputPath, data, req.Overwrite)
+	if err != nil {
+		return &WriteDocumentResponse{Format: format, ErrorMessage: fmt.Sprintf("Failed to write document: %v", err)}, nil
+	}
+
+	return &WriteDocumentResponse{
+		OutputPath: written,
+		Format:     format,
+		FileSize:   formatFileSize(int64(len(data))),
+	}, nil
+}
+
+// EditDocument Append to or replace lines of a text document in the output directory
+func EditDocument(ctx context.Context, req *EditDocumentRequest) (*EditDocumentResponse, error) {
+	if !editableExtensions[strings.ToLower(filepath.Ext(req.OutputPath))] {
+		return &EditDocumentResponse{ErrorMessage: "Only plain-text documents whose lines are read unchanged can be edited (.md, .txt, .csv, .yaml, .xml); use write_document with overwrite to replace other formats"}, nil
+	}
+
+	data, err := readOutputFile(req.OutputPath)
+	if err != nil {
+		return &EditDocumentResponse{ErrorMessage: fmt.Sprintf("Failed to read document: %v", err)}, nil
+	}
+	if !utf8.Valid(data) {
+		return &EditDocumentResponse{ErrorMessage: "Document is not valid UTF-8 text"}, nil
+	}
+
+	lines := splitLines(string(data))
+	content := splitLines(req.Content)
+
+	switch req.Mode {
+	case "append":
+		lines = append(lines, content...)
+	case "replace_lines":
+		endLine := req.EndLine
+		if endLine < req.StartLine {
+			endLine = req.StartLine
+		}
+		if req.StartLine < 0 || endLine >= len(lines) {
+			return &EditDocumentResponse{ErrorMessage: fmt.Sprintf("Line range %d-%d is out of range (document has %d lines)", req.StartLine, endLine, len(lines))}, nil
+		}
+		lines = append(lines[:req.StartLine], append(content, lines[endLine+1:]...)...)
+	default:
+		return &EditDocumentResponse{ErrorMessage: "mode must be one of: append, replace_lines"}, nil
+	}
+
+	text := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		text += "\n"
+	}
+
+	written, err := writeOutputFile(req.OutputPath, []byte(text), true)
+	if err != nil {
+		return &EditDocumentResponse{ErrorMessage: fmt.Sprintf("Failed to write document: %v", err)}, nil
+	}
+
+	return &EditDocumentResponse{
+		OutputPath: written,
+		TotalLines: len(lines),
+	}, nil
+}
+
+// rowsToCSV Encode rows as CSV
+func rowsToCSV(rows [][]string) ([]byte, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("rows are required for csv")
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sheetsToXLSX Build a workbook; numeric cell values are stored as numbers
+func sheetsToXLSX(sheets []SheetData) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	for i, sheet := range sheets {
+		name := sheet.Name
+		if name == "" {
+			name = fmt.Sprintf("Sheet%d", i+1)
+		}
+
+		if i == 0 {
+			if err := f.SetSheetName(f.GetSheetName(0), name); err != nil {
+				return nil, err
+			}
+		} else if _, err := f.NewSheet(name); err != nil {
+			return nil, err
+		}
+
+		for r, row := range sheet.Rows {
+			for c, value := range row {
+				cell, err := excelize.CoordinatesToCellName(c+1, r+1)
+				if err != nil {
+					return nil, err
+				}
+				var cellValue any = value
+				if number, err := strconv.ParseFloat(value, 64); err == nil && strings.TrimSpace(value) == value && value != "" {
+					cellValue = number
+				}
+				if err := f.SetCellValue(name, cell, cellValue); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,221 @@
+package doc
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	markdownBulletPattern    = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	markdownNumberedPattern  = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	markdownTableRulePattern = regexp.MustCompile(`^\|?\s*:?-{3,}:?\s*(\|\s*:?-{3,}:?\s*)*\|?$`)
+	markdownRulePattern      = regexp.MustCompile(`^(-{3,}|\*{3,}|_{3,})$`)
+	markdownEmphasisPattern  = regexp.MustCompile(`\*\*(.+?)\*\*|\*(.+?)\*|` + "`(.+?)`")
+)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+</Types>`
+
+const docxPackageRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+const docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// markdownToDOCX Build a DOCX package from markdown.
+// Supports headings, paragraphs, bullet and numbered lists, tables, code blocks and **bold**, *italic* and `code` spans.
+func markdownToDOCX(markdown string) ([]byte, error) {
+	var body strings.Builder
+	lines := splitLines(markdown)
+
+	var paragraph []string
+	flushParagraph := func() {
+		if len(paragraph) > 0 {
+			body.WriteString(docxParagraph("", strings.Join(paragraph, " ")))
+			paragraph = nil
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "" || markdownRulePattern.MatchString(trimmed):
+			flushParagraph()
+
+		case strings.HasPrefix(trimmed, "```"):
+			flushParagraph()
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				body.WriteString(docxPlainParagraph("Code", lines[i]))
+			}
+
+		case markdownHeadingPattern.MatchString(trimmed):
+			flushParagraph()
+			match := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			body.WriteString(docxParagraph(fmt.Sprintf("Heading%d", len(match[1])), match[2]))
+
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && markdownTableRulePattern.MatchString(strings.TrimSpace(lines[i+1])):
+			flushParagraph()
+			rows := [][]string{markdownTableCells(trimmed)}
+			for i += 2; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				rows = append(rows, markdownTableCells(strings.TrimSpace(lines[i])))
+			}
+			i--
+			body.WriteString(docxTable(rows))
+
+		case markdownBulletPattern.MatchString(line):
+			flushParagraph()
+			match := markdownBulletPattern.FindStringSubmatch(line)
+			body.WriteString(docxParagraph("ListParagraph", strings.Repeat("  ", len(match[1])/2)+"• "+match[2]))
+
+		case markdownNumberedPattern.MatchString(line):
+			flushParagraph()
+			match := markdownNumberedPattern.FindStringSubmatch(line)
+			body.WriteString(docxParagraph("ListParagraph", strings.Repeat("  ", len(match[1])/2)+match[2]+". "+match[3]))
+
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	flushParagraph()
+
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		body.String() +
+		`<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1440" w:right="1440" w:bottom="1440" w:left="1440"/></w:sectPr></w:body></w:document>`
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxPackageRels},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/styles.xml", docxStyles()},
+		{"word/document.xml", document},
+	}
+	for _, part := range parts {
+		w, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// docxParagraph A paragraph with an optional style, rendering inline emphasis as runs
+func docxParagraph(style, text string) string {
+	var builder strings.Builder
+	builder.WriteString("<w:p>")
+	if style != "" {
+		builder.WriteString(`<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`)
+	}
+
+	last := 0
+	for _, match := range markdownEmphasisPattern.FindAllStringSubmatchIndex(text, -1) {
+		builder.WriteString(docxRun("", text[last:match[0]]))
+		switch {
+		case match[2] >= 0:
+			builder.WriteString(docxRun("<w:b/>", text[match[2]:match[3]]))
+		case match[4] >= 0:
+			builder.WriteString(docxRun("<w:i/>", text[match[4]:match[5]]))
+		default:
+			builder.WriteString(docxRun(`<w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/>`, text[match[6]:match[7]]))
+		}
+		last = match[1]
+	}
+	builder.WriteString(docxRun("", text[last:]))
+
+	builder.WriteString("</w:p>")
+	return builder.String()
+}
+
+// docxPlainParagraph A paragraph whose text is kept verbatim
+func docxPlainParagraph(style, text string) string {
+	return `<w:p><w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>` + docxRun("", text) + "</w:p>"
+}
+
+// docxRun A text run with optional run properties
+func docxRun(properties, text string) string {
+	if text == "" {
+		return ""
+	}
+	var escaped strings.Builder
+	_ = xml.EscapeText(&escaped, []byte(text))
+
+	run := "<w:r>"
+	if properties != "" {
+		run += "<w:rPr>" + properties + "</w:rPr>"
+	}
+	return run + `<w:t xml:space="preserve">` + escaped.String() + "</w:t></w:r>"
+}
+
+// docxTable A bordered table; the first row is bold
+func docxTable(rows [][]string) string {
+	var builder strings.Builder
+	builder.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="0" w:type="auto"/></w:tblPr>`)
+	for i, row := range rows {
+		builder.WriteString("<w:tr>")
+		for _, cell := range row {
+			text := cell
+			if i == 0 && text != "" {
+				text = "**" + text + "**"
+			}
+			builder.WriteString("<w:tc>" + docxParagraph("", text) + "</w:tc>")
+		}
+		builder.WriteString("</w:tr>")
+	}
+	builder.WriteString("</w:tbl>")
+	return builder.String()
+}
+
+// markdownTableCells Split a markdown table row into trimmed cells
+func markdownTableCells(line string) []string {
+	line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+	cells := strings.Split(line, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// docxStyles Styles for the paragraphs written by markdownToDOCX
+func docxStyles() string {
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:pPr><w:spacing w:after="120"/></w:pPr><w:rPr><w:sz w:val="22"/></w:rPr></w:style>`)
+
+	sizes := []int{32, 28, 26, 24, 22, 22}
+	for level := 1; level <= 6; level++ {
+		builder.WriteString(fmt.Sprintf(`<w:style w:type="paragraph" w:styleId="Heading%d"><w:name w:val="heading %d"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="%d"/></w:pPr><w:rPr><w:b/><w:sz w:val="%d"/></w:rPr></w:style>`,
+			level, level, level-1, sizes[level-1]))
+	}
+
+	builder.WriteString(`<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:pPr><w:ind w:left="360"/><w:spacing w:after="60"/></w:pPr></w:style>
+<w:style w:type="paragraph" w:styleId="Code"><w:name w:val="Code"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="0"/></w:pPr><w:rPr><w:rFonts w:ascii="Consolas" w:hAnsi="Consolas"/><w:sz w:val="20"/></w:rPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/><w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:left w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:bottom w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:right w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideH w:val="single" w:sz="4" w:space="0" w:color="auto"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="auto"/></w:tblBorders></w:tblPr></w:style>
+</w:styles>`)
+
+	return builder.String()
+}
@@ -0,0 +1,370 @@
+package fetch
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const (
+	// minParagraphLength Characters a paragraph needs to count towards its container's score
+	minParagraphLength = 25
+	// minArticleLength Characters the best candidate needs to be treated as the article
+	minArticleLength = 250
+	// maxBylineLength Longer author elements are not bylines
+	maxBylineLength = 100
+)
+
+// Article Metadata of the main article extracted from an HTML page
+type Article struct {
+	Title     string `json:"title,omitempty" jsonschema:"description:Article title"`
+	Byline    string `json:"byline,omitempty" jsonschema:"description:Author as given by the page"`
+	Published string `json:"published,omitempty" jsonschema:"description:Publish date as given by the page"`
+	LeadImage string `json:"lead_image,omitempty" jsonschema:"description:Absolute URL of the lead image"`
+	Fallback  bool   `json:"fallback,omitempty" jsonschema:"description:No main article was found and content is the whole page body"`
+}
+
+// clutterSelector Elements that never belong to an article
+const clutterSelector = "script, style, noscript, template, iframe, form, nav, footer, aside, button, svg, canvas, select, input, dialog, " +
+	"[role=navigation], [role=banner], [role=contentinfo], [role=complementary], [role=dialog], [aria-hidden=true], [hidden]"
+
+// unlikelyPattern Class and id hints of page furniture such as menus, banners and comments
+var unlikelyPattern = regexp.MustCompile(`(?i)-ad-|advert|banner|breadcrumb|combx|comment|community|consent|cookie|disqus|footer|gdpr|header|menu|modal|nav|newsletter|pager|popup|promo|related|remark|replies|rss|share|shoutbox|sidebar|skyscraper|social|sponsor|subscribe|toolbar|widget`)
+
+// maybeArticlePattern Class and id hints that keep an element matching unlikelyPattern
+var maybeArticlePattern = regexp.MustCompile(`(?i)and|article|body|column|content|main|shadow`)
+
+// positivePattern Class and id hints of article containers
+var positivePattern = regexp.MustCompile(`(?i)article|blog|body|content|entry|h-entry|hentry|main|page|post|story|text`)
+
+// blockSelector Children that stop a <div> from being scored as a paragraph
+const blockSelector = "address, article, aside, blockquote, dl, div, fieldset, figure, footer, form, h1, h2, h3, h4, h5, h6, header, hr, ol, p, pre, section, table, ul"
+
+// extractArticle Extract the main article of an HTML page as Markdown with its metadata,
+// falling back to the whole body when no container holds enough text
+func extractArticle(content string, base *url.URL) (string, *Article, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	// Metadata first, while JSON-LD scripts and headers are still in the document
+	article := articleMetadata(doc, base)
+
+	doc.Find(clutterSelector).Remove()
+	doc.Find("header").Not("article header").Remove()
+	doc.Find("body *").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("article, main") {
+			return
+		}
+		hints := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if unlikelyPattern.MatchString(hints) && !maybeArticlePattern.MatchString(hints) {
+			s.Remove()
+		}
+	})
+
+	top := topCandidate(doc)
+	if top == nil {
+		article.Fallback = true
+		markdown, err := convertHTMLToMarkdown(content)
+		return markdown, article, err
+	}
+
+	main := articleContent(top)
+	main.Find("div, section, ul, ol, table").Each(func(_ int, s *goquery.Selection) {
+		if linkDensity(s) > 0.5 {
+			s.Remove()
+		}
+	})
+	absolutizeLinks(main, base)
+
+	if article.LeadImage == "" {
+		if src, ok := main.Find("img[src]").First().Attr("src"); ok {
+			article.LeadImage = src
+		}
+	}
+
+	body, err := goquery.OuterHtml(main)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to extract article: %w", err)
+	}
+	markdown, err := convertHTMLToMarkdown(body)
+	if err != nil {
+		return "", nil, err
+	}
+	return strings.TrimSpace(markdown), article, nil
+}
+
+// topCandidate Score containers by the paragraphs inside them and return the best, or nil
+// when none holds an article's worth of text
+func topCandidate(doc *goquery.Document) *goquery.Selection {
+	scores := map[*html.Node]float64{}
+	var order []*goquery.Selection
+
+	add := func(s *goquery.Selection, score float64) {
+		if s.Length() == 0 || s.Is("html") {
+			return
+		}
+		node := s.Get(0)
+		if _, ok := scores[node]; !ok {
+			scores[node] = initialScore(s)
+			order = append(order, s)
+		}
+		scores[node] += score
+	}
+
+	doc.Find("p, pre, td, blockquote, div").Each(func(_ int, s *goquery.Selection) {
+		if s.Is("div") && s.Find(blockSelector).Length() > 0 {
+			return
+		}
+		text := cleanText(s.Text())
+		length := utf8.RuneCountInString(text)
+		if length < minParagraphLength {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "，")) + math.Min(float64(length)/100, 3)
+		add(s.Parent(), score)
+		add(s.Parent().Parent(), score/2)
+	})
+
+	var best *goquery.Selection
+	bestScore := 0.0
+	for _, s := range order {
+		score := scores[s.Get(0)] * (1 - linkDensity(s))
+		scores[s.Get(0)] = score
+		if best == nil || score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	if best == nil || best.Is("body") || utf8.RuneCountInString(cleanText(best.Text())) < minArticleLength {
+		return nil
+	}
+
+	// Keep scored siblings that belong to the article, such as a split body
+	threshold := math.Max(10, bestScore*0.2)
+	best.Siblings().Each(func(_ int, s *goquery.Selection) {
+		if score, ok := scores[s.Get(0)]; ok && score >= threshold {
+			s.SetAttr("data-fetch-article", "")
+		}
+	})
+	best.SetAttr("data-fetch-article", "")
+	return best
+}
+
+// articleContent The top candidate and the siblings marked by topCandidate, in document order
+func articleContent(top *goquery.Selection) *goquery.Selection {
+	parts := top.Parent().ChildrenFiltered("[data-fetch-article]")
+	parts.RemoveAttr("data-fetch-article")
+	if parts.Length() == 1 {
+		return parts
+	}
+	wrapper := goquery.NewDocumentFromNode(&html.Node{Type: html.ElementNode, Data: "div"}).Selection
+	return wrapper.AppendSelection(parts)
+}
+
+// initialScore Score a container starts with, from its tag and class/id hints
+func initialScore(s *goquery.Selection) float64 {
+	score := 0.0
+	switch goquery.NodeName(s) {
+	case "article":
+		score += 10
+	case "div":
+		score += 5
+	case "pre", "td", "blockquote":
+		score += 3
+	case "address", "ol", "ul", "dl", "dd", "dt", "li", "form":
+		score -= 3
+	case "h1", "h2", "h3", "h4", "h5", "h6", "th":
+		score -= 5
+	}
+
+	hints := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+	if positivePattern.MatchString(hints) {
+		score += 25
+	}
+	if unlikelyPattern.MatchString(hints) {
+		score -= 25
+	}
+	return score
+}
+
+// linkDensity Share of an element's text that is link text
+func linkDensity(s *goquery.Selection) float64 {
+	length := utf8.RuneCountInString(cleanText(s.Text()))
+	if length == 0 {
+		return 0
+	}
+	links := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		links += utf8.RuneCountInString(cleanText(a.Text()))
+	})
+	return float64(links) / float64(length)
+}
+
+// absolutizeLinks Resolve link and image URLs against the page URL, promoting lazy-loaded image sources
+func absolutizeLinks(s *goquery.Selection, base *url.URL) {
+	s.Find("img").Each(func(_ int, img *goquery.Selection) {
+		if _, ok := img.Attr("src"); !ok {
+			if lazy := img.AttrOr("data-src", ""); lazy != "" {
+				img.SetAttr("src", lazy)
+			}
+		}
+	})
+	for _, attr := range []string{"href", "src"} {
+		s.Find("[" + attr + "]").Each(func(_ int, el *goquery.Selection) {
+			el.SetAttr(attr, resolveURL(base, el.AttrOr(attr, "")))
+		})
+	}
+}
+
+// resolveURL Resolve a possibly relative URL against base
+func resolveURL(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if base == nil || ref == "" {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// articleMetadata Read title, byline, publish date and lead image from meta tags, JSON-LD and markup
+func articleMetadata(doc *goquery.Document, base *url.URL) *Article {
+	ld := linkedData(doc)
+
+	article := &Article{
+		Title: firstNonEmpty(
+			metaContent(doc, "og:title", "twitter:title"),
+			ldString(ld["headline"]),
+			cleanText(doc.Find("article h1, main h1").First().Text()),
+			cleanText(doc.Find("title").First().Text()),
+		),
+		Byline: firstNonEmpty(
+			notURL(metaContent(doc, "author", "article:author", "byl", "dc.creator")),
+			notURL(ldString(ld["author"])),
+			markupByline(doc),
+		),
+		Published: firstNonEmpty(
+			metaContent(doc, "article:published_time", "datePublished", "date", "pubdate", "publish-date", "dc.date"),
+			ldString(ld["datePublished"]),
+			doc.Find("article time[datetime], time[datetime]").First().AttrOr("datetime", ""),
+		),
+		LeadImage: firstNonEmpty(
+			metaContent(doc, "og:image", "og:image:url", "twitter:image", "twitter:image:src"),
+			ldString(ld["image"]),
+		),
+	}
+	if article.LeadImage != "" {
+		article.LeadImage = resolveURL(base, article.LeadImage)
+	}
+	return article
+}
+
+// metaContent Content of the first <meta> whose property, name or itemprop is one of names
+func metaContent(doc *goquery.Document, names ...string) string {
+	for _, name := range names {
+		for _, attr := range []string{"property", "name", "itemprop"} {
+			selector := fmt.Sprintf("meta[%s=%q i]", attr, name)
+			if content := strings.TrimSpace(doc.Find(selector).First().AttrOr("content", "")); content != "" {
+				return content
+			}
+		}
+	}
+	return ""
+}
+
+// markupByline Author from rel=author, itemprop=author or byline/author classes
+func markupByline(doc *goquery.Document) string {
+	var byline string
+	doc.Find(`[rel=author], [itemprop=author], .byline, .author, [class*=byline]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		text := cleanText(s.Text())
+		if len(text) > 3 && strings.EqualFold(text[:3], "by ") {
+			text = text[3:]
+		}
+		if text != "" && utf8.RuneCountInString(text) <= maxBylineLength {
+			byline = text
+			return false
+		}
+		return true
+	})
+	return byline
+}
+
+// linkedData The first JSON-LD object describing an article, or nil
+func linkedData(doc *goquery.Document) map[string]any {
+	var found map[string]any
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data any
+		if json.Unmarshal([]byte(s.Text()), &data) != nil {
+			return true
+		}
+		found = findArticleObject(data)
+		return found == nil
+	})
+	return found
+}
+
+// findArticleObject Search JSON-LD values, including @graph lists, for an object with a headline
+func findArticleObject(data any) map[string]any {
+	switch v := data.(type) {
+	case map[string]any:
+		if _, ok := v["headline"]; ok {
+			return v
+		}
+		return findArticleObject(v["@graph"])
+	case []any:
+		for _, item := range v {
+			if found := findArticleObject(item); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// ldString Text of a JSON-LD value: a string, an object's name or url, or the first list item
+func ldString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		return firstNonEmpty(ldString(v["name"]), ldString(v["url"]))
+	case []any:
+		if len(v) > 0 {
+			return ldString(v[0])
+		}
+	}
+	return ""
+}
+
+// notURL Drop profile URLs given where a name is expected
+func notURL(value string) string {
+	if strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return ""
+	}
+	return value
+}
+
+// firstNonEmpty First value that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// cleanText Collapse whitespace runs to single spaces
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
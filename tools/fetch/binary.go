@@ -0,0 +1,269 @@
+package fetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// MaxDocumentSize Maximum size of a document read as text (50MB); documents are only readable whole
+	MaxDocumentSize = 50 * 1024 * 1024
+	// MaxImageSize Maximum size of an image returned as image content (5MB)
+	MaxImageSize = 5 * 1024 * 1024
+)
+
+// bodyKind How a response body is returned
+type bodyKind int
+
+const (
+	textBody bodyKind = iota
+	documentBody
+	binaryBody
+)
+
+// documentTypes Extensions of document content types, checked before the URL extension
+var documentTypes = map[string]string{
+	"application/pdf":    ".pdf",
+	"application/msword": ".doc",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document": ".docx",
+	"application/vnd.ms-excel": ".xls",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         ".xlsx",
+	"application/vnd.ms-powerpoint":                                             ".ppt",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": ".pptx",
+	"application/vnd.oasis.opendocument.text":                                   ".odt",
+	"application/vnd.oasis.opendocument.spreadsheet":                            ".ods",
+	"application/vnd.oasis.opendocument.presentation":                           ".odp",
+	"application/epub+zip":                                                      ".epub",
+	"application/rtf":                                                           ".rtf",
+}
+
+// textTypes Non-text/* media types whose bodies are text
+var textTypes = []string{
+	"application/javascript",
+	"application/ecmascript",
+	"application/json",
+	"application/x-ndjson",
+	"application/xml",
+	"application/yaml",
+	"application/x-yaml",
+	"application/toml",
+	"application/x-www-form-urlencoded",
+	"application/graphql",
+	"application/sql",
+	"application/x-sh",
+	"message/rfc822",
+}
+
+// imageTypes Image types returned as image content
+var imageTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+// DocumentPage A page of a fetched document; Name is set for named pages such as sheets
+type DocumentPage struct {
+	Name  string
+	Lines []string
+}
+
+// DocumentReader Parses fetched documents such as PDF and Office files; the doc tools register one
+type DocumentReader interface {
+	// Extensions returns the lowercase file extensions (with leading dot) handled by this reader
+	Extensions() []string
+
+	// ReadPages parses the document at filePath into pages of text lines
+	ReadPages(filePath string) ([]DocumentPage, error)
+}
+
+// documentReader Reader for fetched documents, nil when none is registered
+var documentReader DocumentReader
+
+// RegisterDocumentReader Set the reader for fetched documents; without one they are returned as binary metadata
+func RegisterDocumentReader(reader DocumentReader) {
+	documentReader = reader
+}
+
+// DocumentInfo Page layout of a document returned as text
+type DocumentInfo struct {
+	Format     string             `json:"format" jsonschema:"description:Document file extension, such as .pdf or .docx"`
+	TotalPages int                `json:"total_pages" jsonschema:"description:Number of pages, slides or sheets"`
+	Pages      []DocumentPageInfo `json:"pages,omitempty" jsonschema:"description:Where each page starts in the content"`
+}
+
+// DocumentPageInfo Position of a document page in the content
+type DocumentPageInfo struct {
+	PageNumber int    `json:"page_number" jsonschema:"description:Page number, 0-based like the page_index and start_page of the document tools"`
+	Name       string `json:"name,omitempty" jsonschema:"description:Page name, such as a sheet name"`
+	StartIndex int    `json:"start_index" jsonschema:"description:Character offset of the page in the whole content, usable as start_index"`
+}
+
+// BinaryInfo Description of an image or other binary response
+type BinaryInfo struct {
+	MIMEType string `json:"mime_type" jsonschema:"description:Declared or detected MIME type"`
+	Size     int64  `json:"size" jsonschema:"description:Size in bytes"`
+	SHA256   string `json:"sha256,omitempty" jsonschema:"description:SHA-256 of the content, absent when it was too large to read whole"`
+	Width    int    `json:"width,omitempty" jsonschema:"description:Image width in pixels"`
+	Height   int    `json:"height,omitempty" jsonschema:"description:Image height in pixels"`
+}
+
+// ToolContent Return a fetched image as MCP image content
+func (r *FetchResponse) ToolContent() []mcp.Content {
+	if r == nil || len(r.image) == 0 {
+		return nil
+	}
+	return []mcp.Content{&mcp.ImageContent{Data: r.image, MIMEType: r.Binary.MIMEType}}
+}
+
+// classifyBody Route a response by its Content-Type, sniffing the body when the type is missing or
+// generic, and return the document extension for documents
+func classifyBody(body []byte, contentType string, target *url.URL) (bodyKind, string) {
+	if len(body) == 0 {
+		return textBody, ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	if isTextType(mediaType) {
+		if looksBinary(body) && detectCharset(body, contentType) == "" {
+			return binaryBody, ""
+		}
+		return textBody, ""
+	}
+	if ext := documentExtension(mediaType, target); ext != "" {
+		return documentBody, ext
+	}
+	return binaryBody, ""
+}
+
+// isTextType Whether a media type carries text
+func isTextType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") ||
+		slices.Contains(textTypes, mediaType)
+}
+
+// documentExtension Extension of a document the registered reader can parse, from the media type or
+// else the URL path, or an empty string
+func documentExtension(mediaType string, target *url.URL) string {
+	if documentReader == nil {
+		return ""
+	}
+	ext, ok := documentTypes[mediaType]
+	if !ok && target != nil {
+		ext = strings.ToLower(path.Ext(target.Path))
+	}
+	if ext == "" || !slices.Contains(documentReader.Extensions(), ext) {
+		return ""
+	}
+	return ext
+}
+
+// readDocument Parse a document with the registered reader into text with page headings,
+// markdown headings for the markdown and article formats
+func readDocument(body []byte, ext, format string) (string, *DocumentInfo, error) {
+	file, err := os.CreateTemp("", "fetch-*"+ext)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store document: %w", err)
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to store document: %w", err)
+	}
+
+	pages, err := documentReader.ReadPages(file.Name())
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read document: %w", err)
+	}
+
+	info := &DocumentInfo{Format: ext, TotalPages: len(pages)}
+	var content strings.Builder
+	length := 0
+	for i, page := range pages {
+		if i > 0 {
+			content.WriteString("\n\n")
+			length += 2
+		}
+		info.Pages = append(info.Pages, DocumentPageInfo{PageNumber: i, Name: page.Name, StartIndex: length})
+
+		var text strings.Builder
+		if len(pages) > 1 {
+			text.WriteString(documentPageHeading(i, page.Name, format))
+			text.WriteString("\n\n")
+		}
+		text.WriteString(strings.Join(page.Lines, "\n"))
+
+		content.WriteString(text.String())
+		length += utf8.RuneCountInString(text.String())
+	}
+	return content.String(), info, nil
+}
+
+// documentPageHeading Heading written before each page of a multi-page document, numbered from 0
+// like the document tools' page headings
+func documentPageHeading(number int, name, format string) string {
+	if format == "markdown" || format == "article" {
+		if name != "" {
+			return "## " + name
+		}
+		return fmt.Sprintf("## Page %d", number)
+	}
+	if name != "" {
+		return fmt.Sprintf("--- Page %d: %s ---", number, name)
+	}
+	return fmt.Sprintf("--- Page %d ---", number)
+}
+
+// binaryResponse Describe an image or other binary body; images within MaxImageSize that decode
+// are also returned as image content
+func binaryResponse(body []byte, contentType string, contentLength int64, truncated bool) (*BinaryInfo, []byte, string) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "" || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(body))
+	}
+
+	info := &BinaryInfo{MIMEType: mediaType, Size: int64(len(body))}
+	if truncated && contentLength > info.Size {
+		info.Size = contentLength
+	}
+	if !truncated {
+		sum := sha256.Sum256(body)
+		info.SHA256 = hex.EncodeToString(sum[:])
+	}
+
+	if !strings.HasPrefix(mediaType, "image/") {
+		return info, nil, fmt.Sprintf("Binary content (%s, %d bytes) is not returned as text.", mediaType, info.Size)
+	}
+
+	// Dimensions when a decoder is available; the type comes from the bytes when they decode
+	if config, format, err := image.DecodeConfig(bytes.NewReader(body)); err == nil {
+		info.MIMEType = "image/" + format
+		info.Width, info.Height = config.Width, config.Height
+	}
+	switch {
+	case !slices.Contains(imageTypes, info.MIMEType):
+		return info, nil, fmt.Sprintf("Image (%s, %d bytes) is not a PNG, JPEG, GIF or WebP image and is not returned.", info.MIMEType, info.Size)
+	case truncated || len(body) > MaxImageSize:
+		return info, nil, fmt.Sprintf("Image (%s, %d bytes) is larger than the %d byte limit and is not returned.", info.MIMEType, info.Size, MaxImageSize)
+	}
+	return info, body, fmt.Sprintf("Image (%s, %d bytes) is returned as image content.", info.MIMEType, info.Size)
+}
@@ -0,0 +1,118 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// charsetPrescanSize Bytes searched for a <meta> or XML encoding declaration
+const charsetPrescanSize = 1024
+
+// metaCharsetPattern Matches <meta charset="..."> and <meta http-equiv="Content-Type" content="...; charset=...">
+var metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.+-]+)`)
+
+// xmlEncodingPattern Matches the encoding of an XML declaration
+var xmlEncodingPattern = regexp.MustCompile(`^<\?xml[^>]+encoding\s*=\s*["']([a-zA-Z0-9_:.+-]+)["']`)
+
+// boms Byte order marks and the encodings they announce
+var boms = []struct {
+	bom  []byte
+	name string
+}{
+	{[]byte{0xEF, 0xBB, 0xBF}, "utf-8"},
+	{[]byte{0xFE, 0xFF}, "utf-16be"},
+	{[]byte{0xFF, 0xFE}, "utf-16le"},
+}
+
+// decodeBody Detect the character encoding of a text response from its BOM, the Content-Type
+// charset or an in-document declaration, and transcode it to UTF-8. It returns the content and
+// the canonical name of the original encoding.
+func decodeBody(body []byte, contentType string) (string, string, error) {
+	name := detectCharset(body, contentType)
+	if name == "" {
+		// Undeclared: UTF-8 when it decodes as such, else the web's legacy default
+		name = "utf-8"
+		if !utf8.Valid(body) {
+			name = "windows-1252"
+		}
+	}
+
+	if name == "utf-8" {
+		body = bytes.TrimPrefix(body, boms[0].bom)
+		return strings.ToValidUTF8(string(body), "�"), name, nil
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return "", "", fmt.Errorf("unsupported charset %s", name)
+	}
+	// A BOM overrides the declared encoding and is removed
+	decoded, _, err := transform.Bytes(unicode.BOMOverride(enc.NewDecoder()), body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to decode %s content: %w", name, err)
+	}
+	return strings.ToValidUTF8(string(decoded), "�"), name, nil
+}
+
+// detectCharset Canonical name of the declared encoding, or an empty string when none is declared
+func detectCharset(body []byte, contentType string) string {
+	for _, b := range boms {
+		if bytes.HasPrefix(body, b.bom) {
+			return b.name
+		}
+	}
+
+	if _, params, err := mime.ParseMediaType(contentType); err == nil {
+		if name := canonicalCharset(params["charset"]); name != "" {
+			return name
+		}
+	}
+
+	head := body[:min(len(body), charsetPrescanSize)]
+	if match := xmlEncodingPattern.FindSubmatch(head); match != nil {
+		if name := canonicalCharset(string(match[1])); name != "" {
+			return name
+		}
+	}
+	if match := metaCharsetPattern.FindSubmatch(head); match != nil {
+		if name := canonicalCharset(string(match[1])); name != "" {
+			// A document that declares UTF-16 in ASCII cannot be UTF-16
+			if strings.HasPrefix(name, "utf-16") {
+				return "utf-8"
+			}
+			return name
+		}
+	}
+
+	return ""
+}
+
+// canonicalCharset WHATWG name of a charset label, or an empty string for unknown labels
+func canonicalCharset(label string) string {
+	if strings.TrimSpace(label) == "" {
+		return ""
+	}
+	enc, err := htmlindex.Get(label)
+	if err != nil || enc == encoding.Replacement {
+		return ""
+	}
+	name, err := htmlindex.Name(enc)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// looksBinary Whether content contains NUL bytes, which undeclared text never does
+func looksBinary(body []byte) bool {
+	return bytes.IndexByte(body[:min(len(body), charsetPrescanSize)], 0) >= 0
+}
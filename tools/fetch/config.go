@@ -0,0 +1,69 @@
+package fetch
+
+import (
+	"net/textproto"
+	"strings"
+)
+
+// DefaultAllowedPorts Ports fetch may connect to unless Config.AllowedPorts says otherwise
+var DefaultAllowedPorts = []int{80, 443, 8080, 8443}
+
+// DefaultAllowedMethods HTTP methods fetch may send unless Config.AllowedMethods says otherwise
+var DefaultAllowedMethods = []string{"GET", "HEAD", "POST"}
+
+// Config Fetch tool settings
+type Config struct {
+	// AllowPrivate turns off the private, loopback, link-local and metadata address checks.
+	// Only for trusted deployments that must reach internal services.
+	AllowPrivate bool
+
+	// AllowHosts are the only hosts fetch may request; an entry also matches its subdomains.
+	// Optional. Empty allows every host that is not denied.
+	AllowHosts []string
+
+	// DenyHosts are hosts fetch never requests; an entry also matches its subdomains,
+	// and CIDR entries match IP address hosts.
+	DenyHosts []string
+
+	// AllowedPorts are the ports fetch may connect to.
+	// Optional. Empty means DefaultAllowedPorts.
+	AllowedPorts []int
+
+	// AllowedMethods are the HTTP methods fetch may send.
+	// Optional. Empty means DefaultAllowedMethods.
+	AllowedMethods []string
+
+	// AllowedHeaders are the request header names callers may set.
+	// Optional. Empty allows any header except hop-by-hop and proxy headers.
+	AllowedHeaders []string
+}
+
+// policy URL policy applied to every request and redirect
+var policy = newURLPolicy(&Config{})
+
+// allowedMethods HTTP methods callers may use, upper case
+var allowedMethods = DefaultAllowedMethods
+
+// allowedHeaders Canonical request header names callers may set, any when empty
+var allowedHeaders []string
+
+// Configure Apply fetch tool settings; call before GetTools
+func Configure(config *Config) {
+	if config == nil {
+		config = &Config{}
+	}
+	policy = newURLPolicy(config)
+
+	allowedMethods = DefaultAllowedMethods
+	if len(config.AllowedMethods) > 0 {
+		allowedMethods = nil
+		for _, method := range config.AllowedMethods {
+			allowedMethods = append(allowedMethods, strings.ToUpper(strings.TrimSpace(method)))
+		}
+	}
+
+	allowedHeaders = nil
+	for _, name := range config.AllowedHeaders {
+		allowedHeaders = append(allowedHeaders, textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name)))
+	}
+}
@@ -0,0 +1,556 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func TestURLPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		url     string
+		blocked string
+	}{
+		{name: "public https", config: &Config{}, url: "https://go.dev/doc/"},
+		{name: "alternate port", config: &Config{}, url: "http://example.com:8080/"},
+		{name: "scheme", config: &Config{}, url: "ftp://example.com/", blocked: "http:// or https://"},
+		{name: "port", config: &Config{}, url: "http://example.com:6379/", blocked: "port 6379"},
+		{name: "loopback", config: &Config{}, url: "http://127.0.0.1/", blocked: "private or reserved"},
+		{name: "metadata", config: &Config{}, url: "http://169.254.169.254/latest/meta-data/", blocked: "private or reserved"},
+		{name: "private", config: &Config{}, url: "http://10.0.0.8/", blocked: "private or reserved"},
+		{name: "ipv6 loopback", config: &Config{}, url: "http://[::1]/", blocked: "private or reserved"},
+		{name: "mapped ipv4", config: &Config{}, url: "http://[::ffff:192.168.1.1]/", blocked: "private or reserved"},
+		{name: "override", config: &Config{AllowPrivate: true}, url: "http://127.0.0.1/"},
+		{name: "deny subdomain", config: &Config{DenyHosts: []string{"example.com"}}, url: "https://api.example.com/", blocked: "deny list"},
+		{name: "deny cidr", config: &Config{AllowPrivate: true, DenyHosts: []string{"10.0.0.0/8"}}, url: "http://10.1.2.3/", blocked: "deny list"},
+		{name: "allow list", config: &Config{AllowHosts: []string{"*.go.dev"}}, url: "https://pkg.go.dev/", blocked: ""},
+		{name: "not allowed", config: &Config{AllowHosts: []string{"go.dev"}}, url: "https://example.com/", blocked: "allow list"},
+		{name: "custom ports", config: &Config{AllowedPorts: []int{3000}}, url: "https://example.com/", blocked: "port 443"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = newURLPolicy(tt.config).checkURL(u)
+			if tt.blocked == "" {
+				if err != nil {
+					t.Errorf("checkURL(%s) = %v, want allowed", tt.url, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.blocked) {
+				t.Errorf("checkURL(%s) = %v, want error containing %q", tt.url, err, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestIsBlockedAddr(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":              false,
+		"2606:4700::1111":      false,
+		"127.0.0.53":           true,
+		"0.0.0.0":              true,
+		"172.16.5.4":           true,
+		"100.100.100.200":      true,
+		"fd00:ec2::254":        true,
+		"fe80::1":              true,
+		"64:ff9b::a00:1":       true,
+		"64:ff9b::808:808":     false,
+		"255.255.255.255":      true,
+		"::":                   true,
+		"::ffff:169.254.1.1":   true,
+		"2001:db8::1":          true,
+		"203.0.113.10":         true,
+		"93.184.216.34":        false,
+		"224.0.0.251":          true,
+		"ff02::1":              true,
+		"192.0.0.192":          true,
+		"198.18.0.1":           true,
+		"1.1.1.1":              false,
+		"2a00:1450:4001::200e": false,
+	}
+	for addr, want := range tests {
+		if got := isBlockedAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("isBlockedAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestFetchPolicy(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "http://blocked.example:"+r.URL.Query().Get("port")+"/", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	// A host name passes the URL check, so the dialer must catch the loopback address
+	target := "http://localhost:" + u.Port() + "/"
+
+	Configure(&Config{AllowedPorts: []int{port}})
+	resp, _ := Fetch(context.Background(), &FetchRequest{URL: target})
+	if !strings.Contains(resp.ErrorMessage, "not allowed") || !strings.Contains(resp.ErrorMessage, "private or reserved") {
+		t.Errorf("loopback fetch ErrorMessage = %q", resp.ErrorMessage)
+	}
+
+	Configure(&Config{AllowPrivate: true, AllowedPorts: []int{port}})
+	resp, _ = Fetch(context.Background(), &FetchRequest{URL: target})
+	if resp.ErrorMessage != "" || resp.Content != "internal" {
+		t.Errorf("override fetch = %+v", resp)
+	}
+
+	Configure(&Config{AllowPrivate: true, AllowedPorts: []int{port}, DenyHosts: []string{"blocked.example"}})
+	resp, _ = Fetch(context.Background(), &FetchRequest{URL: target + "redirect?port=" + u.Port()})
+	if !strings.Contains(resp.ErrorMessage, "deny list") {
+		t.Errorf("redirect fetch ErrorMessage = %q", resp.ErrorMessage)
+	}
+}
+
+func TestFetchRequestOptions(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/missing" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "no such item"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("X-Trace", "a")
+		w.Header().Add("X-Trace", "b")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{
+			"method":        r.Method,
+			"query":         r.URL.RawQuery,
+			"body":          string(body),
+			"content_type":  r.Header.Get("Content-Type"),
+			"authorization": r.Header.Get("Authorization"),
+			"accept":        r.Header.Get("Accept"),
+			"user_agent":    r.Header.Get("User-Agent"),
+		})
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	Configure(&Config{AllowPrivate: true, AllowedPorts: []int{port}})
+	ctx := context.Background()
+
+	resp, _ := Fetch(ctx, &FetchRequest{
+		URL:         server.URL + "/items?a=1",
+		Format:      "json",
+		Method:      "post",
+		Headers:     map[string]string{"accept": "application/json", "User-Agent": "agent/2"},
+		QueryParams: map[string]string{"b": "2"},
+		JSONBody:    map[string]any{"name": "gopher"},
+		BearerToken: "secret",
+	})
+	if resp.ErrorMessage != "" || resp.StatusCode != http.StatusCreated {
+		t.Fatalf("POST response = %+v", resp)
+	}
+	var echo map[string]string
+	if err := json.Unmarshal([]byte(resp.Content), &echo); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"method":        "POST",
+		"query":         "a=1&b=2",
+		"body":          `{"name":"gopher"}`,
+		"content_type":  "application/json",
+		"authorization": "Bearer secret",
+		"accept":        "application/json",
+		"user_agent":    "agent/2",
+	}
+	for key, value := range want {
+		if echo[key] != value {
+			t.Errorf("server saw %s = %q, want %q", key, echo[key], value)
+		}
+	}
+	if resp.Headers["X-Trace"] != "a, b" {
+		t.Errorf("response headers = %v", resp.Headers)
+	}
+
+	resp, _ = Fetch(ctx, &FetchRequest{URL: server.URL + "/missing"})
+	if resp.StatusCode != http.StatusNotFound || !strings.Contains(resp.Content, "no such item") || resp.ErrorMessage == "" {
+		t.Errorf("404 response = %+v", resp)
+	}
+
+	rejected := []struct {
+		req  *FetchRequest
+		want string
+	}{
+		{&FetchRequest{URL: server.URL, Method: "DELETE"}, "method DELETE is not allowed"},
+		{&FetchRequest{URL: server.URL, Body: "x"}, "cannot be sent with GET"},
+		{&FetchRequest{URL: server.URL, Method: "POST", Body: "x", JSONBody: 1}, "either body or json_body"},
+		{&FetchRequest{URL: server.URL, Headers: map[string]string{"Host": "evil"}}, "header Host cannot be set"},
+		{&FetchRequest{URL: server.URL, Headers: map[string]string{"X-A": "1\r\nX-B: 2"}}, "line breaks"},
+		{&FetchRequest{URL: server.URL, BearerToken: "t", BasicAuth: &BasicAuth{Username: "u"}}, "either basic_auth or bearer_token"},
+		{&FetchRequest{URL: server.URL, Method: "POST", Body: strings.Repeat("x", MaxRequestBodySize+1)}, "too large"},
+	}
+	for _, tt := range rejected {
+		if resp, _ := Fetch(ctx, tt.req); !strings.Contains(resp.ErrorMessage, tt.want) {
+			t.Errorf("ErrorMessage = %q, want to contain %q", resp.ErrorMessage, tt.want)
+		}
+	}
+
+	Configure(&Config{AllowPrivate: true, AllowedPorts: []int{port}, AllowedMethods: []string{"get", "delete"}, AllowedHeaders: []string{"accept"}})
+	if resp, _ := Fetch(ctx, &FetchRequest{URL: server.URL, Method: "DELETE", Headers: map[string]string{"Accept": "text/plain"}}); resp.ErrorMessage != "" {
+		t.Errorf("configured DELETE = %+v", resp)
+	}
+	if resp, _ := Fetch(ctx, &FetchRequest{URL: server.URL, Headers: map[string]string{"X-Api-Key": "k"}}); !strings.Contains(resp.ErrorMessage, "not allowed") {
+		t.Errorf("unlisted header ErrorMessage = %q", resp.ErrorMessage)
+	}
+}
+
+func TestFetchCharset(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+
+	encode := func(enc encoding.Encoding, s string) []byte {
+		b, err := enc.NewEncoder().Bytes([]byte(s))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+
+	pages := map[string]struct {
+		contentType string
+		body        []byte
+	}{
+		"/header": {"text/plain; charset=GBK", encode(simplifiedchinese.GBK, "你好，世界")},
+		"/meta":   {"text/html", encode(japanese.ShiftJIS, `<html><head><meta charset="Shift_JIS"><title>t</title></head><body><p>こんにちは</p></body></html>`)},
+		"/equiv":  {"text/html", encode(charmap.Windows1252, `<meta http-equiv="Content-Type" content="text/html; charset=iso-8859-1"><p>café</p>`)},
+		"/xml":    {"application/xml", encode(simplifiedchinese.GBK, `<?xml version="1.0" encoding="gb2312"?><a>中文</a>`)},
+		"/bom":    {"text/plain; charset=iso-8859-1", encode(utf16, "naïve")},
+		"/guess":  {"text/plain", encode(charmap.Windows1252, "déjà vu")},
+		"/utf8":   {"text/plain", []byte("\xEF\xBB\xBFplain ü")},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := pages[r.URL.Path]
+		w.Header().Set("Content-Type", page.contentType)
+		w.Write(page.body)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	Configure(&Config{AllowPrivate: true, AllowedPorts: []int{port}})
+
+	tests := []struct {
+		path     string
+		format   string
+		content  string
+		encoding string
+	}{
+		{"/header", "text", "你好，世界", "gbk"},
+		{"/meta", "markdown", "こんにちは", "shift_jis"},
+		{"/equiv", "html", "café", "windows-1252"},
+		{"/xml", "text", "中文", "gbk"},
+		{"/bom", "text", "naïve", "utf-16le"},
+		{"/guess", "text", "déjà vu", "windows-1252"},
+		{"/utf8", "text", "plain ü", "utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, _ := Fetch(context.Background(), &FetchRequest{URL: server.URL + tt.path, Format: tt.format})
+			if resp.ErrorMessage != "" {
+				t.Fatalf("ErrorMessage = %q", resp.ErrorMessage)
+			}
+			if !strings.Contains(resp.Content, tt.content) || strings.HasPrefix(resp.Content, "\uFEFF") {
+				t.Errorf("Content = %q, want to contain %q", resp.Content, tt.content)
+			}
+			if resp.Encoding != tt.encoding {
+				t.Errorf("Encoding = %q, want %q", resp.Encoding, tt.encoding)
+			}
+		})
+	}
+
+}
+
+func TestFetchArticle(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+
+	page, err := os.ReadFile("testdata/article.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/short" {
+			w.Write([]byte(`<html><head><title>Status</title></head><body><nav><a href="/">Home</a></nav><p>All systems operational.</p></body></html>`))
+			return
+		}
+		w.Write(page)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	Configure(&Config{AllowPrivate: true, AllowedPorts: []int{port}})
+
+	resp, _ := Fetch(context.Background(), &FetchRequest{URL: server.URL + "/blog/go1.25", Format: "article"})
+	if resp.ErrorMessage != "" {
+		t.Fatalf("ErrorMessage = %q", resp.ErrorMessage)
+	}
+	want := Article{
+		Title:     "Go 1.25 is released",
+		Byline:    "Dana Gopher",
+		Published: "2025-08-12T10:00:00Z",
+		LeadImage: server.URL + "/images/gopher-lead.png",
+	}
+	if resp.Article == nil || *resp.Article != want {
+		t.Errorf("Article = %+v, want %+v", resp.Article, want)
+	}
+	for _, text := range []string{"container-aware GOMAXPROCS", "thank everyone", "(" + server.URL + "/blog/downloads/)", "(" + server.URL + "/blog/img/trace.png)"} {
+		if !strings.Contains(resp.Content, text) {
+			t.Errorf("Content does not contain %q:\n%s", text, resp.Content)
+		}
+	}
+	for _, text := range []string{"cookies", "Trending", "Share on", "Copyright", "News"} {
+		if strings.Contains(resp.Content, text) {
+			t.Errorf("Content contains %q:\n%s", text, resp.Content)
+		}
+	}
+
+	resp, _ = Fetch(context.Background(), &FetchRequest{URL: server.URL + "/short", Format: "article"})
+	if resp.ErrorMessage != "" || resp.Article == nil || !resp.Article.Fallback || resp.Article.Title != "Status" || !strings.Contains(resp.Content, "All systems operational.") {
+		t.Errorf("fallback response = %+v", resp)
+	}
+}
+
+func TestFetchPages(t *testing.T) {
+	t.Cleanup(func() { Configure(nil) })
+
+	var lines []string
+	for i := range 40 {
+		lines = append(lines, fmt.Sprintf("Line %02d of the long document, ünïcode included.", i))
+	}
+	page := strings.Join(lines, "\n")
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(page))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	Configure(&Config{AllowPrivate: true, AllowedPorts: []int{port}})
+	ctx := context.Background()
+
+	var parts []string
+	req := &FetchRequest{URL: server.URL + "/doc", MaxLength: 300}
+	for {
+		resp, _ := Fetch(ctx, req)
+		if resp.ErrorMessage != "" {
+			t.Fatalf("part at %d: ErrorMessage = %q", req.StartIndex, resp.ErrorMessage)
+		}
+		if resp.TotalLength != utf8.RuneCountInString(page) {
+			t.Errorf("TotalLength = %d, want %d", resp.TotalLength, utf8.RuneCountInString(page))
+		}
+		if n := utf8.RuneCountInString(resp.Content); n > req.MaxLength || (resp.NextIndex > 0 && !strings.HasSuffix(resp.Content, "\n")) {
+			t.Errorf("part at %d has %d characters, ending %q", req.StartIndex, n, resp.Content[len(resp.Content)-5:])
+		}
+		if resp.Cached != (req.StartIndex > 0) {
+			t.Errorf("part at %d: Cached = %v", req.StartIndex, resp.Cached)
+		}
+		parts = append(parts, resp.Content)
+		if resp.NextIndex == 0 {
+			break
+		}
+		req.StartIndex = resp.NextIndex
+	}
+	if strings.Join(parts, "") != page || len(parts) < 7 {
+		t.Errorf("%d parts do not add up to the page", len(parts))
+	}
+	if requests != 1 {
+		t.Errorf("server saw %d requests, want 1", requests)
+	}
+
+	// A POST is sent once; when its cached parts are gone it is not sent again
+	post := &FetchRequest{URL: server.URL + "/doc", Method: "POST", Body: "q=1", MaxLength: 300}
+	first, _ := Fetch(ctx, post)
+	post.StartIndex = first.NextIndex
+	if next, _ := Fetch(ctx, post); next.ErrorMessage != "" || !next.Cached {
+		t.Errorf("cached POST part = %+v", next)
+	}
+	saved := pages
+	pages = newPageCache(PageCacheTTL, PageCacheSize)
+	if missed, _ := Fetch(ctx, post); !strings.Contains(missed.ErrorMessage, "start_index 0") {
+		t.Errorf("uncached POST part ErrorMessage = %q", missed.ErrorMessage)
+	}
+	pages = saved
+	if requests != 2 {
+		t.Errorf("server saw %d requests after the POST, want 2", requests)
+	}
+
+	resp, _ := Fetch(ctx, &FetchRequest{URL: server.URL + "/doc", StartIndex: 5000})
+	if !strings.Contains(resp.ErrorMessage, "beyond the end") {
+		t.Errorf("out of range ErrorMessage = %q", resp.ErrorMessage)
+	}
+	if resp, _ := Fetch(ctx, &FetchRequest{URL: server.URL + "/doc", MaxLength: -1}); resp.ErrorMessage == "" {
+		t.Error("negative max_length accepted")
+	}
+}
+
+func TestPageCache(t *testing.T) {
+	now := time.Now()
+	cache := newPageCache(time.Minute, 10)
+	cache.now = func() time.Time { return now }
+
+	cache.put("a", &FetchResponse{Content: "12345"})
+	cache.put("b", &FetchResponse{Content: "12345"})
+	if _, ok := cache.get("a"); !ok {
+		t.Fatal("a missing")
+	}
+	// a was used more recently, so b is evicted to make room
+	cache.put("c", &FetchResponse{Content: "123"})
+	if _, ok := cache.get("b"); ok {
+		t.Error("b not evicted")
+	}
+	if resp, ok := cache.get("a"); !ok || resp.Content != "12345" {
+		t.Errorf("a = %+v, %v", resp, ok)
+	}
+
+	cache.put("big", &FetchResponse{Content: strings.Repeat("x", 11)})
+	if _, ok := cache.get("big"); ok {
+		t.Error("oversized entry cached")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.get("c"); ok {
+		t.Error("c not expired")
+	}
+}
+
+// stubDocumentReader Reads PDF files as two pages holding the file's size
+type stubDocumentReader struct{}
+
+func (stubDocumentReader) Extensions() []string { return []string{".pdf"} }
+
+func (stubDocumentReader) ReadPages(filePath string) ([]DocumentPage, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return []DocumentPage{
+		{Lines: []string{"first page", fmt.Sprintf("%d bytes", info.Size())}},
+		{Lines: []string{"second page"}},
+	}, nil
+}
+
+func TestFetchBinary(t *testing.T) {
+	t.Cleanup(func() { Configure(nil); RegisterDocumentReader(nil) })
+	RegisterDocumentReader(stubDocumentReader{})
+
+	var pngData bytes.Buffer
+	png.Encode(&pngData, image.NewGray(image.Rect(0, 0, 4, 3)))
+	archive := []byte("PK\x03\x04\x14\x00\x00\x00\x00\x00")
+	pdf := []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n1 0 obj\n<<>>\nendobj\n")
+
+	bodies := map[string]struct {
+		contentType string
+		body        []byte
+	}{
+		"/report":     {"application/pdf", pdf},
+		"/sniffed":    {"", pdf},
+		"/logo.png":   {"application/octet-stream", pngData.Bytes()},
+		"/icon":       {"image/x-icon", []byte{0, 0, 1, 0, 1, 0}},
+		"/bundle.zip": {"application/zip", archive},
+		"/nul.txt":    {"text/plain", []byte("abc\x00\x01\x02")},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := bodies[r.URL.Path]
+		w.Header().Set("Content-Type", entry.contentType)
+		w.Write(entry.body)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(u.Port())
+	Configure(&Config{AllowPrivate: true, AllowedPorts: []int{port}})
+	ctx := context.Background()
+
+	for _, path := range []string{"/report", "/sniffed"} {
+		resp, _ := Fetch(ctx, &FetchRequest{URL: server.URL + path})
+		want := fmt.Sprintf("--- Page 0 ---\n\nfirst page\n%d bytes\n\n--- Page 1 ---\n\nsecond page", len(pdf))
+		if resp.ErrorMessage != "" || resp.Content != want {
+			t.Fatalf("%s response = %+v", path, resp)
+		}
+		second := resp.Document.Pages[1]
+		if resp.Document.Format != ".pdf" || resp.Document.TotalPages != 2 || second.PageNumber != 1 || !strings.HasPrefix(resp.Content[second.StartIndex:], "--- Page 1 ---") {
+			t.Errorf("%s document = %+v", path, resp.Document)
+		}
+	}
+
+	resp, _ := Fetch(ctx, &FetchRequest{URL: server.URL + "/logo.png"})
+	if resp.ErrorMessage != "" || resp.Binary == nil || resp.Binary.MIMEType != "image/png" || resp.Binary.Width != 4 || resp.Binary.Height != 3 {
+		t.Fatalf("image response = %+v", resp)
+	}
+	content := resp.ToolContent()
+	if len(content) != 1 || !bytes.Equal(content[0].(*mcp.ImageContent).Data, pngData.Bytes()) {
+		t.Errorf("image ToolContent = %v", content)
+	}
+
+	resp, _ = Fetch(ctx, &FetchRequest{URL: server.URL + "/icon"})
+	if resp.Binary == nil || resp.Binary.MIMEType != "image/x-icon" || resp.ToolContent() != nil || !strings.Contains(resp.Content, "not returned") {
+		t.Errorf("icon response = %+v", resp)
+	}
+
+	for _, path := range []string{"/bundle.zip", "/nul.txt"} {
+		resp, _ = Fetch(ctx, &FetchRequest{URL: server.URL + path})
+		body := bodies[path].body
+		sum := sha256.Sum256(body)
+		if resp.ErrorMessage != "" || resp.Binary == nil || resp.Binary.Size != int64(len(body)) || resp.Binary.SHA256 != hex.EncodeToString(sum[:]) || resp.ToolContent() != nil {
+			t.Errorf("%s response = %+v", path, resp)
+		}
+	}
+
+	// Without a document reader, documents are described like other binaries
+	RegisterDocumentReader(nil)
+	resp, _ = Fetch(ctx, &FetchRequest{URL: server.URL + "/report"})
+	if resp.Document != nil || resp.Binary == nil || resp.Binary.MIMEType != "application/pdf" {
+		t.Errorf("unread document response = %+v", resp)
+	}
+}
@@ -2,6 +2,7 @@ package fetch
 
 import (
 	"context"
+	"errors"
 	"fkmcps/constants"
 	"fmt"
 	"io"
@@ -10,7 +11,6 @@ import (
 	"os"
 	"strings"
 	"time"
-	"unicode/utf8"
 
 	md "github.com/JohannesKaufmann/html-to-markdown/v2"
 	"github.com/PuerkitoBio/goquery"
@@ -28,8 +28,19 @@ const (
 // FetchRequest HTTP request parameters
 type FetchRequest struct {
 	URL     string `json:"url" jsonschema:"required,description:URL address to fetch content from (must start with http:// or https://)"`
-	Format  string `json:"format,omitempty" jsonschema:"description:Format of returned content (text/markdown/html/json), default text. text format automatically extracts plain text from HTML, markdown format converts HTML to markdown, html format returns raw HTML, json format keeps JSON as-is"`
+	Format  string `json:"format,omitempty" jsonschema:"description:Format of returned content (text/markdown/html/json/article), default text. text format automatically extracts plain text from HTML, markdown format converts HTML to markdown, html format returns raw HTML, json format keeps JSON as-is, article format returns only the main article as markdown with its metadata"`
 	Timeout int    `json:"timeout,omitempty" jsonschema:"description:Request timeout in seconds, default 30 seconds, maximum 120 seconds"`
+
+	Method      string            `json:"method,omitempty" jsonschema:"description:HTTP method, default GET. The server limits the allowed methods"`
+	Headers     map[string]string `json:"headers,omitempty" jsonschema:"description:Request headers such as Accept or Authorization. The server may limit the allowed header names"`
+	QueryParams map[string]string `json:"query_params,omitempty" jsonschema:"description:Query parameters added to the URL"`
+	Body        string            `json:"body,omitempty" jsonschema:"description:Raw request body, set Content-Type in headers. Not allowed with GET or HEAD"`
+	JSONBody    any               `json:"json_body,omitempty" jsonschema:"description:Request body encoded as JSON with Content-Type application/json. Use instead of body"`
+	BasicAuth   *BasicAuth        `json:"basic_auth,omitempty" jsonschema:"description:HTTP basic authentication credentials"`
+	BearerToken string            `json:"bearer_token,omitempty" jsonschema:"description:Bearer token sent in the Authorization header"`
+
+	StartIndex int `json:"start_index,omitempty" jsonschema:"description:Character offset into the processed content to start from, default 0. Pass next_index from the previous response to read the next part"`
+	MaxLength  int `json:"max_length,omitempty" jsonschema:"description:Maximum characters of processed content to return, default all"`
 }
 
 // FetchResponse HTTP response
@@ -39,6 +50,21 @@ type FetchResponse struct {
 	ContentType  string `json:"content_type,omitempty" jsonschema:"description:Original content type"`
 	IsTruncated  bool   `json:"is_truncated,omitempty" jsonschema:"description:Whether content is truncated"`
 	ErrorMessage string `json:"error_message,omitempty" jsonschema:"description:Error message"`
+
+	Headers map[string]string `json:"headers,omitempty" jsonschema:"description:Response headers, repeated values joined with a comma"`
+
+	Encoding string `json:"encoding,omitempty" jsonschema:"description:Original character encoding of the content, transcoded to UTF-8"`
+
+	Article *Article `json:"article,omitempty" jsonschema:"description:Title, byline, publish date and lead image of the extracted article (article format)"`
+
+	TotalLength int  `json:"total_length,omitempty" jsonschema:"description:Length of the whole processed content in characters"`
+	NextIndex   int  `json:"next_index,omitempty" jsonschema:"description:start_index of the next part, absent on the last part"`
+	Cached      bool `json:"cached,omitempty" jsonschema:"description:Part served from the page cache without fetching the URL again"`
+
+	Document *DocumentInfo `json:"document,omitempty" jsonschema:"description:Page layout of a PDF, Office or other document returned as text"`
+	Binary   *BinaryInfo   `json:"binary,omitempty" jsonschema:"description:Type, size and hash of an image or other binary response; images are also returned as image content"`
+
+	image []byte
 }
 
 // Fetch Send HTTP request to get web resources
@@ -48,9 +74,13 @@ func Fetch(ctx context.Context, req *FetchRequest) (*FetchResponse, error) {
 		return &FetchResponse{ErrorMessage: "URL is required"}, nil
 	}
 
-	// Validate URL protocol
-	if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
-		return &FetchResponse{ErrorMessage: "URL must start with http:// or https://"}, nil
+	// Validate URL against the policy; addresses are checked again when connecting
+	parsedURL, err := url.Parse(req.URL)
+	if err != nil {
+		return &FetchResponse{ErrorMessage: fmt.Sprintf("invalid URL: %v", err)}, nil
+	}
+	if err := policy.checkURL(parsedURL); err != nil {
+		return &FetchResponse{ErrorMessage: blockedMessage(err)}, nil
 	}
 
 	// Set default values and limits
@@ -65,35 +95,87 @@ func Fetch(ctx context.Context, req *FetchRequest) (*FetchResponse, error) {
 	if format == "" {
 		format = "text"
 	}
-	if format != "text" && format != "markdown" && format != "html" && format != "json" {
-		return &FetchResponse{ErrorMessage: "format must be one of: text, markdown, html, json"}, nil
+	if format != "text" && format != "markdown" && format != "html" && format != "json" && format != "article" {
+		return &FetchResponse{ErrorMessage: "format must be one of: text, markdown, html, json, article"}, nil
 	}
 
-	// Create HTTP client
-	client := createHTTPClient(req.Timeout)
+	if req.StartIndex < 0 || req.MaxLength < 0 {
+		return &FetchResponse{ErrorMessage: "start_index and max_length must not be negative"}, nil
+	}
 
-	// Create request
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
-	if err != nil {
-		return &FetchResponse{ErrorMessage: fmt.Sprintf("failed to create request: %v", err)}, nil
+	// Later parts of a page come from the page cache instead of a new request
+	key := pageKey(ctx, req, format)
+	if req.StartIndex > 0 {
+		if cached, ok := pages.get(key); ok {
+			cached.Cached = true
+			return pagedResponse(cached, req), nil
+		}
+		// Sending the request again would repeat its side effects
+		if method := requestMethod(req); method != http.MethodGet && method != http.MethodHead {
+			return &FetchResponse{ErrorMessage: fmt.Sprintf("the earlier parts of this %s response are no longer cached; send the request again with start_index 0", method)}, nil
+		}
 	}
 
-	// Set User-Agent
-	httpReq.Header.Set("User-Agent", "FKTEAMS/1.0")
+	resp := fetchContent(ctx, req, parsedURL, format)
+	if resp.ErrorMessage != "" {
+		return resp, nil
+	}
+
+	paged := pagedResponse(resp, req)
+	if paged.NextIndex > 0 && key != "" {
+		pages.put(key, resp)
+	}
+	return paged, nil
+}
+
+// pagedResponse The part of a processed response selected by start_index and max_length
+func pagedResponse(resp *FetchResponse, req *FetchRequest) *FetchResponse {
+	paged, msg := pageOf(resp, req.StartIndex, req.MaxLength)
+	if msg != "" {
+		return &FetchResponse{
+			StatusCode:   resp.StatusCode,
+			ContentType:  resp.ContentType,
+			TotalLength:  len([]rune(resp.Content)),
+			ErrorMessage: msg,
+		}
+	}
+	return paged
+}
+
+// fetchContent Send the request and process the whole response according to format
+func fetchContent(ctx context.Context, req *FetchRequest, parsedURL *url.URL, format string) *FetchResponse {
+	// Create HTTP client
+	client := CreateHTTPClient(req.Timeout)
+
+	// Create request with method, headers, body and authentication
+	httpReq, msg := buildRequest(ctx, req, parsedURL)
+	if msg != "" {
+		return &FetchResponse{ErrorMessage: msg}
+	}
 
 	// Send request
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return &FetchResponse{ErrorMessage: fmt.Sprintf("failed to fetch URL: %v", err)}, nil
+		var blocked *blockedError
+		if errors.As(err, &blocked) {
+			return &FetchResponse{ErrorMessage: blockedMessage(blocked)}
+		}
+		return &FetchResponse{ErrorMessage: fmt.Sprintf("failed to fetch URL: %v", err)}
 	}
 	defer resp.Body.Close()
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
+	headers := responseHeaders(resp.Header)
+
+	// Check status code; API error bodies usually explain the failure
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		errorBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
 		return &FetchResponse{
+			Content:      strings.ToValidUTF8(string(errorBody), ""),
 			StatusCode:   resp.StatusCode,
+			ContentType:  resp.Header.Get("Content-Type"),
+			Headers:      headers,
 			ErrorMessage: fmt.Sprintf("request failed with status code: %d", resp.StatusCode),
-		}, nil
+		}
 	}
 
 	// Read response body (limit size)
@@ -101,41 +183,104 @@ func Fetch(ctx context.Context, req *FetchRequest) (*FetchResponse, error) {
 	if err != nil {
 		return &FetchResponse{
 			StatusCode:   resp.StatusCode,
+			Headers:      headers,
 			ErrorMessage: fmt.Sprintf("failed to read response body: %v", err),
-		}, nil
+		}
 	}
 
-	content := string(body)
 	contentType := resp.Header.Get("Content-Type")
+	isTruncated := int64(len(body)) >= MaxResponseSize
+
+	// Documents and other binary content are not text
+	switch kind, ext := classifyBody(body, contentType, resp.Request.URL); kind {
+	case documentBody:
+		return documentResponse(resp, headers, body, ext, format)
+	case binaryBody:
+		info, image, description := binaryResponse(body, contentType, resp.ContentLength, isTruncated)
+		return &FetchResponse{
+			Content:     description,
+			StatusCode:  resp.StatusCode,
+			ContentType: contentType,
+			IsTruncated: isTruncated,
+			Headers:     headers,
+			Binary:      info,
+			image:       image,
+		}
+	}
 
-	// Validate UTF-8 encoding
-	if !utf8.ValidString(content) {
+	// Transcode to UTF-8 from the detected encoding
+	content, encoding, err := decodeBody(body, contentType)
+	if err != nil {
 		return &FetchResponse{
 			StatusCode:   resp.StatusCode,
 			ContentType:  contentType,
-			ErrorMessage: "response content is not valid UTF-8",
-		}, nil
+			Headers:      headers,
+			ErrorMessage: err.Error(),
+		}
 	}
 
-	// Process content according to format
-	processedContent, err := processContent(content, contentType, format)
+	// Process content according to format; articles also carry metadata
+	var processedContent string
+	var article *Article
+	if format == "article" && strings.Contains(contentType, "text/html") {
+		processedContent, article, err = extractArticle(content, resp.Request.URL)
+	} else {
+		processedContent, err = processContent(content, contentType, format)
+	}
 	if err != nil {
 		return &FetchResponse{
 			StatusCode:   resp.StatusCode,
 			ContentType:  contentType,
+			Headers:      headers,
 			ErrorMessage: fmt.Sprintf("failed to process content: %v", err),
-		}, nil
+		}
 	}
 
-	// Check if truncated
-	isTruncated := int64(len(body)) >= MaxResponseSize
-
 	return &FetchResponse{
 		Content:     processedContent,
 		StatusCode:  resp.StatusCode,
 		ContentType: contentType,
 		IsTruncated: isTruncated,
-	}, nil
+		Encoding:    encoding,
+		Article:     article,
+		Headers:     headers,
+	}
+}
+
+// documentResponse Read a document as text; documents are parsed whole, so up to MaxDocumentSize is read
+func documentResponse(resp *http.Response, headers map[string]string, body []byte, ext, format string) *FetchResponse {
+	response := &FetchResponse{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Headers:     headers,
+	}
+
+	if len(body) >= MaxResponseSize {
+		rest, err := io.ReadAll(io.LimitReader(resp.Body, MaxDocumentSize-int64(len(body))+1))
+		if err != nil {
+			response.ErrorMessage = fmt.Sprintf("failed to read response body: %v", err)
+			return response
+		}
+		body = append(body, rest...)
+		if len(body) > MaxDocumentSize {
+			response.ErrorMessage = fmt.Sprintf("document is larger than the %d MB limit", MaxDocumentSize/1024/1024)
+			return response
+		}
+	}
+
+	content, info, err := readDocument(body, ext, format)
+	if err != nil {
+		response.ErrorMessage = err.Error()
+		return response
+	}
+	response.Content = content
+	response.Document = info
+	return response
+}
+
+// blockedMessage Explain a URL refused by the policy
+func blockedMessage(err error) string {
+	return fmt.Sprintf("URL is not allowed: %v", err)
 }
 
 // processContent Process content according to format
@@ -163,7 +308,8 @@ func processContent(content, contentType, format string) (string, error) {
 		}
 		return content, nil
 
-	case "json":
+	case "json", "article":
+		// Non-HTML content has no article to extract
 		return content, nil
 
 	default:
@@ -227,8 +373,9 @@ func extractHTMLBody(html string) (string, error) {
 	return "<html>\n<body>\n" + body + "\n</body>\n</html>", nil
 }
 
-// createHTTPClient Create HTTP client
-func createHTTPClient(timeoutSec int) *http.Client {
+// CreateHTTPClient Create HTTP client (uses FEIKONG_PROXY_URL, falling back to the system proxy settings)
+// that enforces the URL policy on every connection and redirect
+func CreateHTTPClient(timeoutSec int) *http.Client {
 	proxyStr := os.Getenv(constants.MCP_PROXY_URL)
 	var proxyFunc func(*http.Request) (*url.URL, error)
 
@@ -241,8 +388,14 @@ func createHTTPClient(timeoutSec int) *http.Client {
 		proxyFunc = http.ProxyFromEnvironment
 	}
 
+	// Without a proxy function there is nothing to wrap
+	if proxyFunc == nil {
+		proxyFunc = func(*http.Request) (*url.URL, error) { return nil, nil }
+	}
+
 	transport := &http.Transport{
-		Proxy:                 proxyFunc,
+		Proxy:                 policy.proxy(proxyFunc),
+		DialContext:           policy.dialContext(),
 		MaxIdleConns:          100,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
@@ -250,7 +403,8 @@ func createHTTPClient(timeoutSec int) *http.Client {
 	}
 
 	return &http.Client{
-		Transport: transport,
-		Timeout:   time.Duration(timeoutSec) * time.Second,
+		Transport:     transport,
+		CheckRedirect: policy.checkRedirect,
+		Timeout:       time.Duration(timeoutSec) * time.Second,
 	}
 }
@@ -0,0 +1,152 @@
+package fetch
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fkmcps/structs"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// PageCacheTTL How long processed content stays cached for reading further parts
+	PageCacheTTL = 10 * time.Minute
+	// PageCacheSize Total processed content cached across sessions (64MB)
+	PageCacheSize = 64 * 1024 * 1024
+)
+
+// pageCache LRU of processed responses that were returned in parts, keyed by session and
+// request, so reading the next part does not fetch the URL again
+type pageCache struct {
+	ttl     time.Duration
+	maxSize int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	size    int
+	now     func() time.Time
+}
+
+// cachedPage A processed response with its expiry time
+type cachedPage struct {
+	key      string
+	response FetchResponse
+	expires  time.Time
+}
+
+// pages Page cache shared by all fetch calls
+var pages = newPageCache(PageCacheTTL, PageCacheSize)
+
+func newPageCache(ttl time.Duration, maxSize int) *pageCache {
+	return &pageCache{
+		ttl:     ttl,
+		maxSize: maxSize,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// get Copy of the cached response for key, if present and not expired
+func (c *pageCache) get(key string) (*FetchResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	page := elem.Value.(*cachedPage)
+	if c.now().After(page.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	resp := page.response
+	return &resp, true
+}
+
+// put Cache a processed response, evicting expired and then least recently used entries
+func (c *pageCache) put(key string, resp *FetchResponse) {
+	if len(resp.Content) > c.maxSize {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	c.entries[key] = c.order.PushFront(&cachedPage{key: key, response: *resp, expires: c.now().Add(c.ttl)})
+	c.size += len(resp.Content)
+
+	now := c.now()
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if now.After(elem.Value.(*cachedPage).expires) {
+			c.remove(elem)
+		}
+		elem = prev
+	}
+	for c.size > c.maxSize {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove Drop an entry; the caller holds mu
+func (c *pageCache) remove(elem *list.Element) {
+	page := c.order.Remove(elem).(*cachedPage)
+	delete(c.entries, page.key)
+	c.size -= len(page.response.Content)
+}
+
+// pageKey Cache key of a request within the calling session, ignoring the part being read
+func pageKey(ctx context.Context, req *FetchRequest, format string) string {
+	keyed := *req
+	keyed.Format = format
+	keyed.StartIndex, keyed.MaxLength, keyed.Timeout = 0, 0, 0
+	encoded, err := json.Marshal(keyed)
+	if err != nil {
+		// Unencodable requests are never paged from the cache
+		return ""
+	}
+	sum := sha256.Sum256(append([]byte(structs.SessionID(ctx)+"\n"), encoded...))
+	return hex.EncodeToString(sum[:])
+}
+
+// pageOf Cut the part of resp's content starting at start with at most maxLength characters,
+// setting TotalLength and NextIndex; maxLength 0 returns the rest of the content
+func pageOf(resp *FetchResponse, start, maxLength int) (*FetchResponse, string) {
+	runes := []rune(resp.Content)
+	total := len(runes)
+	if start > 0 && start >= total {
+		return nil, fmt.Sprintf("start_index %d is beyond the end of the content (total_length %d)", start, total)
+	}
+
+	end := total
+	if maxLength > 0 && start+maxLength < total {
+		end = start + maxLength
+		// Prefer ending at a line break in the second half of the part
+		for i := end - 1; i > start+maxLength/2; i-- {
+			if runes[i] == '\n' {
+				end = i + 1
+				break
+			}
+		}
+	}
+
+	page := *resp
+	page.Content = string(runes[start:end])
+	page.TotalLength = total
+	page.NextIndex = 0
+	if end < total {
+		page.NextIndex = end
+	}
+	return &page, ""
+}
@@ -0,0 +1,234 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxRedirects Maximum number of redirects followed per request
+const maxRedirects = 10
+
+// blockedPrefixes Special-purpose ranges not covered by the netip classification methods
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT, includes cloud metadata such as 100.100.100.200
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments, includes 192.0.0.192 metadata
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// nat64Prefix Well-known NAT64 prefix, which embeds an IPv4 address in its last 32 bits
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// blockedError A URL or address refused by the URL policy
+type blockedError struct {
+	reason string
+}
+
+func (e *blockedError) Error() string {
+	return e.reason
+}
+
+// urlPolicy Decides which URLs and addresses fetch may reach
+type urlPolicy struct {
+	allowPrivate bool
+	allowHosts   []string
+	denyHosts    []string
+	ports        []int
+
+	// proxies Proxy addresses the dialer may reach; their targets are checked in proxy instead
+	proxies sync.Map
+}
+
+func newURLPolicy(config *Config) *urlPolicy {
+	p := &urlPolicy{
+		allowPrivate: config.AllowPrivate,
+		ports:        config.AllowedPorts,
+	}
+	if len(p.ports) == 0 {
+		p.ports = DefaultAllowedPorts
+	}
+	for _, host := range config.AllowHosts {
+		if host = normalizeHostPattern(host); host != "" {
+			p.allowHosts = append(p.allowHosts, host)
+		}
+	}
+	for _, host := range config.DenyHosts {
+		if host = normalizeHostPattern(host); host != "" {
+			p.denyHosts = append(p.denyHosts, host)
+		}
+	}
+	return p
+}
+
+// normalizeHostPattern Lower-case a host list entry and drop a leading "*." or "."
+func normalizeHostPattern(pattern string) string {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	pattern = strings.TrimPrefix(pattern, "*.")
+	pattern = strings.TrimPrefix(pattern, ".")
+	return strings.TrimSuffix(pattern, ".")
+}
+
+// CheckURL Apply the configured URL policy to a URL that is about to be requested outside Fetch,
+// such as a document download; addresses are still checked again when connecting
+func CheckURL(u *url.URL) error {
+	return policy.checkURL(u)
+}
+
+// checkURL Validate the scheme, host lists, port and IP address literal of a URL
+func (p *urlPolicy) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return &blockedError{reason: "URL must start with http:// or https://"}
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return &blockedError{reason: "URL has no host"}
+	}
+
+	if slices.ContainsFunc(p.denyHosts, func(pattern string) bool { return hostMatches(host, pattern) }) {
+		return &blockedError{reason: fmt.Sprintf("host %s is blocked by the server's deny list", host)}
+	}
+	if len(p.allowHosts) > 0 && !slices.ContainsFunc(p.allowHosts, func(pattern string) bool { return hostMatches(host, pattern) }) {
+		return &blockedError{reason: fmt.Sprintf("host %s is not in the server's allow list", host)}
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+	if n, err := strconv.Atoi(port); err != nil || !slices.Contains(p.ports, n) {
+		return &blockedError{reason: fmt.Sprintf("port %s is not allowed (allowed ports: %s)", port, joinPorts(p.ports))}
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return p.checkAddr(addr)
+	}
+	return nil
+}
+
+// checkAddr Refuse private, loopback, link-local, metadata and other special-purpose addresses
+func (p *urlPolicy) checkAddr(addr netip.Addr) error {
+	if p.allowPrivate {
+		return nil
+	}
+	if isBlockedAddr(addr) {
+		return &blockedError{reason: fmt.Sprintf("address %s is private or reserved", addr)}
+	}
+	return nil
+}
+
+// isBlockedAddr Whether an address is in a range fetch must not reach
+func isBlockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if nat64Prefix.Contains(addr) {
+		raw := addr.As16()
+		addr = netip.AddrFrom4([4]byte(raw[12:]))
+	}
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+	return slices.ContainsFunc(blockedPrefixes, func(prefix netip.Prefix) bool { return prefix.Contains(addr) })
+}
+
+// hostMatches Whether host is pattern, a subdomain of it, or an IP address inside a CIDR pattern
+func hostMatches(host, pattern string) bool {
+	if host == pattern || strings.HasSuffix(host, "."+pattern) {
+		return true
+	}
+	if prefix, err := netip.ParsePrefix(pattern); err == nil {
+		if addr, err := netip.ParseAddr(host); err == nil {
+			return prefix.Contains(addr.Unmap())
+		}
+	}
+	return false
+}
+
+// joinPorts Render a port list for error messages
+func joinPorts(ports []int) string {
+	parts := make([]string, len(ports))
+	for i, port := range ports {
+		parts[i] = strconv.Itoa(port)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// control Dialer hook that checks the resolved address of every connection, so DNS
+// answers cannot point an allowed host name at a private address
+func (p *urlPolicy) control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return &blockedError{reason: fmt.Sprintf("invalid address %s", address)}
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return &blockedError{reason: fmt.Sprintf("invalid address %s", address)}
+	}
+	return p.checkAddr(addr)
+}
+
+// proxy Wrap a proxy function; a proxy resolves the target itself, so the target's
+// addresses are resolved and checked here and the proxy address is trusted by the dialer
+func (p *urlPolicy) proxy(next func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := next(req)
+		if err != nil || proxyURL == nil || p.allowPrivate {
+			return proxyURL, err
+		}
+
+		addrs, err := net.DefaultResolver.LookupNetIP(req.Context(), "ip", req.URL.Hostname())
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", req.URL.Hostname(), err)
+		}
+		for _, addr := range addrs {
+			if err := p.checkAddr(addr); err != nil {
+				return nil, err
+			}
+		}
+
+		port := proxyURL.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443", "socks5": "1080", "socks5h": "1080"}[proxyURL.Scheme]
+		}
+		p.proxies.Store(net.JoinHostPort(proxyURL.Hostname(), port), true)
+		return proxyURL, nil
+	}
+}
+
+// dialContext Dial proxies directly and everything else through the address check
+func (p *urlPolicy) dialContext() func(ctx context.Context, network, address string) (net.Conn, error) {
+	direct := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	guarded := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: p.control}
+
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		if _, ok := p.proxies.Load(address); ok {
+			return direct.DialContext(ctx, network, address)
+		}
+		return guarded.DialContext(ctx, network, address)
+	}
+}
+
+// checkRedirect Apply the URL policy to every redirect hop
+func (p *urlPolicy) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	return p.checkURL(req.URL)
+}
@@ -0,0 +1,169 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	// MaxRequestBodySize Maximum request body size (1MB)
+	MaxRequestBodySize = 1024 * 1024
+	// MaxRequestHeaders Maximum number of request headers a caller may set
+	MaxRequestHeaders = 50
+	// maxErrorBodySize Bytes of an error response body returned to the caller
+	maxErrorBodySize = 4096
+)
+
+// deniedHeaders Headers managed by the HTTP client or the proxy, which callers may not set
+var deniedHeaders = []string{
+	"Connection",
+	"Content-Length",
+	"Host",
+	"Keep-Alive",
+	"Proxy-Authorization",
+	"Proxy-Connection",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// BasicAuth HTTP basic authentication credentials
+type BasicAuth struct {
+	Username string `json:"username" jsonschema:"required,description:User name"`
+	Password string `json:"password,omitempty" jsonschema:"description:Password"`
+}
+
+// buildRequest Create the HTTP request described by req, or explain why it is not allowed
+func buildRequest(ctx context.Context, req *FetchRequest, target *url.URL) (*http.Request, string) {
+	method := requestMethod(req)
+	if !slices.Contains(allowedMethods, method) {
+		return nil, fmt.Sprintf("method %s is not allowed (allowed methods: %s)", method, strings.Join(allowedMethods, ", "))
+	}
+
+	if len(req.QueryParams) > 0 {
+		query := target.Query()
+		for key, value := range req.QueryParams {
+			query.Set(key, value)
+		}
+		target.RawQuery = query.Encode()
+	}
+
+	body, contentType, msg := requestBody(req, method)
+	if msg != "" {
+		return nil, msg
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, target.String(), bodyReader)
+	if err != nil {
+		return nil, fmt.Sprintf("failed to create request: %v", err)
+	}
+
+	httpReq.Header.Set("User-Agent", "FKTEAMS/1.0")
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+
+	if len(req.Headers) > MaxRequestHeaders {
+		return nil, fmt.Sprintf("too many headers (maximum %d)", MaxRequestHeaders)
+	}
+	for name, value := range req.Headers {
+		canonical := textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+		if msg := checkHeader(canonical, value); msg != "" {
+			return nil, msg
+		}
+		httpReq.Header.Set(canonical, value)
+	}
+
+	switch {
+	case req.BasicAuth != nil && req.BearerToken != "":
+		return nil, "use either basic_auth or bearer_token, not both"
+	case req.BasicAuth != nil:
+		httpReq.SetBasicAuth(req.BasicAuth.Username, req.BasicAuth.Password)
+	case req.BearerToken != "":
+		if strings.ContainsAny(req.BearerToken, "\r\n") {
+			return nil, "bearer_token must not contain line breaks"
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+req.BearerToken)
+	}
+
+	return httpReq, ""
+}
+
+// requestMethod Upper-case HTTP method of req, GET when unset
+func requestMethod(req *FetchRequest) string {
+	if method := strings.ToUpper(strings.TrimSpace(req.Method)); method != "" {
+		return method
+	}
+	return http.MethodGet
+}
+
+// requestBody Encode body or json_body, returning the bytes and their default content type
+func requestBody(req *FetchRequest, method string) ([]byte, string, string) {
+	if req.Body == "" && req.JSONBody == nil {
+		return nil, "", ""
+	}
+	if req.Body != "" && req.JSONBody != nil {
+		return nil, "", "use either body or json_body, not both"
+	}
+	if method == http.MethodGet || method == http.MethodHead {
+		return nil, "", fmt.Sprintf("a request body cannot be sent with %s", method)
+	}
+
+	body := []byte(req.Body)
+	contentType := ""
+	if req.JSONBody != nil {
+		encoded, err := json.Marshal(req.JSONBody)
+		if err != nil {
+			return nil, "", fmt.Sprintf("json_body cannot be encoded: %v", err)
+		}
+		body = encoded
+		contentType = "application/json"
+	}
+
+	if len(body) > MaxRequestBodySize {
+		return nil, "", fmt.Sprintf("request body is too large (maximum %d bytes)", MaxRequestBodySize)
+	}
+	return body, contentType, ""
+}
+
+// checkHeader Explain why a caller may not set a header, or return an empty string
+func checkHeader(name, value string) string {
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool { return r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) }) {
+		return fmt.Sprintf("invalid header name %q", name)
+	}
+	if strings.ContainsAny(value, "\r\n") {
+		return fmt.Sprintf("header %s must not contain line breaks", name)
+	}
+	if slices.Contains(deniedHeaders, name) || strings.HasPrefix(name, "Proxy-") {
+		return fmt.Sprintf("header %s cannot be set", name)
+	}
+	if len(allowedHeaders) > 0 && !slices.Contains(allowedHeaders, name) {
+		return fmt.Sprintf("header %s is not allowed (allowed headers: %s)", name, strings.Join(allowedHeaders, ", "))
+	}
+	return ""
+}
+
+// responseHeaders Flatten response headers, joining repeated values with ", "
+func responseHeaders(header http.Header) map[string]string {
+	if len(header) == 0 {
+		return nil
+	}
+	flat := make(map[string]string, len(header))
+	for name, values := range header {
+		flat[name] = strings.Join(values, ", ")
+	}
+	return flat
+}
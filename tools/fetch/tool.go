@@ -11,7 +11,7 @@ const toolDescription = `Fetch web resource content from URL and return content
 ## When to Use
 Use this tool when you need to:
 - Get raw content from a web page
-- Access API endpoints to get JSON data
+- Access API endpoints to get JSON data, including POST requests with headers, bodies and authentication
 - Download HTML/text/Markdown content
 - Quickly obtain web resources without complex processing
 
@@ -20,18 +20,26 @@ Don't use this tool when you need to:
 - Analyze or summarize web page content (should fetch first then analyze)
 
 ## Features
-- Supports four output formats: text (plain text), markdown (Markdown format), html (HTML format), json (JSON format)
+- Supports five output formats: text (plain text), markdown (Markdown format), html (HTML format), json (JSON format), article (main article as Markdown)
 - Automatically handles HTTP redirects
+- Detects the page encoding (Content-Type charset, <meta> declaration or byte order mark) and converts it to UTF-8; the original encoding is returned in encoding
 - Automatically extracts plain text from HTML (text format)
 - Automatically converts HTML to Markdown (markdown format)
 - Sets reasonable timeout to prevent long waits
-- Limits response size (maximum 5MB) to prevent memory overflow
+- Limits response size (maximum 5MB, 50MB for documents) to prevent memory overflow
+- Reads PDF, Word, Excel, PowerPoint, OpenDocument and EPUB links as text with page headings; document.pages gives the start_index of each page, numbered from 0 like the document tools
+- Returns PNG, JPEG, GIF and WebP images (up to 5MB) as image content, and the type, size and SHA-256 of other binary files in binary
+- Refuses private, loopback and cloud metadata addresses, and hosts or ports the server does not allow
 
 ## Usage Tips
 - text format: Suitable for getting plain text content or extracting text from HTML
 - markdown format: Suitable for content that needs formatted rendering
 - html format: Suitable for scenarios requiring raw HTML structure
 - json format: Suitable for JSON data returned by API endpoints
+- article format: Best for reading news, blog and documentation pages; drops navigation, banners and footers and returns title, byline, publish date and lead image in article (fallback is set when the whole body had to be returned)
+- For APIs, set method, headers, query_params, json_body (or body) and basic_auth or bearer_token; response headers are returned in headers
+- Error responses include the start of the response body, which usually explains the failure
+- For long pages, set max_length to read the processed content in parts; total_length gives the full length and next_index the start_index of the next part, which is served from a short-lived per-session cache without fetching the URL again
 - Set appropriate timeout based on website speed (default 30 seconds, maximum 120 seconds)`
 
 func GetTools(s *mcp.Server) {
@@ -0,0 +1,168 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// maxRelatedTopics bounds the related topics returned with an instant answer
+const maxRelatedTopics = 10
+
+// answerTypes names the Type codes of the Instant Answer API
+var answerTypes = map[string]string{
+	"A": "article",
+	"D": "disambiguation",
+	"C": "category",
+	"N": "name",
+	"E": "exclusive",
+}
+
+// ddgInstantAnswer is the response of the Instant Answer API. Answer and Infobox are an
+// empty string when absent, and infobox values may be objects, so they are decoded lazily.
+type ddgInstantAnswer struct {
+	Heading          string          `json:"Heading"`
+	Type             string          `json:"Type"`
+	AbstractText     string          `json:"AbstractText"`
+	AbstractSource   string          `json:"AbstractSource"`
+	AbstractURL      string          `json:"AbstractURL"`
+	Image            string          `json:"Image"`
+	Answer           json.RawMessage `json:"Answer"`
+	AnswerType       string          `json:"AnswerType"`
+	Definition       string          `json:"Definition"`
+	DefinitionSource string          `json:"DefinitionSource"`
+	DefinitionURL    string          `json:"DefinitionURL"`
+	Infobox          json.RawMessage `json:"Infobox"`
+	RelatedTopics    []ddgTopic      `json:"RelatedTopics"`
+}
+
+// ddgTopic is a related topic, or a named group of topics
+type ddgTopic struct {
+	Text     string     `json:"Text"`
+	FirstURL string     `json:"FirstURL"`
+	Name     string     `json:"Name"`
+	Topics   []ddgTopic `json:"Topics"`
+}
+
+// ddgInfobox is the infobox of an instant answer
+type ddgInfobox struct {
+	Content []struct {
+		Label string          `json:"label"`
+		Value json.RawMessage `json:"value"`
+	} `json:"content"`
+}
+
+func (c *client) InstantAnswer(ctx context.Context, input *InstantAnswerRequest) (*InstantAnswerResponse, error) {
+	if msg := validateQuery(strings.TrimSpace(input.Query)); msg != "" {
+		return &InstantAnswerResponse{
+			ErrorMessage: msg,
+		}, nil
+	}
+
+	params := url.Values{
+		"q":             {input.Query},
+		"format":        {"json"},
+		"no_html":       {"1"},
+		"no_redirect":   {"1"},
+		"skip_disambig": {"0"},
+	}
+	body, err := c.getMedia(ctx, instantAnswerURL+"?"+params.Encode())
+	if err != nil {
+		return &InstantAnswerResponse{
+			ErrorMessage: fmt.Sprintf("instant answer request failed: %v. Please try again or use search", err),
+		}, nil
+	}
+
+	var raw ddgInstantAnswer
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return &InstantAnswerResponse{
+			ErrorMessage: fmt.Sprintf("failed to parse instant answer: %v", err),
+		}, nil
+	}
+
+	answer := raw.toInstantAnswer()
+	if answer == nil {
+		return &InstantAnswerResponse{
+			Message: "No instant answer for this query. Use search for web results instead.",
+		}, nil
+	}
+
+	return &InstantAnswerResponse{
+		Message: "Found an instant answer.",
+		Answer:  answer,
+	}, nil
+}
+
+// InstantAnswer is served by DuckDuckGo, whatever the search engine
+func (r *engineRegistry) InstantAnswer(ctx context.Context, input *InstantAnswerRequest) (*InstantAnswerResponse, error) {
+	return r.duckduckgo.InstantAnswer(ctx, input)
+}
+
+// toInstantAnswer converts the API response, returning nil when it holds no answer
+func (d *ddgInstantAnswer) toInstantAnswer() *InstantAnswer {
+	answer := &InstantAnswer{
+		Heading:          strings.TrimSpace(d.Heading),
+		Type:             answerTypes[d.Type],
+		Abstract:         strings.TrimSpace(d.AbstractText),
+		Source:           d.AbstractSource,
+		SourceURL:        d.AbstractURL,
+		Answer:           rawText(d.Answer),
+		AnswerType:       d.AnswerType,
+		Definition:       strings.TrimSpace(d.Definition),
+		DefinitionSource: d.DefinitionSource,
+		DefinitionURL:    d.DefinitionURL,
+	}
+	if d.Image != "" {
+		// Images are given relative to duckduckgo.com
+		answer.Image = absoluteURL(d.Image)
+		if strings.HasPrefix(answer.Image, "/") {
+			answer.Image = searchBaseURL + answer.Image
+		}
+	}
+
+	var infobox ddgInfobox
+	if len(d.Infobox) > 0 && d.Infobox[0] == '{' && json.Unmarshal(d.Infobox, &infobox) == nil {
+		for _, entry := range infobox.Content {
+			if value := rawText(entry.Value); entry.Label != "" && value != "" {
+				answer.Infobox = append(answer.Infobox, &InfoboxEntry{Label: entry.Label, Value: value})
+			}
+		}
+	}
+
+	for _, topic := range d.RelatedTopics {
+		answer.RelatedTopics = appendTopic(answer.RelatedTopics, topic, "")
+		for _, sub := range topic.Topics {
+			answer.RelatedTopics = appendTopic(answer.RelatedTopics, sub, topic.Name)
+		}
+	}
+
+	if answer.Abstract == "" && answer.Answer == "" && answer.Definition == "" &&
+		len(answer.Infobox) == 0 && len(answer.RelatedTopics) == 0 {
+		return nil
+	}
+	return answer
+}
+
+// appendTopic adds a related topic with text, up to maxRelatedTopics
+func appendTopic(topics []*RelatedTopic, topic ddgTopic, category string) []*RelatedTopic {
+	text := strings.TrimSpace(topic.Text)
+	if text == "" || len(topics) >= maxRelatedTopics {
+		return topics
+	}
+	return append(topics, &RelatedTopic{Text: text, URL: topic.FirstURL, Category: category})
+}
+
+// rawText renders a JSON string or number as text; other values are left out
+func rawText(raw json.RawMessage) string {
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return strings.TrimSpace(text)
+	}
+	var number json.Number
+	if json.Unmarshal(raw, &number) == nil {
+		return number.String()
+	}
+	return ""
+}
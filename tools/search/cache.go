@@ -0,0 +1,269 @@
+package search
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default search cache settings
+const (
+	DefaultCacheTTL  = 15 * time.Minute
+	DefaultCacheSize = 500
+)
+
+// cachedSearch wraps a Search with an LRU of recent text search responses that expire
+// after a TTL, with optional gob persistence to disk; other searches pass through
+type cachedSearch struct {
+	Search
+
+	ttl        time.Duration
+	maxEntries int
+	dir        string
+
+	// Request defaults that distinguish otherwise identical cache keys
+	defaultEngine string
+	region        Region
+	maxResults    int
+	limit         int
+
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	hits    int64
+	misses  int64
+	now     func() time.Time
+}
+
+// cachedResponse A cached response with its expiry time
+type cachedResponse struct {
+	Key      string
+	Response *TextSearchResponse
+	Expires  time.Time
+}
+
+func newCachedSearch(next Search, ttl time.Duration, maxEntries int, dir string) *cachedSearch {
+	return &cachedSearch{
+		Search:     next,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		dir:        dir,
+		region:     RegionWT,
+		maxResults: 10,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+func (c *cachedSearch) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	key := c.key(input)
+
+	if resp, ok := c.get(key); ok {
+		c.logStats("HIT", input.Query)
+		return markCached(resp), nil
+	}
+
+	if entry, ok := c.loadPersisted(key); ok {
+		c.put(entry)
+		c.logStats("DISK HIT", input.Query)
+		return markCached(entry.Response), nil
+	}
+
+	resp, err := c.Search.TextSearch(ctx, input)
+	if err != nil || resp == nil || resp.ErrorMessage != "" {
+		// Failures are not cached so a retry reaches the engine again
+		return resp, err
+	}
+
+	entry := &cachedResponse{Key: key, Response: resp, Expires: c.now().Add(c.ttl)}
+	c.put(entry)
+	c.persist(entry)
+	c.logStats("MISS", input.Query)
+
+	return resp, nil
+}
+
+// key Cache key of a request: query with site filters, engine, region, time range,
+// safe search, max results, offset, and whether ads are kept, the query expanded and
+// the instant answer included
+func (c *cachedSearch) key(input *TextSearchRequest) string {
+	engine := strings.ToLower(strings.ReplaceAll(input.Engine, " ", ""))
+	if engine == "" {
+		engine = c.defaultEngine
+	}
+	count := input.resultCount(c.maxResults, c.limit)
+	return fmt.Sprintf("%s|%s|%s|%s|%s|%d|%d|%t|%t|%t",
+		input.searchQuery(),
+		engine,
+		input.searchRegion(c.region),
+		input.TimeRange,
+		input.SafeSearch,
+		count,
+		input.startOffset(count),
+		input.IncludeAds,
+		input.Expand,
+		input.IncludeAnswer)
+}
+
+// markCached Copy a cached response and flag it as served from the cache
+func markCached(resp *TextSearchResponse) *TextSearchResponse {
+	cached := *resp
+	cached.Cached = true
+	return &cached
+}
+
+// get Return an unexpired response and mark it as recently used
+func (c *cachedSearch) get(key string) (*TextSearchResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+
+	entry := element.Value.(*cachedResponse)
+	if !c.now().Before(entry.Expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		c.misses++
+		return nil, false
+	}
+
+	c.hits++
+	c.order.MoveToFront(element)
+	return entry.Response, true
+}
+
+// put Store a response, evicting least recently used entries beyond the size bound
+func (c *cachedSearch) put(entry *cachedResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[entry.Key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, entry.Key)
+	}
+
+	for c.order.Len() >= c.maxEntries && c.order.Len() > 0 {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedResponse).Key)
+	}
+
+	c.entries[entry.Key] = c.order.PushFront(entry)
+}
+
+// logStats Log a cache lookup together with the running statistics
+func (c *cachedSearch) logStats(event, query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	log.Printf("[SEARCH CACHE] %s | Query: %q | Hits: %d | Misses: %d | Entries: %d / %d",
+		event,
+		query,
+		c.hits,
+		c.misses,
+		c.order.Len(),
+		c.maxEntries)
+}
+
+// persist Write a response to the on-disk cache, if enabled
+func (c *cachedSearch) persist(entry *cachedResponse) {
+	if c.dir == "" {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o700); err != nil {
+		log.Printf("[SEARCH CACHE] failed to create cache directory: %v", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".entry-*")
+	if err != nil {
+		log.Printf("[SEARCH CACHE] failed to persist entry: %v", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	err = gob.NewEncoder(tmp).Encode(entry)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.persistedPath(entry.Key))
+	}
+	if err != nil {
+		log.Printf("[SEARCH CACHE] failed to persist entry: %v", err)
+		return
+	}
+
+	c.trimPersisted()
+}
+
+// loadPersisted Read an unexpired response from the on-disk cache, if enabled and present
+func (c *cachedSearch) loadPersisted(key string) (*cachedResponse, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+
+	path := c.persistedPath(key)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+
+	var entry cachedResponse
+	if err := gob.NewDecoder(f).Decode(&entry); err != nil || entry.Key != key {
+		return nil, false
+	}
+	if !c.now().Before(entry.Expires) {
+		os.Remove(path)
+		return nil, false
+	}
+	return &entry, true
+}
+
+// persistedPath On-disk cache file for a key
+func (c *cachedSearch) persistedPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".gob")
+}
+
+// trimPersisted Remove the oldest files so the disk cache keeps at most maxEntries entries
+func (c *cachedSearch) trimPersisted() {
+	matches, err := filepath.Glob(filepath.Join(c.dir, "*.gob"))
+	if err != nil || len(matches) <= c.maxEntries {
+		return
+	}
+
+	type file struct {
+		path    string
+		modTime time.Time
+	}
+	files := make([]file, 0, len(matches))
+	for _, path := range matches {
+		if info, err := os.Stat(path); err == nil {
+			files = append(files, file{path: path, modTime: info.ModTime()})
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+
+	for _, f := range files[:max(len(files)-c.maxEntries, 0)] {
+		os.Remove(f.path)
+	}
+}
@@ -11,6 +11,21 @@ import (
 )
 
 func NewDuckDuckGoSearch(ctx context.Context) (Search, error) {
+	httpClient, err := newProxyHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewSearch(ctx, &Config{
+		Region:     RegionWT,
+		MaxResults: 10,
+		HTTPClient: httpClient,
+	})
+}
+
+// newProxyHTTPClient builds the HTTP client shared by all search engines,
+// honouring the custom proxy variable before the system proxy settings.
+func newProxyHTTPClient() (*http.Client, error) {
 	// 1. Get custom proxy environment variable
 	proxyStr := os.Getenv(constants.MCP_PROXY_URL)
 
@@ -36,14 +51,8 @@ func NewDuckDuckGoSearch(ctx context.Context) (Search, error) {
 		ExpectContinueTimeout: 1 * time.Second,
 	}
 
-	httpClient := &http.Client{
+	return &http.Client{
 		Transport: transport,
 		Timeout:   time.Second * 30,
-	}
-
-	return NewSearch(ctx, &Config{
-		Region:     RegionWT,
-		MaxResults: 10,
-		HTTPClient: httpClient,
-	})
+	}, nil
 }
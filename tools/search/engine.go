@@ -0,0 +1,404 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Engine names accepted by the engine argument
+const (
+	EngineDuckDuckGo = "duckduckgo"
+	EngineSearxNG    = "searxng"
+	EngineBing       = "bing"
+	EngineBrave      = "brave"
+	EngineGoogle     = "google"
+	// EngineAll runs every configured engine and merges the results
+	EngineAll = "all"
+)
+
+// Options configures the engines registered by GetTools.
+type Options struct {
+	// DefaultEngine is used when a request does not name an engine.
+	// It may be an engine name, a comma-separated list or EngineAll.
+	// Default: EngineDuckDuckGo
+	DefaultEngine string
+
+	// SearxNGURL is the base URL of a SearxNG instance with the JSON format enabled.
+	// Optional. Empty disables the searxng engine.
+	SearxNGURL string
+
+	// BingAPIKey enables the bing engine (Bing Web Search API v7).
+	BingAPIKey string
+
+	// BraveAPIKey enables the brave engine (Brave Search API).
+	BraveAPIKey string
+
+	// GoogleAPIKey and GoogleCX together enable the google engine (Programmable Search Engine).
+	GoogleAPIKey string
+	GoogleCX     string
+
+	// JSONEngines are additional engines backed by arbitrary JSON APIs.
+	JSONEngines []JSONEngineConfig
+
+	// MaxResults bounds the max_results a request may ask for.
+	// Default: DefaultMaxResultsLimit
+	MaxResults int
+
+	// CacheTTL is how long search responses are reused.
+	// Zero disables the cache.
+	CacheTTL time.Duration
+
+	// CacheSize is the maximum number of cached responses.
+	// Zero disables the cache.
+	CacheSize int
+
+	// CacheDir persists cached responses across restarts.
+	// Optional. Empty keeps the cache in memory only.
+	CacheDir string
+}
+
+// options Settings applied by Configure
+var options = &Options{
+	MaxResults: DefaultMaxResultsLimit,
+	CacheTTL:   DefaultCacheTTL,
+	CacheSize:  DefaultCacheSize,
+}
+
+// Configure Apply search tool settings; call before GetTools
+func Configure(opts *Options) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	options = opts
+}
+
+// LoadJSONEngines reads generic JSON engine definitions from a JSON array file.
+func LoadJSONEngines(path string) ([]JSONEngineConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read search engine definitions: %w", err)
+	}
+
+	var engines []JSONEngineConfig
+	if err := json.Unmarshal(data, &engines); err != nil {
+		return nil, fmt.Errorf("invalid search engine definitions in %s: %w", path, err)
+	}
+
+	for i, engine := range engines {
+		if engine.Name == "" || engine.URL == "" {
+			return nil, fmt.Errorf("search engine definition %d in %s needs a name and a url", i, path)
+		}
+		if !strings.Contains(engine.URL, "{query}") {
+			return nil, fmt.Errorf("search engine %s: url must contain the {query} placeholder", engine.Name)
+		}
+	}
+
+	return engines, nil
+}
+
+// textSearcher is a web search backend; engines other than DuckDuckGo only offer text search
+type textSearcher interface {
+	TextSearch(ctx context.Context, req *TextSearchRequest) (*TextSearchResponse, error)
+}
+
+// engineRegistry holds the named search engines and dispatches requests
+// to one of them, or to several merged into a metasearch.
+type engineRegistry struct {
+	engines       map[string]textSearcher
+	duckduckgo    Search
+	names         []string
+	defaultEngine string
+	maxResults    int
+	limit         int
+}
+
+// newEngineRegistry builds every engine the options enable; DuckDuckGo is always available.
+func newEngineRegistry(ctx context.Context, opts *Options) (*engineRegistry, error) {
+	httpCli, err := newProxyHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+
+	limit := opts.MaxResults
+	if limit <= 0 {
+		limit = DefaultMaxResultsLimit
+	}
+
+	duckduckgo, err := NewSearch(ctx, &Config{
+		Region:          RegionWT,
+		MaxResults:      10,
+		MaxResultsLimit: limit,
+		HTTPClient:      httpCli,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &engineRegistry{
+		engines:       map[string]textSearcher{},
+		duckduckgo:    duckduckgo,
+		defaultEngine: opts.DefaultEngine,
+		maxResults:    10,
+		limit:         limit,
+	}
+	registry.add(EngineDuckDuckGo, duckduckgo)
+
+	if opts.SearxNGURL != "" {
+		registry.add(EngineSearxNG, &searxngEngine{apiEngine: newAPIEngine(httpCli, registry.maxResults, registry.limit), baseURL: strings.TrimRight(opts.SearxNGURL, "/")})
+	}
+	if opts.BingAPIKey != "" {
+		registry.add(EngineBing, &bingEngine{apiEngine: newAPIEngine(httpCli, registry.maxResults, registry.limit), apiKey: opts.BingAPIKey})
+	}
+	if opts.BraveAPIKey != "" {
+		registry.add(EngineBrave, &braveEngine{apiEngine: newAPIEngine(httpCli, registry.maxResults, registry.limit), apiKey: opts.BraveAPIKey})
+	}
+	if opts.GoogleAPIKey != "" && opts.GoogleCX != "" {
+		registry.add(EngineGoogle, &googleEngine{apiEngine: newAPIEngine(httpCli, registry.maxResults, registry.limit), apiKey: opts.GoogleAPIKey, cx: opts.GoogleCX})
+	}
+	for _, config := range opts.JSONEngines {
+		name := strings.ToLower(config.Name)
+		if _, exists := registry.engines[name]; exists || name == EngineAll {
+			return nil, fmt.Errorf("search engine name %q is already in use", config.Name)
+		}
+		registry.add(name, &jsonEngine{apiEngine: newAPIEngine(httpCli, registry.maxResults, registry.limit), config: config})
+	}
+
+	if registry.defaultEngine == "" {
+		registry.defaultEngine = EngineDuckDuckGo
+	}
+	if _, err := registry.resolve(registry.defaultEngine); err != nil {
+		return nil, fmt.Errorf("invalid default search engine: %w", err)
+	}
+
+	return registry, nil
+}
+
+// newCachedRegistry wraps the registry in the response cache when the options enable it
+func newCachedRegistry(registry *engineRegistry, opts *Options) Search {
+	if opts.CacheTTL <= 0 || opts.CacheSize <= 0 {
+		return registry
+	}
+
+	cache := newCachedSearch(registry, opts.CacheTTL, opts.CacheSize, opts.CacheDir)
+	cache.defaultEngine = registry.defaultEngine
+	cache.maxResults = registry.maxResults
+	cache.limit = registry.limit
+	return cache
+}
+
+func (r *engineRegistry) add(name string, engine textSearcher) {
+	r.engines[name] = engine
+	r.names = append(r.names, name)
+}
+
+// resolve turns an engine argument into the engine names to query
+func (r *engineRegistry) resolve(engine string) ([]string, error) {
+	engine = strings.ToLower(strings.TrimSpace(engine))
+	if engine == "" {
+		engine = r.defaultEngine
+	}
+	if engine == EngineAll {
+		return r.names, nil
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(engine, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := r.engines[name]; !ok {
+			available := append([]string{}, r.names...)
+			sort.Strings(available)
+			return nil, fmt.Errorf("unknown search engine %q, available engines: %s (or %s)", name, strings.Join(available, ", "), EngineAll)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no search engine selected")
+	}
+	return names, nil
+}
+
+func (r *engineRegistry) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	names, err := r.resolve(input.Engine)
+	if err != nil {
+		return &TextSearchResponse{
+			ErrorMessage: err.Error(),
+		}, nil
+	}
+
+	// The instant answer is looked up while the search runs
+	var answer chan *InstantAnswer
+	if input.IncludeAnswer {
+		answer = make(chan *InstantAnswer, 1)
+		go func() {
+			resp, err := r.duckduckgo.InstantAnswer(ctx, &InstantAnswerRequest{Query: input.Query})
+			if err != nil {
+				answer <- nil
+				return
+			}
+			answer <- resp.Answer
+		}()
+	}
+
+	var resp *TextSearchResponse
+	if input.Expand {
+		if msg := validateQuery(input.Query); msg != "" {
+			return &TextSearchResponse{
+				ErrorMessage: msg,
+			}, nil
+		}
+		resp, err = r.expandedSearch(ctx, input, names)
+	} else {
+		resp, err = r.search(ctx, input, names)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	count := input.resultCount(r.maxResults, r.limit)
+	if resp.ErrorMessage == "" {
+		rankResults(resp.Results, input.startOffset(count))
+	}
+
+	// A full page suggests more results follow
+	if resp.ErrorMessage == "" && len(resp.Results) >= count {
+		resp.NextOffset = input.startOffset(count) + len(resp.Results)
+	}
+
+	if answer != nil {
+		resp.Answer = <-answer
+	}
+
+	return resp, nil
+}
+
+// search runs the query on one engine, or on several merged into a metasearch
+func (r *engineRegistry) search(ctx context.Context, input *TextSearchRequest, names []string) (*TextSearchResponse, error) {
+	if len(names) == 1 {
+		return r.engines[names[0]].TextSearch(ctx, input)
+	}
+
+	if msg := validateQuery(input.Query); msg != "" {
+		return &TextSearchResponse{
+			ErrorMessage: msg,
+		}, nil
+	}
+	return r.metaSearch(ctx, input, names), nil
+}
+
+// NewsSearch is served by DuckDuckGo, the only engine with a news endpoint
+func (r *engineRegistry) NewsSearch(ctx context.Context, input *NewsSearchRequest) (*NewsSearchResponse, error) {
+	return r.duckduckgo.NewsSearch(ctx, input)
+}
+
+// ImageSearch is served by DuckDuckGo, the only engine with an image endpoint
+func (r *engineRegistry) ImageSearch(ctx context.Context, input *ImageSearchRequest) (*ImageSearchResponse, error) {
+	return r.duckduckgo.ImageSearch(ctx, input)
+}
+
+// VideoSearch is served by DuckDuckGo, the only engine with a video endpoint
+func (r *engineRegistry) VideoSearch(ctx context.Context, input *VideoSearchRequest) (*VideoSearchResponse, error) {
+	return r.duckduckgo.VideoSearch(ctx, input)
+}
+
+// validateQuery returns an error message for queries no engine should receive
+func validateQuery(query string) string {
+	if query == "" {
+		return "search query is required, please provide a query string"
+	}
+
+	if len(query) > 500 {
+		return "search query is too long (max 500 characters), please shorten your query"
+	}
+
+	return ""
+}
+
+// searchQuery is the query with the site and exclude_site filters appended as operators
+func (t *TextSearchRequest) searchQuery() string {
+	query := strings.TrimSpace(t.Query)
+
+	var sites []string
+	for _, site := range t.Site {
+		if site = strings.TrimSpace(site); site != "" {
+			sites = append(sites, "site:"+site)
+		}
+	}
+	if len(sites) > 0 {
+		query += " " + strings.Join(sites, " OR ")
+	}
+
+	for _, site := range t.ExcludeSite {
+		if site = strings.TrimSpace(site); site != "" {
+			query += " -site:" + site
+		}
+	}
+
+	return query
+}
+
+// resultCount is the number of results to return: max_results when set, bounded by limit
+func (t *TextSearchRequest) resultCount(defaultMax, limit int) int {
+	return boundedCount(t.MaxResults, defaultMax, limit)
+}
+
+// boundedCount is requested when positive, else defaultMax, capped at limit
+func boundedCount(requested, defaultMax, limit int) int {
+	count := requested
+	if count <= 0 {
+		count = defaultMax
+	}
+	if limit > 0 && count > limit {
+		count = limit
+	}
+	return count
+}
+
+// startOffset is the number of results to skip, from offset or else from page
+func (t *TextSearchRequest) startOffset(count int) int {
+	if t.Offset > 0 {
+		return t.Offset
+	}
+	if t.Page > 1 {
+		return (t.Page - 1) * count
+	}
+	return 0
+}
+
+// searchRegion is the request region, or fallback when none is given
+func (t *TextSearchRequest) searchRegion(fallback Region) Region {
+	if t.Region != "" {
+		return Region(strings.ToLower(strings.TrimSpace(string(t.Region))))
+	}
+	return fallback
+}
+
+// regionParts splits a region code such as us-en into country and language; both are empty for RegionWT
+func regionParts(region Region) (country, language string) {
+	if region == RegionWT || region == "" {
+		return "", ""
+	}
+	country, language, _ = strings.Cut(string(region), "-")
+	return country, language
+}
+
+// apiEngine holds what the keyed JSON API engines share
+type apiEngine struct {
+	httpCli    *http.Client
+	maxResults int
+	limit      int
+}
+
+func newAPIEngine(httpCli *http.Client, maxResults, limit int) apiEngine {
+	return apiEngine{httpCli: httpCli, maxResults: maxResults, limit: limit}
+}
@@ -0,0 +1,505 @@
+package search
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Endpoints of the keyed search APIs
+var (
+	bingSearchURL   = "https://api.bing.microsoft.com/v7.0/search"
+	braveSearchURL  = "https://api.search.brave.com/res/v1/web/search"
+	googleSearchURL = "https://www.googleapis.com/customsearch/v1"
+)
+
+// maxAPIPages bounds the requests one search makes to an API engine
+const maxAPIPages = 5
+
+// maxAPIResponseSize limits the size of an API response body
+const maxAPIResponseSize = 5 * 1024 * 1024
+
+// pageFetcher returns the results of one page (0-based) of an API search
+type pageFetcher func(ctx context.Context, page int) ([]*TextSearchResult, error)
+
+// count is the number of results a request asks this engine for
+func (e *apiEngine) count(input *TextSearchRequest) int {
+	return input.resultCount(e.maxResults, e.limit)
+}
+
+// collect validates the query and gathers pages until count results are found or a page comes back empty
+func (e *apiEngine) collect(ctx context.Context, input *TextSearchRequest, count, pages int, fetch pageFetcher) *TextSearchResponse {
+	if msg := validateQuery(input.Query); msg != "" {
+		return &TextSearchResponse{
+			ErrorMessage: msg,
+		}
+	}
+
+	results := make([]*TextSearchResult, 0, count)
+	seen := map[string]bool{}
+
+	for page := 0; page < pages && len(results) < count; page++ {
+		pageResults, err := fetch(ctx, page)
+		if err != nil {
+			if len(results) > 0 {
+				break
+			}
+			return &TextSearchResponse{
+				ErrorMessage: fmt.Sprintf("search request failed: %v. Please try again or rephrase your query", err),
+			}
+		}
+
+		added := 0
+		for _, result := range pageResults {
+			if result.URL == "" || seen[result.URL] {
+				continue
+			}
+			seen[result.URL] = true
+			result.Page = page + 1
+			results = append(results, result)
+			added++
+		}
+		if added == 0 {
+			break
+		}
+	}
+
+	if len(results) > count {
+		results = results[:count]
+	}
+
+	if len(results) == 0 {
+		return &TextSearchResponse{
+			Message: "No results found for your query. Try using different keywords or broader search terms.",
+		}
+	}
+
+	return &TextSearchResponse{
+		Message: fmt.Sprintf("Found %d results successfully.", len(results)),
+		Results: results,
+	}
+}
+
+// getJSON sends a GET request and decodes the JSON response into v
+func (e *apiEngine) getJSON(ctx context.Context, endpoint string, header http.Header, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create search request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := e.httpCli.Do(req)
+	if err != nil {
+		return fmt.Errorf("network error, please check your connection: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("rate limit exceeded (status 429), please wait a moment and try again")
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("access denied (status %d), check the API key configured for this engine", resp.StatusCode)
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("search service returned status %d, please try again later", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxAPIResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read search results: %w", err)
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse search results: %w", err)
+	}
+	return nil
+}
+
+// searxngEngine queries a SearxNG instance through its JSON output format
+type searxngEngine struct {
+	apiEngine
+	baseURL string
+}
+
+func (e *searxngEngine) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	timeRanges := map[TimeRange]string{
+		TimeRangeDay:   "day",
+		TimeRangeWeek:  "week",
+		TimeRangeMonth: "month",
+		TimeRangeYear:  "year",
+	}
+
+	safeSearch := map[SafeSearch]string{
+		SafeSearchOff:      "0",
+		SafeSearchModerate: "1",
+		SafeSearchStrict:   "2",
+	}
+
+	// SearxNG pages cannot be sized, so the offset is approximated with its usual 10 results per page
+	count := e.count(input)
+	firstPage := input.startOffset(count)/10 + 1
+	_, language := regionParts(input.searchRegion(RegionWT))
+
+	return e.collect(ctx, input, count, maxAPIPages, func(ctx context.Context, page int) ([]*TextSearchResult, error) {
+		params := url.Values{
+			"q":      {input.searchQuery()},
+			"format": {"json"},
+			"pageno": {strconv.Itoa(firstPage + page)},
+		}
+		if value, ok := timeRanges[input.TimeRange]; ok {
+			params.Set("time_range", value)
+		}
+		if value, ok := safeSearch[input.SafeSearch]; ok {
+			params.Set("safesearch", value)
+		}
+		if language != "" {
+			params.Set("language", language)
+		}
+
+		var body struct {
+			Results []struct {
+				Title         string `json:"title"`
+				URL           string `json:"url"`
+				Content       string `json:"content"`
+				PublishedDate string `json:"publishedDate"`
+			} `json:"results"`
+		}
+		if err := e.getJSON(ctx, e.baseURL+"/search?"+params.Encode(), nil, &body); err != nil {
+			return nil, err
+		}
+
+		results := make([]*TextSearchResult, 0, len(body.Results))
+		for _, r := range body.Results {
+			results = append(results, &TextSearchResult{Title: r.Title, URL: r.URL, Summary: r.Content, Date: parseResultDate(r.PublishedDate)})
+		}
+		return results, nil
+	}), nil
+}
+
+// bingEngine queries the Bing Web Search API v7
+type bingEngine struct {
+	apiEngine
+	apiKey string
+}
+
+func (e *bingEngine) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	count := e.count(input)
+	pageSize := min(count, 50)
+	offset := input.startOffset(count)
+	safeSearch := map[SafeSearch]string{
+		SafeSearchOff:      "Off",
+		SafeSearchModerate: "Moderate",
+		SafeSearchStrict:   "Strict",
+	}
+
+	return e.collect(ctx, input, count, maxAPIPages, func(ctx context.Context, page int) ([]*TextSearchResult, error) {
+		params := url.Values{
+			"q":      {input.searchQuery()},
+			"count":  {strconv.Itoa(pageSize)},
+			"offset": {strconv.Itoa(offset + page*pageSize)},
+		}
+		if value, ok := safeSearch[input.SafeSearch]; ok {
+			params.Set("safeSearch", value)
+		}
+		if country, language := regionParts(input.searchRegion(RegionWT)); country != "" {
+			params.Set("mkt", language+"-"+strings.ToUpper(country))
+		}
+		switch input.TimeRange {
+		case TimeRangeDay:
+			params.Set("freshness", "Day")
+		case TimeRangeWeek:
+			params.Set("freshness", "Week")
+		case TimeRangeMonth:
+			params.Set("freshness", "Month")
+		case TimeRangeYear:
+			// Bing has no yearly freshness value but accepts a date range
+			now := time.Now().UTC()
+			params.Set("freshness", now.AddDate(-1, 0, 0).Format(time.DateOnly)+".."+now.Format(time.DateOnly))
+		}
+
+		var body struct {
+			WebPages struct {
+				Value []struct {
+					Name       string `json:"name"`
+					URL        string `json:"url"`
+					DisplayURL string `json:"displayUrl"`
+					Snippet    string `json:"snippet"`
+				} `json:"value"`
+			} `json:"webPages"`
+		}
+		header := http.Header{"Ocp-Apim-Subscription-Key": {e.apiKey}}
+		if err := e.getJSON(ctx, bingSearchURL+"?"+params.Encode(), header, &body); err != nil {
+			return nil, err
+		}
+
+		results := make([]*TextSearchResult, 0, len(body.WebPages.Value))
+		for _, r := range body.WebPages.Value {
+			results = append(results, &TextSearchResult{Title: r.Name, URL: r.URL, Summary: r.Snippet, DisplayURL: r.DisplayURL})
+		}
+		return results, nil
+	}), nil
+}
+
+// braveEngine queries the Brave Search API
+type braveEngine struct {
+	apiEngine
+	apiKey string
+}
+
+func (e *braveEngine) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	count := e.count(input)
+	pageSize := min(count, 20)
+	offset := input.startOffset(count)
+	freshness := map[TimeRange]string{
+		TimeRangeDay:   "pd",
+		TimeRangeWeek:  "pw",
+		TimeRangeMonth: "pm",
+		TimeRangeYear:  "py",
+	}
+
+	return e.collect(ctx, input, count, maxAPIPages, func(ctx context.Context, page int) ([]*TextSearchResult, error) {
+		// Brave's offset counts pages of size count
+		params := url.Values{
+			"q":      {input.searchQuery()},
+			"count":  {strconv.Itoa(pageSize)},
+			"offset": {strconv.Itoa(offset/pageSize + page)},
+		}
+		if value, ok := freshness[input.TimeRange]; ok {
+			params.Set("freshness", value)
+		}
+		if input.SafeSearch != "" {
+			params.Set("safesearch", string(input.SafeSearch))
+		}
+		if country, _ := regionParts(input.searchRegion(RegionWT)); country != "" {
+			params.Set("country", country)
+		}
+
+		var body struct {
+			Web struct {
+				Results []struct {
+					Title       string `json:"title"`
+					URL         string `json:"url"`
+					Description string `json:"description"`
+					PageAge     string `json:"page_age"`
+					MetaURL     struct {
+						Favicon string `json:"favicon"`
+					} `json:"meta_url"`
+				} `json:"results"`
+			} `json:"web"`
+		}
+		header := http.Header{"X-Subscription-Token": {e.apiKey}}
+		if err := e.getJSON(ctx, braveSearchURL+"?"+params.Encode(), header, &body); err != nil {
+			return nil, err
+		}
+
+		results := make([]*TextSearchResult, 0, len(body.Web.Results))
+		for _, r := range body.Web.Results {
+			results = append(results, &TextSearchResult{
+				Title:   r.Title,
+				URL:     r.URL,
+				Summary: stripHTMLTags(r.Description),
+				Date:    parseResultDate(r.PageAge),
+				Favicon: r.MetaURL.Favicon,
+			})
+		}
+		return results, nil
+	}), nil
+}
+
+// googleEngine queries a Google Programmable Search Engine through the Custom Search JSON API
+type googleEngine struct {
+	apiEngine
+	apiKey string
+	cx     string
+}
+
+func (e *googleEngine) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	count := e.count(input)
+	pageSize := min(count, 10)
+	offset := input.startOffset(count)
+	dateRestrict := map[TimeRange]string{
+		TimeRangeDay:   "d1",
+		TimeRangeWeek:  "w1",
+		TimeRangeMonth: "m1",
+		TimeRangeYear:  "y1",
+	}
+
+	return e.collect(ctx, input, count, maxAPIPages, func(ctx context.Context, page int) ([]*TextSearchResult, error) {
+		params := url.Values{
+			"key":   {e.apiKey},
+			"cx":    {e.cx},
+			"q":     {input.searchQuery()},
+			"num":   {strconv.Itoa(pageSize)},
+			"start": {strconv.Itoa(offset + page*pageSize + 1)},
+		}
+		if value, ok := dateRestrict[input.TimeRange]; ok {
+			params.Set("dateRestrict", value)
+		}
+		switch input.SafeSearch {
+		case SafeSearchStrict:
+			params.Set("safe", "active")
+		case SafeSearchOff, SafeSearchModerate:
+			params.Set("safe", "off")
+		}
+		if country, _ := regionParts(input.searchRegion(RegionWT)); country != "" {
+			params.Set("gl", country)
+		}
+
+		var body struct {
+			Items []struct {
+				Title        string `json:"title"`
+				Link         string `json:"link"`
+				FormattedURL string `json:"formattedUrl"`
+				Snippet      string `json:"snippet"`
+			} `json:"items"`
+		}
+		if err := e.getJSON(ctx, googleSearchURL+"?"+params.Encode(), nil, &body); err != nil {
+			return nil, err
+		}
+
+		results := make([]*TextSearchResult, 0, len(body.Items))
+		for _, r := range body.Items {
+			results = append(results, &TextSearchResult{Title: r.Title, URL: r.Link, Summary: r.Snippet, DisplayURL: r.FormattedURL})
+		}
+		return results, nil
+	}), nil
+}
+
+// JSONEngineConfig describes a search API that answers GET requests with JSON.
+type JSONEngineConfig struct {
+	// Name selects the engine through the engine argument
+	Name string `json:"name"`
+	// URL is the request URL; {query} is replaced with the escaped query, and the optional
+	// {max_results}, {page} (1-based), {offset}, {time_range}, {region} and {safe_search}
+	// placeholders with their values.
+	// Without {page} only one page is requested.
+	URL string `json:"url"`
+	// Headers are sent with every request, e.g. an API key
+	Headers map[string]string `json:"headers,omitempty"`
+	// ResultsPath is the dot-separated path to the result array, empty when the response is the array
+	ResultsPath string `json:"results_path,omitempty"`
+	// TitleField, URLField and SummaryField are dot-separated paths inside each result.
+	// Default: title, url, summary
+	TitleField   string `json:"title_field,omitempty"`
+	URLField     string `json:"url_field,omitempty"`
+	SummaryField string `json:"summary_field,omitempty"`
+	// TimeRanges maps d, w, m and y to the API's own values for {time_range}
+	TimeRanges map[string]string `json:"time_ranges,omitempty"`
+}
+
+// jsonEngine queries a search API described by a JSONEngineConfig
+type jsonEngine struct {
+	apiEngine
+	config JSONEngineConfig
+}
+
+func (e *jsonEngine) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	pages := 1
+	if strings.Contains(e.config.URL, "{page}") {
+		pages = maxAPIPages
+	}
+
+	titleField := cmp.Or(e.config.TitleField, "title")
+	urlField := cmp.Or(e.config.URLField, "url")
+	summaryField := cmp.Or(e.config.SummaryField, "summary")
+
+	count := e.count(input)
+	offset := input.startOffset(count)
+
+	return e.collect(ctx, input, count, pages, func(ctx context.Context, page int) ([]*TextSearchResult, error) {
+		endpoint := strings.NewReplacer(
+			"{query}", url.QueryEscape(input.searchQuery()),
+			"{max_results}", strconv.Itoa(count),
+			"{page}", strconv.Itoa(offset/count+page+1),
+			"{offset}", strconv.Itoa(offset+page*count),
+			"{time_range}", url.QueryEscape(e.config.TimeRanges[string(input.TimeRange)]),
+			"{region}", url.QueryEscape(string(input.searchRegion(RegionWT))),
+			"{safe_search}", url.QueryEscape(string(input.SafeSearch)),
+		).Replace(e.config.URL)
+
+		header := http.Header{}
+		for key, value := range e.config.Headers {
+			header.Set(key, value)
+		}
+
+		var body any
+		if err := e.getJSON(ctx, endpoint, header, &body); err != nil {
+			return nil, err
+		}
+
+		items, ok := jsonPath(body, e.config.ResultsPath).([]any)
+		if !ok {
+			return nil, fmt.Errorf("response has no result array at %q", e.config.ResultsPath)
+		}
+
+		results := make([]*TextSearchResult, 0, len(items))
+		for _, item := range items {
+			results = append(results, &TextSearchResult{
+				Title:   jsonString(jsonPath(item, titleField)),
+				URL:     jsonString(jsonPath(item, urlField)),
+				Summary: jsonString(jsonPath(item, summaryField)),
+			})
+		}
+		return results, nil
+	}), nil
+}
+
+// jsonPath follows a dot-separated path of object keys and array indexes
+func jsonPath(value any, path string) any {
+	if path == "" {
+		return value
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[key]
+		case []any:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil
+			}
+			value = v[index]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// jsonString renders a decoded JSON scalar as text
+func jsonString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// stripHTMLTags removes the highlighting markup some APIs put in snippets
+func stripHTMLTags(s string) string {
+	var b strings.Builder
+	inTag := false
+	for _, r := range s {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>' && inTag:
+			inTag = false
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
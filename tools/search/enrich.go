@@ -0,0 +1,88 @@
+package search
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// resultDateLayouts are the date formats engines show next to results
+var resultDateLayouts = []string{
+	"2006-01-02T15:04:05.0000000",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	time.DateOnly,
+	"Jan 2, 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+}
+
+// unwrapRedirect returns the target of a DuckDuckGo /l/?uddg= redirect link, or href unchanged
+func unwrapRedirect(href string) string {
+	href = strings.TrimSpace(href)
+
+	u, err := url.Parse(href)
+	if err != nil {
+		return href
+	}
+	if u.Host != "" && !strings.HasSuffix(strings.ToLower(u.Hostname()), "duckduckgo.com") {
+		return href
+	}
+	if u.Path != "/l/" && u.Path != "/l" {
+		return href
+	}
+
+	if target := u.Query().Get("uddg"); target != "" {
+		return target
+	}
+	return href
+}
+
+// isAdURL reports whether href is a DuckDuckGo ad click link
+func isAdURL(href string) bool {
+	u, err := url.Parse(href)
+	return err == nil && strings.HasSuffix(strings.ToLower(u.Hostname()), "duckduckgo.com") && u.Path == "/y.js"
+}
+
+// resultDomain is the host of rawURL in lower case without a leading "www."
+func resultDomain(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// parseResultDate reads a date shown by an engine and formats it as YYYY-MM-DD,
+// or returns an empty string when text is not a date
+func parseResultDate(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return ""
+	}
+
+	for _, layout := range resultDateLayouts {
+		if date, err := time.Parse(layout, text); err == nil {
+			return date.Format(time.DateOnly)
+		}
+	}
+	return ""
+}
+
+// absoluteURL adds the https scheme to protocol-relative URLs
+func absoluteURL(rawURL string) string {
+	if strings.HasPrefix(rawURL, "//") {
+		return "https:" + rawURL
+	}
+	return rawURL
+}
+
+// rankResults numbers results from offset+1 and fills in domains the engine left out
+func rankResults(results []*TextSearchResult, offset int) {
+	for i, result := range results {
+		result.Position = offset + i + 1
+		if result.Domain == "" {
+			result.Domain = resultDomain(result.URL)
+		}
+	}
+}
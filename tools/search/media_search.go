@@ -0,0 +1,352 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxMediaPages bounds the JSON pages one news, image or video search requests
+const maxMediaPages = 5
+
+// vqdPattern finds the validation query digest DuckDuckGo embeds in its search page
+var vqdPattern = regexp.MustCompile(`vqd=["']?([0-9-]+)["']?`)
+
+// mediaPage is the envelope shared by the news, image and video endpoints
+type mediaPage[T any] struct {
+	Results []T    `json:"results"`
+	Next    string `json:"next"`
+}
+
+// ddgNewsResult is a result of the news.js endpoint
+type ddgNewsResult struct {
+	Date    int64  `json:"date"`
+	Title   string `json:"title"`
+	Excerpt string `json:"excerpt"`
+	URL     string `json:"url"`
+	Image   string `json:"image"`
+	Source  string `json:"source"`
+}
+
+// ddgImageResult is a result of the i.js endpoint
+type ddgImageResult struct {
+	Title     string `json:"title"`
+	Image     string `json:"image"`
+	Thumbnail string `json:"thumbnail"`
+	URL       string `json:"url"`
+	Height    int    `json:"height"`
+	Width     int    `json:"width"`
+	Source    string `json:"source"`
+}
+
+// ddgVideoResult is a result of the v.js endpoint
+type ddgVideoResult struct {
+	Content     string `json:"content"`
+	Description string `json:"description"`
+	Duration    string `json:"duration"`
+	Images      struct {
+		Large  string `json:"large"`
+		Medium string `json:"medium"`
+		Small  string `json:"small"`
+	} `json:"images"`
+	Published  string `json:"published"`
+	Publisher  string `json:"publisher"`
+	Statistics struct {
+		ViewCount *int64 `json:"viewCount"`
+	} `json:"statistics"`
+	Title    string `json:"title"`
+	Uploader string `json:"uploader"`
+}
+
+func (c *client) NewsSearch(ctx context.Context, input *NewsSearchRequest) (*NewsSearchResponse, error) {
+	if msg := validateQuery(input.Query); msg != "" {
+		return &NewsSearchResponse{
+			ErrorMessage: msg,
+		}, nil
+	}
+
+	params := url.Values{
+		"l":     {string(mediaRegion(input.Region, c.region))},
+		"noamp": {"1"},
+		"p":     {mediaSafeSearch(input.SafeSearch)},
+	}
+	switch input.TimeRange {
+	case TimeRangeDay, TimeRangeWeek, TimeRangeMonth:
+		params.Set("df", string(input.TimeRange))
+	}
+
+	items, err := searchMedia[ddgNewsResult](ctx, c, "news.js", input.Query, params, boundedCount(input.MaxResults, c.maxResults, c.limit))
+	if err != nil {
+		return &NewsSearchResponse{
+			ErrorMessage: fmt.Sprintf("news search failed: %v. Please try again or rephrase your query", err),
+		}, nil
+	}
+
+	results := make([]*NewsSearchResult, 0, len(items))
+	for _, item := range items {
+		result := &NewsSearchResult{
+			Title:   strings.TrimSpace(item.Title),
+			URL:     item.URL,
+			Summary: strings.TrimSpace(item.Excerpt),
+			Source:  item.Source,
+			Image:   item.Image,
+		}
+		if item.Date > 0 {
+			result.Date = time.Unix(item.Date, 0).UTC().Format(time.RFC3339)
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return &NewsSearchResponse{
+			Message: "No news found for your query. Try using different keywords or a longer time range.",
+		}, nil
+	}
+
+	return &NewsSearchResponse{
+		Message: fmt.Sprintf("Found %d news articles successfully.", len(results)),
+		Results: results,
+	}, nil
+}
+
+func (c *client) ImageSearch(ctx context.Context, input *ImageSearchRequest) (*ImageSearchResponse, error) {
+	if msg := validateQuery(input.Query); msg != "" {
+		return &ImageSearchResponse{
+			ErrorMessage: msg,
+		}, nil
+	}
+
+	// Images only distinguish filtered and unfiltered results
+	safeSearch := "1"
+	if input.SafeSearch == SafeSearchOff {
+		safeSearch = "-1"
+	}
+
+	timeRanges := map[TimeRange]string{
+		TimeRangeDay:   "time:Day",
+		TimeRangeWeek:  "time:Week",
+		TimeRangeMonth: "time:Month",
+		TimeRangeYear:  "time:Year",
+	}
+	filters := []string{
+		timeRanges[input.TimeRange],
+		mediaFilter("size", input.Size),
+		mediaFilter("color", input.Color),
+		mediaFilter("type", input.Type),
+		mediaFilter("layout", input.Layout),
+		mediaFilter("license", input.License),
+	}
+
+	params := url.Values{
+		"l": {string(mediaRegion(input.Region, c.region))},
+		"f": {strings.Join(filters, ",")},
+		"p": {safeSearch},
+	}
+
+	items, err := searchMedia[ddgImageResult](ctx, c, "i.js", input.Query, params, boundedCount(input.MaxResults, c.maxResults, c.limit))
+	if err != nil {
+		return &ImageSearchResponse{
+			ErrorMessage: fmt.Sprintf("image search failed: %v. Please try again or rephrase your query", err),
+		}, nil
+	}
+
+	results := make([]*ImageSearchResult, 0, len(items))
+	for _, item := range items {
+		results = append(results, &ImageSearchResult{
+			Title:        strings.TrimSpace(item.Title),
+			ImageURL:     item.Image,
+			ThumbnailURL: item.Thumbnail,
+			PageURL:      item.URL,
+			Width:        item.Width,
+			Height:       item.Height,
+			Source:       item.Source,
+		})
+	}
+
+	if len(results) == 0 {
+		return &ImageSearchResponse{
+			Message: "No images found for your query. Try using different keywords or fewer filters.",
+		}, nil
+	}
+
+	return &ImageSearchResponse{
+		Message: fmt.Sprintf("Found %d images successfully.", len(results)),
+		Results: results,
+	}, nil
+}
+
+func (c *client) VideoSearch(ctx context.Context, input *VideoSearchRequest) (*VideoSearchResponse, error) {
+	if msg := validateQuery(input.Query); msg != "" {
+		return &VideoSearchResponse{
+			ErrorMessage: msg,
+		}, nil
+	}
+
+	var timeRange string
+	switch input.TimeRange {
+	case TimeRangeDay, TimeRangeWeek, TimeRangeMonth:
+		timeRange = "publishedAfter:" + string(input.TimeRange)
+	}
+	filters := []string{
+		timeRange,
+		mediaFilter("videoDefinition", input.Resolution),
+		mediaFilter("videoDuration", input.Duration),
+		mediaFilter("videoLicense", input.License),
+	}
+
+	params := url.Values{
+		"l": {string(mediaRegion(input.Region, c.region))},
+		"f": {strings.Join(filters, ",")},
+		"p": {mediaSafeSearch(input.SafeSearch)},
+	}
+
+	items, err := searchMedia[ddgVideoResult](ctx, c, "v.js", input.Query, params, boundedCount(input.MaxResults, c.maxResults, c.limit))
+	if err != nil {
+		return &VideoSearchResponse{
+			ErrorMessage: fmt.Sprintf("video search failed: %v. Please try again or rephrase your query", err),
+		}, nil
+	}
+
+	results := make([]*VideoSearchResult, 0, len(items))
+	for _, item := range items {
+		result := &VideoSearchResult{
+			Title:        strings.TrimSpace(item.Title),
+			URL:          item.Content,
+			Description:  strings.TrimSpace(item.Description),
+			Duration:     item.Duration,
+			Publisher:    item.Publisher,
+			Uploader:     item.Uploader,
+			Published:    item.Published,
+			ThumbnailURL: item.Images.Medium,
+		}
+		if result.ThumbnailURL == "" {
+			result.ThumbnailURL = item.Images.Small
+		}
+		if item.Statistics.ViewCount != nil {
+			result.ViewCount = *item.Statistics.ViewCount
+		}
+		results = append(results, result)
+	}
+
+	if len(results) == 0 {
+		return &VideoSearchResponse{
+			Message: "No videos found for your query. Try using different keywords or fewer filters.",
+		}, nil
+	}
+
+	return &VideoSearchResponse{
+		Message: fmt.Sprintf("Found %d videos successfully.", len(results)),
+		Results: results,
+	}, nil
+}
+
+// searchMedia fetches a vqd token for the query, then follows the endpoint's pages until count results are found
+func searchMedia[T any](ctx context.Context, c *client, endpoint, query string, params url.Values, count int) ([]T, error) {
+	vqd, err := c.getVQD(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Set("q", query)
+	params.Set("vqd", vqd)
+	params.Set("o", "json")
+
+	var results []T
+	for page := 0; page < maxMediaPages && len(results) < count; page++ {
+		var body mediaPage[T]
+		if err := c.getMediaJSON(ctx, searchBaseURL+"/"+endpoint+"?"+params.Encode(), &body); err != nil {
+			if len(results) > 0 {
+				break
+			}
+			return nil, err
+		}
+
+		results = append(results, body.Results...)
+		if len(body.Results) == 0 || body.Next == "" {
+			break
+		}
+
+		// next is a relative URL carrying the offset of the following page
+		next, err := url.Parse(body.Next)
+		if err != nil || next.Query().Get("s") == "" {
+			break
+		}
+		params.Set("s", next.Query().Get("s"))
+	}
+
+	if len(results) > count {
+		results = results[:count]
+	}
+	return results, nil
+}
+
+// getVQD reads the validation query digest the JSON endpoints require for a query
+func (c *client) getVQD(ctx context.Context, query string) (string, error) {
+	body, err := c.getMedia(ctx, searchBaseURL+"/?"+url.Values{"q": {query}}.Encode())
+	if err != nil {
+		return "", err
+	}
+
+	match := vqdPattern.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("search token not found, the search service may have changed or be blocking requests")
+	}
+	return string(match[1]), nil
+}
+
+// getMediaJSON requests a JSON endpoint page and decodes it into v
+func (c *client) getMediaJSON(ctx context.Context, endpoint string, v any) error {
+	body, err := c.getMedia(ctx, endpoint)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse search results: %w", err)
+	}
+	return nil
+}
+
+// getMedia sends a GET request to duckduckgo.com and returns the body
+func (c *client) getMedia(ctx context.Context, endpoint string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create search request: %w", err)
+	}
+	req.Header.Set("Referer", searchBaseURL+"/")
+
+	return c.send(ctx, req)
+}
+
+// mediaRegion is the request region, or fallback when none is given
+func mediaRegion(region, fallback Region) Region {
+	if region == "" {
+		return fallback
+	}
+	return Region(strings.ToLower(strings.TrimSpace(string(region))))
+}
+
+// mediaSafeSearch maps a safe search level to the p parameter of the news and video endpoints
+func mediaSafeSearch(level SafeSearch) string {
+	switch level {
+	case SafeSearchStrict:
+		return "1"
+	case SafeSearchOff:
+		return "-2"
+	default:
+		return "-1"
+	}
+}
+
+// mediaFilter renders one name:value slot of the f parameter, empty when no value is given
+func mediaFilter(name, value string) string {
+	if value = strings.TrimSpace(value); value == "" {
+		return ""
+	}
+	return name + ":" + value
+}
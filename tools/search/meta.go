@@ -0,0 +1,170 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// rrfK is the rank constant of reciprocal-rank fusion; larger values flatten the
+// advantage of top positions so agreement between engines weighs more.
+const rrfK = 60
+
+// engineResults is one engine's contribution to a metasearch, or one sub-query's
+// contribution to an expanded search (engine is then empty)
+type engineResults struct {
+	engine   string
+	response *TextSearchResponse
+}
+
+// metaSearch queries several engines concurrently and fuses their rankings
+func (r *engineRegistry) metaSearch(ctx context.Context, input *TextSearchRequest, names []string) *TextSearchResponse {
+	responses := make([]engineResults, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := r.engines[name].TextSearch(ctx, input)
+			if err != nil {
+				resp = &TextSearchResponse{ErrorMessage: err.Error()}
+			}
+			responses[i] = engineResults{engine: name, response: resp}
+		}()
+	}
+	wg.Wait()
+
+	var failures []string
+	var succeeded []string
+	for _, er := range responses {
+		if er.response.ErrorMessage != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", er.engine, er.response.ErrorMessage))
+			continue
+		}
+		succeeded = append(succeeded, er.engine)
+	}
+
+	if len(succeeded) == 0 {
+		return &TextSearchResponse{
+			ErrorMessage: "all search engines failed: " + strings.Join(failures, "; "),
+		}
+	}
+
+	results := fuseResults(responses, input.resultCount(r.maxResults, r.limit))
+
+	var message string
+	if len(results) == 0 {
+		message = "No results found for your query. Try using different keywords or broader search terms."
+	} else {
+		message = fmt.Sprintf("Found %d results successfully from %s.", len(results), strings.Join(succeeded, ", "))
+	}
+	if len(failures) > 0 {
+		message += " Some engines failed: " + strings.Join(failures, "; ")
+	}
+
+	return &TextSearchResponse{
+		Message: message,
+		Results: results,
+	}
+}
+
+// fusedResult accumulates one URL's score across engines
+type fusedResult struct {
+	result *TextSearchResult
+	score  float64
+	order  int
+	lists  map[int]bool
+}
+
+// fuseResults merges ranked lists with reciprocal-rank fusion, deduplicating by normalized URL
+func fuseResults(responses []engineResults, maxResults int) []*TextSearchResult {
+	fused := map[string]*fusedResult{}
+	var order []*fusedResult
+
+	for list, er := range responses {
+		if er.response.ErrorMessage != "" {
+			continue
+		}
+		for rank, result := range er.response.Results {
+			key := normalizeURL(result.URL)
+			entry, ok := fused[key]
+			if !ok {
+				// Position and page are per engine and meaningless once rankings are merged
+				merged := *result
+				merged.Position, merged.Page, merged.Engines = 0, 0, nil
+				entry = &fusedResult{
+					result: &merged,
+					order:  len(order),
+					lists:  map[int]bool{},
+				}
+				fused[key] = entry
+				order = append(order, entry)
+			} else if len(result.Summary) > len(entry.result.Summary) {
+				entry.result.Summary = result.Summary
+			}
+			if !entry.lists[list] {
+				entry.score += 1 / float64(rrfK+rank+1)
+				entry.lists[list] = true
+			}
+			// Results of an expanded metasearch already name their engines
+			for _, engine := range append([]string{er.engine}, result.Engines...) {
+				if engine != "" && !slices.Contains(entry.result.Engines, engine) {
+					entry.result.Engines = append(entry.result.Engines, engine)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		if order[i].score != order[j].score {
+			return order[i].score > order[j].score
+		}
+		return order[i].order < order[j].order
+	})
+
+	results := make([]*TextSearchResult, 0, min(len(order), maxResults))
+	for _, entry := range order {
+		if len(results) == maxResults {
+			break
+		}
+		results = append(results, entry.result)
+	}
+	return results
+}
+
+// normalizeURL reduces a URL to a key that treats trivially different
+// forms of the same page as equal: scheme, "www.", default ports, fragments,
+// trailing slashes, tracking parameters and query parameter order are ignored.
+func normalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Host == "" {
+		return strings.ToLower(strings.TrimSpace(raw))
+	}
+
+	host := strings.ToLower(u.Hostname())
+	host = strings.TrimPrefix(host, "www.")
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+
+	path := strings.TrimRight(u.EscapedPath(), "/")
+
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		if strings.HasPrefix(lower, "utm_") || lower == "fbclid" || lower == "gclid" || lower == "ref" {
+			query.Del(key)
+		}
+	}
+
+	key := host + path
+	if encoded := query.Encode(); encoded != "" {
+		key += "?" + encoded
+	}
+	return key
+}
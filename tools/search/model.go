@@ -3,15 +3,24 @@ package search
 import (
 	"context"
 	"net/http"
+	"sync"
+	"time"
 )
 
 // Common constants
 var (
-	searchHTMLURL = "https://html.duckduckgo.com/html/"
+	searchHTMLURL    = "https://html.duckduckgo.com/html/"
+	searchBaseURL    = "https://duckduckgo.com"
+	instantAnswerURL = "https://api.duckduckgo.com/"
 )
 
 type Search interface {
 	TextSearch(ctx context.Context, req *TextSearchRequest) (*TextSearchResponse, error)
+	NewsSearch(ctx context.Context, req *NewsSearchRequest) (*NewsSearchResponse, error)
+	ImageSearch(ctx context.Context, req *ImageSearchRequest) (*ImageSearchResponse, error)
+	VideoSearch(ctx context.Context, req *VideoSearchRequest) (*VideoSearchResponse, error)
+	Suggest(ctx context.Context, req *SuggestRequest) (*SuggestResponse, error)
+	InstantAnswer(ctx context.Context, req *InstantAnswerRequest) (*InstantAnswerResponse, error)
 }
 
 // client represents the DuckDuckGo search client.
@@ -20,7 +29,17 @@ type Search interface {
 type client struct {
 	httpCli    *http.Client
 	maxResults int
+	limit      int
 	region     Region
+
+	// retry controls retries of throttled and failed requests
+	retry retryPolicy
+	// pageDelay is the pause between result pages; DuckDuckGo answers 202 to fast paging
+	pageDelay time.Duration
+
+	// ua is the session user agent, replaced only when DuckDuckGo blocks it
+	uaMu sync.Mutex
+	ua   string
 }
 
 // Region represents a geographical region for search results.
@@ -64,12 +83,53 @@ const (
 	TimeRangeAny TimeRange = ""
 )
 
+// SafeSearch represents the adult content filter level.
+type SafeSearch string
+
+const (
+	// SafeSearchStrict filters explicit text and images
+	SafeSearchStrict SafeSearch = "strict"
+	// SafeSearchModerate filters explicit images (default)
+	SafeSearchModerate SafeSearch = "moderate"
+	// SafeSearchOff disables filtering
+	SafeSearchOff SafeSearch = "off"
+)
+
 type TextSearchRequest struct {
 	// Query is the user's search query
 	Query string `json:"query" jsonschema:"required,description:Search keywords (required)"`
 	// TimeRange is the search time range
 	// Default: TimeRangeAny
 	TimeRange TimeRange `json:"time_range,omitempty" jsonschema:"description:Time range for search results. Options: d (past day), w (past week), m (past month), y (past year), empty string (any time, default)"`
+	// Engine selects the search engine
+	// Default: the server's default engine
+	Engine string `json:"engine,omitempty" jsonschema:"description:Search engine to use, a comma-separated list of engines, or all to merge results from every configured engine (default: the server's default engine)"`
+	// Region is the geographical region for results
+	// Default: the server's region (RegionWT)
+	Region Region `json:"region,omitempty" jsonschema:"description:Region code such as us-en, uk-en, de-de, fr-fr, jp-jp, cn-zh or wt-wt for no region (default wt-wt)"`
+	// MaxResults limits the number of results returned, bounded by the server maximum
+	// Default: the server's default (10)
+	MaxResults int `json:"max_results,omitempty" jsonschema:"description:Number of results to return (default 10, capped by the server maximum)"`
+	// SafeSearch is the adult content filter level
+	// Default: SafeSearchModerate
+	SafeSearch SafeSearch `json:"safe_search,omitempty" jsonschema:"description:Adult content filter. Options: strict, moderate (default), off"`
+	// Site restricts results to these domains
+	Site []string `json:"site,omitempty" jsonschema:"description:Only return results from these domains (e.g. go.dev)"`
+	// ExcludeSite removes results from these domains
+	ExcludeSite []string `json:"exclude_site,omitempty" jsonschema:"description:Leave out results from these domains"`
+	// Page is the 1-based result page of max_results results, ignored when Offset is set
+	Page int `json:"page,omitempty" jsonschema:"description:Result page to return, starting at 1 (default 1)"`
+	// Offset is the number of results to skip
+	Offset int `json:"offset,omitempty" jsonschema:"description:Number of results to skip; takes precedence over page (default 0)"`
+	// IncludeAds keeps sponsored results, flagged with Sponsored
+	// Default: false (ads are dropped)
+	IncludeAds bool `json:"include_ads,omitempty" jsonschema:"description:Keep sponsored results, flagged as sponsored (default false)"`
+	// Expand also searches the top query suggestions and merges their results
+	// Default: false
+	Expand bool `json:"expand,omitempty" jsonschema:"description:Also search the top autocomplete suggestions for the query and merge the results (default false)"`
+	// IncludeAnswer adds the DuckDuckGo instant answer for the query, when there is one
+	// Default: false
+	IncludeAnswer bool `json:"include_answer,omitempty" jsonschema:"description:Also return the instant answer (abstract, definition, infobox) for factual queries when available (default false)"`
 }
 
 // TextSearchResult represents a single search result.
@@ -81,6 +141,22 @@ type TextSearchResult struct {
 	URL string `json:"url"`
 	// Summary is the summary of the result content
 	Summary string `json:"summary"`
+	// DisplayURL is the shortened address the engine shows for the result
+	DisplayURL string `json:"display_url,omitempty"`
+	// Domain is the host of URL without a leading "www."
+	Domain string `json:"domain,omitempty"`
+	// Date is the published date shown by the engine, as YYYY-MM-DD
+	Date string `json:"date,omitempty"`
+	// Favicon is the URL of the site icon shown by the engine
+	Favicon string `json:"favicon,omitempty"`
+	// Position is the 1-based rank of the result, counting skipped results
+	Position int `json:"position,omitempty"`
+	// Page is the 1-based engine result page the result came from (single engine only)
+	Page int `json:"page,omitempty"`
+	// Sponsored marks an ad, returned only with include_ads
+	Sponsored bool `json:"sponsored,omitempty"`
+	// Engines lists the engines that returned the result (metasearch only)
+	Engines []string `json:"engines,omitempty"`
 }
 
 // TextSearchResponse represents the complete response from a search request.
@@ -89,6 +165,256 @@ type TextSearchResponse struct {
 	Message string `json:"message,omitempty"`
 	// Results contains the list of search results
 	Results []*TextSearchResult `json:"results,omitempty"`
+	// NextOffset is the offset of the next page when more results may follow
+	NextOffset int `json:"next_offset,omitempty"`
+	// Answer is the instant answer for the query, when IncludeAnswer is set and one exists
+	Answer *InstantAnswer `json:"answer,omitempty"`
+	// ExpandedQueries lists the suggestion queries searched alongside the query when Expand is set
+	ExpandedQueries []string `json:"expanded_queries,omitempty"`
+	// Cached reports that the response was served from the search cache
+	Cached bool `json:"cached,omitempty"`
+	// ErrorMessage contains error information to guide the model
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// NewsSearchRequest represents a news search request.
+type NewsSearchRequest struct {
+	// Query is the user's search query
+	Query string `json:"query" jsonschema:"required,description:Search keywords (required)"`
+	// TimeRange is the search time range (d, w or m)
+	// Default: TimeRangeAny
+	TimeRange TimeRange `json:"time_range,omitempty" jsonschema:"description:Time range for news. Options: d (past day), w (past week), m (past month), empty string (any time, default)"`
+	// Region is the geographical region for results
+	// Default: RegionWT
+	Region Region `json:"region,omitempty" jsonschema:"description:Region code such as us-en, uk-en, de-de or wt-wt for no region (default wt-wt)"`
+	// SafeSearch is the adult content filter level
+	// Default: SafeSearchModerate
+	SafeSearch SafeSearch `json:"safe_search,omitempty" jsonschema:"description:Adult content filter. Options: strict, moderate (default), off"`
+	// MaxResults limits the number of results returned, bounded by the server maximum
+	// Default: 10
+	MaxResults int `json:"max_results,omitempty" jsonschema:"description:Number of results to return (default 10, capped by the server maximum)"`
+}
+
+// NewsSearchResult represents a single news article.
+type NewsSearchResult struct {
+	// Title is the headline
+	Title string `json:"title"`
+	// URL is the article address
+	URL string `json:"url"`
+	// Summary is the article excerpt
+	Summary string `json:"summary"`
+	// Source is the publishing outlet
+	Source string `json:"source,omitempty"`
+	// Date is the publication time in RFC 3339 format
+	Date string `json:"date,omitempty"`
+	// Image is the article's preview image
+	Image string `json:"image,omitempty"`
+}
+
+// NewsSearchResponse represents the complete response from a news search request.
+type NewsSearchResponse struct {
+	// Message is a brief status message for the model
+	Message string `json:"message,omitempty"`
+	// Results contains the list of news articles
+	Results []*NewsSearchResult `json:"results,omitempty"`
+	// ErrorMessage contains error information to guide the model
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// ImageSearchRequest represents an image search request.
+type ImageSearchRequest struct {
+	// Query is the user's search query
+	Query string `json:"query" jsonschema:"required,description:Search keywords (required)"`
+	// TimeRange is the search time range
+	// Default: TimeRangeAny
+	TimeRange TimeRange `json:"time_range,omitempty" jsonschema:"description:Time range for images. Options: d (past day), w (past week), m (past month), y (past year), empty string (any time, default)"`
+	// Region is the geographical region for results
+	// Default: RegionWT
+	Region Region `json:"region,omitempty" jsonschema:"description:Region code such as us-en, uk-en, de-de or wt-wt for no region (default wt-wt)"`
+	// SafeSearch is the adult content filter level
+	// Default: SafeSearchModerate
+	SafeSearch SafeSearch `json:"safe_search,omitempty" jsonschema:"description:Adult content filter. Options: strict, moderate (default), off"`
+	// Size filters by image size
+	Size string `json:"size,omitempty" jsonschema:"description:Image size. Options: Small, Medium, Large, Wallpaper"`
+	// Color filters by dominant color
+	Color string `json:"color,omitempty" jsonschema:"description:Image color. Options: color, Monochrome, Red, Orange, Yellow, Green, Blue, Purple, Pink, Brown, Black, Gray, Teal, White"`
+	// Type filters by image type
+	Type string `json:"type,omitempty" jsonschema:"description:Image type. Options: photo, clipart, gif, transparent, line"`
+	// Layout filters by aspect
+	Layout string `json:"layout,omitempty" jsonschema:"description:Image layout. Options: Square, Tall, Wide"`
+	// License filters by usage rights
+	License string `json:"license,omitempty" jsonschema:"description:Image license. Options: any (Creative Commons), Public, Share, ShareCommercially, Modify, ModifyCommercially"`
+	// MaxResults limits the number of results returned, bounded by the server maximum
+	// Default: 10
+	MaxResults int `json:"max_results,omitempty" jsonschema:"description:Number of results to return (default 10, capped by the server maximum)"`
+}
+
+// ImageSearchResult represents a single image.
+type ImageSearchResult struct {
+	// Title is the image title
+	Title string `json:"title"`
+	// ImageURL is the full-size image address
+	ImageURL string `json:"image_url"`
+	// ThumbnailURL is a small preview of the image
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	// PageURL is the page the image appears on
+	PageURL string `json:"page_url"`
+	// Width and Height are the image dimensions in pixels
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// Source is the search provider that found the image
+	Source string `json:"source,omitempty"`
+}
+
+// ImageSearchResponse represents the complete response from an image search request.
+type ImageSearchResponse struct {
+	// Message is a brief status message for the model
+	Message string `json:"message,omitempty"`
+	// Results contains the list of images
+	Results []*ImageSearchResult `json:"results,omitempty"`
+	// ErrorMessage contains error information to guide the model
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// VideoSearchRequest represents a video search request.
+type VideoSearchRequest struct {
+	// Query is the user's search query
+	Query string `json:"query" jsonschema:"required,description:Search keywords (required)"`
+	// TimeRange is the search time range (d, w or m)
+	// Default: TimeRangeAny
+	TimeRange TimeRange `json:"time_range,omitempty" jsonschema:"description:Time range for videos. Options: d (past day), w (past week), m (past month), empty string (any time, default)"`
+	// Region is the geographical region for results
+	// Default: RegionWT
+	Region Region `json:"region,omitempty" jsonschema:"description:Region code such as us-en, uk-en, de-de or wt-wt for no region (default wt-wt)"`
+	// SafeSearch is the adult content filter level
+	// Default: SafeSearchModerate
+	SafeSearch SafeSearch `json:"safe_search,omitempty" jsonschema:"description:Adult content filter. Options: strict, moderate (default), off"`
+	// Resolution filters by video definition
+	Resolution string `json:"resolution,omitempty" jsonschema:"description:Video resolution. Options: high, standard"`
+	// Duration filters by video length
+	Duration string `json:"duration,omitempty" jsonschema:"description:Video length. Options: short, medium, long"`
+	// License filters by usage rights
+	License string `json:"license,omitempty" jsonschema:"description:Video license. Options: creativeCommon, youtube"`
+	// MaxResults limits the number of results returned, bounded by the server maximum
+	// Default: 10
+	MaxResults int `json:"max_results,omitempty" jsonschema:"description:Number of results to return (default 10, capped by the server maximum)"`
+}
+
+// VideoSearchResult represents a single video.
+type VideoSearchResult struct {
+	// Title is the video title
+	Title string `json:"title"`
+	// URL is the video page address
+	URL string `json:"url"`
+	// Description is the video description
+	Description string `json:"description,omitempty"`
+	// Duration is the video length, e.g. 4:12
+	Duration string `json:"duration,omitempty"`
+	// Publisher is the hosting site, e.g. YouTube
+	Publisher string `json:"publisher,omitempty"`
+	// Uploader is the channel or account that published the video
+	Uploader string `json:"uploader,omitempty"`
+	// Published is the publication time in RFC 3339 format
+	Published string `json:"published,omitempty"`
+	// ThumbnailURL is a preview image of the video
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	// ViewCount is the number of views, when known
+	ViewCount int64 `json:"view_count,omitempty"`
+}
+
+// VideoSearchResponse represents the complete response from a video search request.
+type VideoSearchResponse struct {
+	// Message is a brief status message for the model
+	Message string `json:"message,omitempty"`
+	// Results contains the list of videos
+	Results []*VideoSearchResult `json:"results,omitempty"`
+	// ErrorMessage contains error information to guide the model
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// SuggestRequest represents a query suggestion (autocomplete) request.
+type SuggestRequest struct {
+	// Query is the query or prefix to complete
+	Query string `json:"query" jsonschema:"required,description:Query or query prefix to get suggestions for (required)"`
+	// Region is the geographical region for suggestions
+	// Default: the server's region (RegionWT)
+	Region Region `json:"region,omitempty" jsonschema:"description:Region code such as us-en, uk-en, de-de or wt-wt for no region (default wt-wt)"`
+	// MaxResults limits the number of suggestions returned
+	// Default: DefaultSuggestions
+	MaxResults int `json:"max_results,omitempty" jsonschema:"description:Number of suggestions to return (default 8, maximum 20)"`
+}
+
+// SuggestResponse represents the complete response from a suggestion request.
+type SuggestResponse struct {
+	// Message is a brief status message for the model
+	Message string `json:"message,omitempty"`
+	// Suggestions contains the suggested queries, most popular first
+	Suggestions []string `json:"suggestions,omitempty"`
+	// ErrorMessage contains error information to guide the model
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// InstantAnswerRequest represents an instant answer request.
+type InstantAnswerRequest struct {
+	// Query is the question or topic to answer
+	Query string `json:"query" jsonschema:"required,description:Topic, entity or factual question, e.g. golang or 100 usd in eur (required)"`
+}
+
+// InstantAnswer is a structured answer: an abstract, a direct answer or a definition,
+// with infobox facts and related topics.
+type InstantAnswer struct {
+	// Heading is the name of the topic answered
+	Heading string `json:"heading,omitempty"`
+	// Type is the answer type: article, disambiguation, category, name or exclusive
+	Type string `json:"type,omitempty"`
+	// Abstract is a summary of the topic
+	Abstract string `json:"abstract,omitempty"`
+	// Source is the name of the abstract's source, e.g. Wikipedia
+	Source string `json:"source,omitempty"`
+	// SourceURL is the address of the abstract's source page
+	SourceURL string `json:"source_url,omitempty"`
+	// Image is an image of the topic
+	Image string `json:"image,omitempty"`
+	// Answer is a direct answer, e.g. a calculation or conversion
+	Answer string `json:"answer,omitempty"`
+	// AnswerType is the kind of direct answer, e.g. calc
+	AnswerType string `json:"answer_type,omitempty"`
+	// Definition is a dictionary definition of the query
+	Definition string `json:"definition,omitempty"`
+	// DefinitionSource is the name of the definition's source
+	DefinitionSource string `json:"definition_source,omitempty"`
+	// DefinitionURL is the address of the definition's source page
+	DefinitionURL string `json:"definition_url,omitempty"`
+	// Infobox contains the facts of the topic's infobox
+	Infobox []*InfoboxEntry `json:"infobox,omitempty"`
+	// RelatedTopics contains related topics, or the meanings of an ambiguous query
+	RelatedTopics []*RelatedTopic `json:"related_topics,omitempty"`
+}
+
+// InfoboxEntry is one fact of an infobox.
+type InfoboxEntry struct {
+	// Label names the fact, e.g. Designed by
+	Label string `json:"label"`
+	// Value is the fact, e.g. Robert Griesemer
+	Value string `json:"value"`
+}
+
+// RelatedTopic is a topic related to an instant answer.
+type RelatedTopic struct {
+	// Text describes the topic
+	Text string `json:"text"`
+	// URL is the topic's DuckDuckGo page
+	URL string `json:"url,omitempty"`
+	// Category groups the topic, e.g. a meaning of an ambiguous query
+	Category string `json:"category,omitempty"`
+}
+
+// InstantAnswerResponse represents the complete response from an instant answer request.
+type InstantAnswerResponse struct {
+	// Message is a brief status message for the model
+	Message string `json:"message,omitempty"`
+	// Answer is the instant answer, absent when the query has none
+	Answer *InstantAnswer `json:"answer,omitempty"`
 	// ErrorMessage contains error information to guide the model
 	ErrorMessage string `json:"error_message,omitempty"`
 }
@@ -0,0 +1,240 @@
+package search
+
+import (
+	"context"
+	"fkmcps/tools/fetch"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultResearchResults Default number of search results read by search_and_read
+	DefaultResearchResults = 3
+	// MaxResearchResults Maximum number of search results read by search_and_read
+	MaxResearchResults = 10
+	// DefaultResearchChars Default total character budget of search_and_read excerpts
+	DefaultResearchChars = 20000
+	// MaxResearchChars Maximum total character budget of search_and_read excerpts
+	MaxResearchChars = 100000
+	// DefaultResearchTimeout Default per-page fetch timeout in seconds
+	DefaultResearchTimeout = 15
+)
+
+// SearchAndReadRequest represents a search followed by reading the top results.
+type SearchAndReadRequest struct {
+	// Query is the user's search query
+	Query string `json:"query" jsonschema:"required,description:Search keywords (required)"`
+	// TimeRange is the search time range
+	// Default: TimeRangeAny
+	TimeRange TimeRange `json:"time_range,omitempty" jsonschema:"description:Time range for search results. Options: d (past day), w (past week), m (past month), y (past year), empty string (any time, default)"`
+	// Engine selects the search engine
+	// Default: the server's default engine
+	Engine string `json:"engine,omitempty" jsonschema:"description:Search engine to use, a comma-separated list of engines, or all (default: the server's default engine)"`
+	// Region is the geographical region for results
+	// Default: RegionWT
+	Region Region `json:"region,omitempty" jsonschema:"description:Region code such as us-en, uk-en, de-de or wt-wt for no region (default wt-wt)"`
+	// Site restricts results to these domains
+	Site []string `json:"site,omitempty" jsonschema:"description:Only read results from these domains"`
+	// MaxResults is the number of top results to read
+	// Default: DefaultResearchResults
+	MaxResults int `json:"max_results,omitempty" jsonschema:"description:Number of top results to fetch and read (default 3, maximum 10)"`
+	// MaxChars is the total character budget shared by all excerpts
+	// Default: DefaultResearchChars
+	MaxChars int `json:"max_chars,omitempty" jsonschema:"description:Total characters of page content to return across all results (default 20000, maximum 100000)"`
+	// Timeout is the per-page fetch timeout in seconds
+	// Default: DefaultResearchTimeout
+	Timeout int `json:"timeout,omitempty" jsonschema:"description:Per-page fetch timeout in seconds (default 15, maximum 120)"`
+}
+
+// SearchAndReadResult represents one search result with its page content.
+type SearchAndReadResult struct {
+	// Title is the title of the search result
+	Title string `json:"title"`
+	// URL is the web address of the result
+	URL string `json:"url"`
+	// Summary is the search engine's snippet
+	Summary string `json:"summary,omitempty"`
+	// Content is the main content of the page as Markdown, shortened to its share of the budget
+	Content string `json:"content,omitempty"`
+	// IsTruncated reports that Content was shortened
+	IsTruncated bool `json:"is_truncated,omitempty"`
+	// ErrorMessage explains why the page could not be read
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// SearchAndReadResponse represents the complete response from a search-and-read request.
+type SearchAndReadResponse struct {
+	// Message is a brief status message for the model
+	Message string `json:"message,omitempty"`
+	// Results contains the search results in rank order with their page content
+	Results []*SearchAndReadResult `json:"results,omitempty"`
+	// ErrorMessage contains error information to guide the model
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// fetchFunc fetches a URL; fetch.Fetch in production
+type fetchFunc func(ctx context.Context, req *fetch.FetchRequest) (*fetch.FetchResponse, error)
+
+// researcher runs a search and reads the top results concurrently
+type researcher struct {
+	search Search
+	fetch  fetchFunc
+}
+
+func (r *researcher) SearchAndRead(ctx context.Context, input *SearchAndReadRequest) (*SearchAndReadResponse, error) {
+	count := boundedCount(input.MaxResults, DefaultResearchResults, MaxResearchResults)
+	budget := boundedCount(input.MaxChars, DefaultResearchChars, MaxResearchChars)
+	timeout := boundedCount(input.Timeout, DefaultResearchTimeout, fetch.MaxTimeout)
+
+	searchResp, err := r.search.TextSearch(ctx, &TextSearchRequest{
+		Query:      input.Query,
+		TimeRange:  input.TimeRange,
+		Engine:     input.Engine,
+		Region:     input.Region,
+		Site:       input.Site,
+		MaxResults: count,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if searchResp.ErrorMessage != "" {
+		return &SearchAndReadResponse{
+			ErrorMessage: searchResp.ErrorMessage,
+		}, nil
+	}
+	if len(searchResp.Results) == 0 {
+		return &SearchAndReadResponse{
+			Message: searchResp.Message,
+		}, nil
+	}
+
+	hits := searchResp.Results
+	if len(hits) > count {
+		hits = hits[:count]
+	}
+
+	results := make([]*SearchAndReadResult, len(hits))
+	contents := make([]string, len(hits))
+
+	var wg sync.WaitGroup
+	for i, hit := range hits {
+		results[i] = &SearchAndReadResult{
+			Title:   hit.Title,
+			URL:     hit.URL,
+			Summary: hit.Summary,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := r.fetch(ctx, &fetch.FetchRequest{URL: hit.URL, Format: "markdown", Timeout: timeout})
+			switch {
+			case err != nil:
+				results[i].ErrorMessage = fmt.Sprintf("failed to fetch page: %v", err)
+			case resp.ErrorMessage != "":
+				results[i].ErrorMessage = resp.ErrorMessage
+			case resp.Binary != nil:
+				results[i].ErrorMessage = fmt.Sprintf("result is %s content, not a readable page", resp.Binary.MIMEType)
+			default:
+				contents[i] = mainContent(resp.Content)
+				if contents[i] == "" {
+					results[i].ErrorMessage = "page has no readable content"
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	read := 0
+	for i, share := range shareBudget(contents, budget) {
+		if contents[i] == "" {
+			continue
+		}
+		read++
+		results[i].Content, results[i].IsTruncated = truncateRunes(contents[i], share)
+	}
+
+	message := fmt.Sprintf("Read %d of %d search results.", read, len(results))
+	if failed := len(results) - read; failed > 0 {
+		message += fmt.Sprintf(" %d could not be read; see error_message on those results.", failed)
+	}
+
+	return &SearchAndReadResponse{
+		Message: message,
+		Results: results,
+	}, nil
+}
+
+// shareBudget splits budget characters between contents: short contents keep their full
+// length and the remainder is divided evenly between the longer ones
+func shareBudget(contents []string, budget int) []int {
+	shares := make([]int, len(contents))
+
+	var order []int
+	for i, content := range contents {
+		if content != "" {
+			order = append(order, i)
+		}
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return len([]rune(contents[order[a]])) < len([]rune(contents[order[b]]))
+	})
+
+	remaining := budget
+	for n, i := range order {
+		fair := remaining / (len(order) - n)
+		shares[i] = min(len([]rune(contents[i])), fair)
+		remaining -= shares[i]
+	}
+	return shares
+}
+
+// truncateRunes shortens s to at most limit characters, preferring to cut at a paragraph or line break
+func truncateRunes(s string, limit int) (string, bool) {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s, false
+	}
+
+	cut := string(runes[:limit])
+	if i := strings.LastIndex(cut, "\n\n"); i > limit/2 {
+		cut = cut[:i]
+	} else if i := strings.LastIndex(cut, "\n"); i > limit/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut) + "\n…", true
+}
+
+// markdownLinkPattern matches Markdown links and images
+var markdownLinkPattern = regexp.MustCompile(`!?\[[^\]]*\]\([^)]*\)`)
+
+// mainContent drops navigation-like lines (lines made almost entirely of links) and
+// collapses blank runs, leaving the readable body of a page converted to Markdown
+func mainContent(markdown string) string {
+	var lines []string
+	blank := false
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			if len(lines) > 0 && !blank {
+				lines = append(lines, "")
+			}
+			blank = true
+			continue
+		}
+
+		stripped := strings.Trim(markdownLinkPattern.ReplaceAllString(trimmed, ""), " -*|•·>#")
+		if stripped == "" || (markdownLinkPattern.MatchString(trimmed) && len(stripped) < len(trimmed)/5) {
+			continue
+		}
+
+		lines = append(lines, strings.TrimRight(line, " \t"))
+		blank = false
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
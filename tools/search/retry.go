@@ -0,0 +1,199 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/corpix/uarand"
+)
+
+// ErrAnomalyDetected reports that DuckDuckGo answered with its anomaly (captcha) page,
+// meaning requests from this server are being treated as automated.
+var ErrAnomalyDetected = errors.New("DuckDuckGo flagged the requests as automated and returned a captcha page; wait a few minutes before searching again, or use another search engine")
+
+// anomalyMarkers are fragments of DuckDuckGo's anomaly and captcha pages
+var anomalyMarkers = [][]byte{
+	[]byte("anomaly-modal"),
+	[]byte("/anomaly.js"),
+	[]byte("challenge-form"),
+	[]byte("Unfortunately, bots use DuckDuckGo too"),
+}
+
+// maxSearchResponseSize limits the size of a DuckDuckGo response body
+const maxSearchResponseSize = 5 * 1024 * 1024
+
+// retryPolicy controls how requests to DuckDuckGo are retried.
+// The zero value sends each request once.
+type retryPolicy struct {
+	// maxAttempts is the total number of tries per request
+	maxAttempts int
+	// baseDelay is the backoff before the first retry, doubled for every further retry
+	baseDelay time.Duration
+	// maxDelay caps the backoff; a longer Retry-After ends the retries
+	maxDelay time.Duration
+}
+
+// defaultRetryPolicy Retry policy of clients built by NewSearch
+var defaultRetryPolicy = retryPolicy{
+	maxAttempts: 4,
+	baseDelay:   time.Second,
+	maxDelay:    30 * time.Second,
+}
+
+// backoff is the delay before retry number attempt (0-based): exponential with jitter
+// between half and the full value, and never shorter than the server's Retry-After
+func (p retryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := p.baseDelay << attempt
+	if delay > p.maxDelay || delay <= 0 {
+		delay = p.maxDelay
+	}
+	delay = delay/2 + rand.N(delay/2+1)
+	return max(delay, retryAfter)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// isAnomalyPage reports whether a response body is DuckDuckGo's anomaly or captcha page
+func isAnomalyPage(body []byte) bool {
+	for _, marker := range anomalyMarkers {
+		if bytes.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableStatus reports whether a status is worth retrying: DuckDuckGo answers
+// 202 and 429 when throttling, and 5xx on transient failures
+func retryableStatus(statusCode int) bool {
+	return statusCode == http.StatusAccepted || statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// send performs req with the session user agent and returns the body, retrying throttling,
+// transient failures and anomaly pages with exponential backoff. The request body is
+// replayed through req.GetBody, which http.NewRequest sets for in-memory bodies.
+func (c *client) send(ctx context.Context, req *http.Request) ([]byte, error) {
+	attempts := max(c.retry.maxAttempts, 1)
+
+	var lastErr error
+	var delay time.Duration
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, lastErr
+			}
+		}
+
+		attemptReq := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to create search request: %w", err)
+			}
+			attemptReq.Body = body
+		}
+		attemptReq.Header.Set("User-Agent", c.userAgent())
+
+		resp, err := c.httpCli.Do(attemptReq)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("network error, please check your connection: %w", err)
+			}
+			lastErr = fmt.Errorf("network error, please check your connection: %w", err)
+			delay = c.retry.backoff(attempt, 0)
+			continue
+		}
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxSearchResponseSize))
+		resp.Body.Close()
+
+		switch {
+		case retryableStatus(resp.StatusCode):
+			lastErr = statusError(resp.StatusCode)
+			retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if retryAfter > c.retry.maxDelay {
+				return nil, lastErr
+			}
+			delay = c.retry.backoff(attempt, retryAfter)
+			continue
+		case resp.StatusCode == http.StatusForbidden || isAnomalyPage(body):
+			// A new identity is the only way past a block; keep it for the rest of the session
+			lastErr = statusError(resp.StatusCode)
+			if isAnomalyPage(body) {
+				lastErr = ErrAnomalyDetected
+			}
+			c.rotateUserAgent()
+			delay = c.retry.backoff(attempt, 0)
+			continue
+		case resp.StatusCode != http.StatusOK:
+			return nil, statusError(resp.StatusCode)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to read search results: %w", err)
+		}
+		return body, nil
+	}
+
+	return nil, lastErr
+}
+
+// userAgent is the session's user agent, chosen on first use and kept until rotated
+func (c *client) userAgent() string {
+	c.uaMu.Lock()
+	defer c.uaMu.Unlock()
+
+	if c.ua == "" {
+		c.ua = uarand.GetRandom()
+	}
+	return c.ua
+}
+
+// rotateUserAgent switches the session to a different user agent
+func (c *client) rotateUserAgent() {
+	c.uaMu.Lock()
+	defer c.uaMu.Unlock()
+
+	previous := c.ua
+	for range 5 {
+		if c.ua = uarand.GetRandom(); c.ua != previous {
+			return
+		}
+	}
+}
+
+// sleepContext waits for d, returning early with the context's error when it is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -6,6 +6,12 @@ import (
 	"time"
 )
 
+// DefaultMaxResultsLimit is the default upper bound on max_results per request
+const DefaultMaxResultsLimit = 50
+
+// defaultPageDelay is the base pause between DuckDuckGo result pages
+const defaultPageDelay = 2 * time.Second
+
 type Config struct {
 	// Timeout specifies the maximum duration for a single request.
 	// Default: 30 seconds
@@ -20,6 +26,10 @@ type Config struct {
 	// Default: 10
 	MaxResults int `json:"max_results"`
 
+	// MaxResultsLimit bounds the max_results a request may ask for
+	// Default: DefaultMaxResultsLimit
+	MaxResultsLimit int `json:"max_results_limit"`
+
 	// Region is the geographical region for results
 	// Default: RegionWT, means all regions
 	// Reference: https://duckduckgo.com/duckduckgo-help-pages/settings/params
@@ -45,6 +55,11 @@ func buildClient(_ context.Context, config *Config) (Search, error) {
 		maxResults = 10
 	}
 
+	limit := config.MaxResultsLimit
+	if limit <= 0 {
+		limit = DefaultMaxResultsLimit
+	}
+
 	timeout := config.Timeout
 	if timeout == 0 {
 		timeout = 30 * time.Second
@@ -62,6 +77,9 @@ func buildClient(_ context.Context, config *Config) (Search, error) {
 	return &client{
 		httpCli:    httpCli,
 		maxResults: maxResults,
+		limit:      limit,
 		region:     region,
+		retry:      defaultRetryPolicy,
+		pageDelay:  defaultPageDelay,
 	}, nil
 }
@@ -2,9 +2,16 @@ package search
 
 import (
 	"context"
+	"errors"
+	"fkmcps/tools/fetch"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -226,7 +233,9 @@ func TestBuildTextHTMLRequestBody(t *testing.T) {
 		name      string
 		request   *TextSearchRequest
 		region    Region
+		offset    int
 		wantQuery string
+		wantKP    string
 	}{
 		{
 			name: "basic query",
@@ -245,11 +254,35 @@ func TestBuildTextHTMLRequestBody(t *testing.T) {
 			region:    RegionUS,
 			wantQuery: "golang",
 		},
+		{
+			name: "per-request region and safe search",
+			request: &TextSearchRequest{
+				Query:      "golang",
+				Region:     RegionDE,
+				SafeSearch: SafeSearchStrict,
+			},
+			region:    RegionUS,
+			wantQuery: "golang",
+			wantKP:    "1",
+		},
+		{
+			name: "site filters and offset",
+			request: &TextSearchRequest{
+				Query:       "generics",
+				Site:        []string{"go.dev", "pkg.go.dev"},
+				ExcludeSite: []string{"reddit.com"},
+				SafeSearch:  SafeSearchOff,
+			},
+			region:    RegionWT,
+			offset:    20,
+			wantQuery: "generics site:go.dev OR site:pkg.go.dev -site:reddit.com",
+			wantKP:    "-2",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			body := tt.request.buildTextHTMLRequestBody(tt.region)
+			body := tt.request.buildTextHTMLRequestBody(tt.region, tt.offset)
 
 			if body.Get("q") != tt.wantQuery {
 				t.Errorf("Query = %q, want %q", body.Get("q"), tt.wantQuery)
@@ -261,13 +294,724 @@ func TestBuildTextHTMLRequestBody(t *testing.T) {
 				}
 			}
 
-			if tt.region != RegionWT {
-				if body.Get("kl") != string(tt.region) {
-					t.Errorf("Region = %q, want %q", body.Get("kl"), tt.region)
+			if region := tt.request.searchRegion(tt.region); region != RegionWT {
+				if body.Get("kl") != string(region) {
+					t.Errorf("Region = %q, want %q", body.Get("kl"), region)
 				}
 			}
 
+			if body.Get("kp") != tt.wantKP {
+				t.Errorf("Safe search = %q, want %q", body.Get("kp"), tt.wantKP)
+			}
+
+			if tt.offset > 0 && body.Get("s") != fmt.Sprint(tt.offset) {
+				t.Errorf("Offset = %q, want %d", body.Get("s"), tt.offset)
+			}
+
 			t.Logf("Request body: %v", body)
 		})
 	}
 }
+
+func TestEngineRegistry(t *testing.T) {
+	var timeRange string
+	var lastQuery url.Values
+	searxng := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/search" || r.URL.Query().Get("format") != "json" {
+			http.NotFound(w, r)
+			return
+		}
+		lastQuery = r.URL.Query()
+		if r.URL.Query().Get("pageno") != "1" {
+			w.Write([]byte(`{"results": []}`))
+			return
+		}
+		timeRange = r.URL.Query().Get("time_range")
+		w.Write([]byte(`{"results": [
+			{"title": "Go", "url": "https://go.dev/", "content": "The Go language"},
+			{"title": "Tour", "url": "https://go.dev/tour", "content": "A tour"}
+		]}`))
+	}))
+	defer searxng.Close()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data": {"items": [
+			{"name": "Go home", "link": "http://www.go.dev?utm_source=x", "text": "Official site, longer summary"},
+			{"name": "Wiki", "link": "https://en.wikipedia.org/wiki/Go", "text": "Encyclopedia"}
+		]}}`))
+	}))
+	defer api.Close()
+
+	registry, err := newEngineRegistry(context.Background(), &Options{
+		DefaultEngine: EngineSearxNG,
+		SearxNGURL:    searxng.URL + "/",
+		JSONEngines: []JSONEngineConfig{{
+			Name:         "myapi",
+			URL:          api.URL + "/find?q={query}",
+			Headers:      map[string]string{"X-Key": "secret"},
+			ResultsPath:  "data.items",
+			TitleField:   "name",
+			URLField:     "link",
+			SummaryField: "text",
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, _ := registry.TextSearch(context.Background(), &TextSearchRequest{Query: "golang", TimeRange: TimeRangeWeek})
+	if resp.ErrorMessage != "" || len(resp.Results) != 2 || resp.Results[0].Summary != "The Go language" {
+		t.Errorf("default engine response = %+v", resp)
+	}
+	if timeRange != "week" {
+		t.Errorf("searxng time_range = %q, want week", timeRange)
+	}
+
+	resp, _ = registry.TextSearch(context.Background(), &TextSearchRequest{Query: "golang", MaxResults: 1})
+	if len(resp.Results) != 1 || resp.NextOffset != 1 {
+		t.Errorf("max_results response = %+v", resp)
+	}
+
+	registry.TextSearch(context.Background(), &TextSearchRequest{Query: "golang", Region: RegionDE, SafeSearch: SafeSearchStrict, Site: []string{"go.dev"}, Page: 3})
+	if lastQuery.Get("q") != "golang site:go.dev" || lastQuery.Get("language") != "de" || lastQuery.Get("safesearch") != "2" || lastQuery.Get("pageno") != "3" {
+		t.Errorf("searxng query = %v", lastQuery)
+	}
+
+	resp, _ = registry.TextSearch(context.Background(), &TextSearchRequest{Query: "golang", Engine: "myapi"})
+	if resp.ErrorMessage != "" || len(resp.Results) != 2 || resp.Results[1].Title != "Wiki" {
+		t.Errorf("json engine response = %+v", resp)
+	}
+
+	resp, _ = registry.TextSearch(context.Background(), &TextSearchRequest{Query: "golang", Engine: "searxng, myapi"})
+	if resp.ErrorMessage != "" || len(resp.Results) != 3 {
+		t.Fatalf("metasearch response = %+v", resp)
+	}
+	first := resp.Results[0]
+	if first.URL != "https://go.dev/" || len(first.Engines) != 2 || first.Summary != "Official site, longer summary" {
+		t.Errorf("fused first result = %+v", first)
+	}
+
+	resp, _ = registry.TextSearch(context.Background(), &TextSearchRequest{Query: "golang", Engine: "nope"})
+	if !strings.Contains(resp.ErrorMessage, "unknown search engine") {
+		t.Errorf("unknown engine ErrorMessage = %q", resp.ErrorMessage)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{"https://www.example.com/a/", "http://example.com/a", true},
+		{"https://example.com:443/a#top", "https://example.com/a", true},
+		{"https://example.com/a?b=2&a=1&utm_source=x", "https://example.com/a?a=1&b=2", true},
+		{"https://example.com/a", "https://example.com/b", false},
+		{"https://example.com/a?id=1", "https://example.com/a?id=2", false},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeURL(tt.a) == normalizeURL(tt.b); got != tt.same {
+			t.Errorf("normalizeURL(%q) == normalizeURL(%q) is %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
+
+// countingSearch is a Search stub that records how often it is called
+type countingSearch struct {
+	Search
+	calls int
+	fail  bool
+}
+
+func (s *countingSearch) TextSearch(_ context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	s.calls++
+	if s.fail {
+		return &TextSearchResponse{ErrorMessage: "engine down"}, nil
+	}
+	return &TextSearchResponse{
+		Message: "Found 1 results successfully.",
+		Results: []*TextSearchResult{{Title: input.Query, URL: "https://example.com/" + input.Query}},
+	}, nil
+}
+
+func TestCachedSearch(t *testing.T) {
+	ctx := context.Background()
+	next := &countingSearch{}
+	now := time.Now()
+	dir := t.TempDir()
+
+	cache := newCachedSearch(next, time.Minute, 2, dir)
+	cache.now = func() time.Time { return now }
+
+	first, _ := cache.TextSearch(ctx, &TextSearchRequest{Query: "golang"})
+	second, _ := cache.TextSearch(ctx, &TextSearchRequest{Query: "golang"})
+	if next.calls != 1 || first.Cached || !second.Cached || second.Results[0].Title != "golang" {
+		t.Fatalf("calls = %d, first.Cached = %v, second = %+v", next.calls, first.Cached, second)
+	}
+
+	cache.TextSearch(ctx, &TextSearchRequest{Query: "golang", TimeRange: TimeRangeWeek})
+	if next.calls != 2 {
+		t.Errorf("time range did not change the cache key, calls = %d", next.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if resp, _ := cache.TextSearch(ctx, &TextSearchRequest{Query: "golang"}); resp.Cached || next.calls != 3 {
+		t.Errorf("expired entry was served, calls = %d", next.calls)
+	}
+
+	cache.TextSearch(ctx, &TextSearchRequest{Query: "rust"})
+	cache.TextSearch(ctx, &TextSearchRequest{Query: "zig"})
+	if cache.order.Len() != 2 {
+		t.Errorf("cache holds %d entries, want 2", cache.order.Len())
+	}
+
+	restarted := newCachedSearch(next, time.Minute, 2, dir)
+	restarted.now = cache.now
+	calls := next.calls
+	if resp, _ := restarted.TextSearch(ctx, &TextSearchRequest{Query: "zig"}); !resp.Cached || next.calls != calls {
+		t.Errorf("persisted entry was not reused, calls = %d, want %d", next.calls, calls)
+	}
+
+	next.fail = true
+	cache.TextSearch(ctx, &TextSearchRequest{Query: "broken"})
+	cache.TextSearch(ctx, &TextSearchRequest{Query: "broken"})
+	if next.calls != calls+2 {
+		t.Errorf("failed response was cached, calls = %d, want %d", next.calls, calls+2)
+	}
+}
+
+func TestMediaSearch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if r.URL.Path != "/" && query.Get("vqd") != "4-12345" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><script>vqd="4-12345";</script></html>`))
+		case "/news.js":
+			if query.Get("df") != "w" || query.Get("p") != "1" {
+				t.Errorf("news params = %v", query)
+			}
+			if query.Get("s") == "" {
+				w.Write([]byte(`{"results": [{"date": 1700000000, "title": "Go 1.22", "excerpt": "Released", "url": "https://go.dev/blog", "source": "Go Blog"}], "next": "news.js?q=golang&s=1"}`))
+				return
+			}
+			w.Write([]byte(`{"results": [{"date": 1700000100, "title": "Go 1.23", "excerpt": "Iterators", "url": "https://go.dev/blog/2", "source": "Go Blog"}]}`))
+		case "/i.js":
+			if query.Get("f") != ",size:Large,,type:photo,," {
+				t.Errorf("image filters = %q", query.Get("f"))
+			}
+			w.Write([]byte(`{"results": [{"title": "Gopher", "image": "https://go.dev/gopher.png", "thumbnail": "https://t.example/1.jpg", "url": "https://go.dev/", "width": 640, "height": 480, "source": "Bing"}]}`))
+		case "/v.js":
+			w.Write([]byte(`{"results": [{"title": "Intro", "content": "https://youtube.com/watch?v=1", "duration": "4:12", "publisher": "YouTube", "images": {"medium": "https://i.example/m.jpg"}, "statistics": {"viewCount": 1200}}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	original := searchBaseURL
+	searchBaseURL = server.URL
+	defer func() { searchBaseURL = original }()
+
+	c := &client{httpCli: server.Client(), maxResults: 10, limit: 50, region: RegionWT}
+	ctx := context.Background()
+
+	news, _ := c.NewsSearch(ctx, &NewsSearchRequest{Query: "golang", TimeRange: TimeRangeWeek, SafeSearch: SafeSearchStrict})
+	if news.ErrorMessage != "" || len(news.Results) != 2 || news.Results[0].Date != "2023-11-14T22:13:20Z" || news.Results[1].Title != "Go 1.23" {
+		t.Errorf("news response = %+v", news)
+	}
+
+	images, _ := c.ImageSearch(ctx, &ImageSearchRequest{Query: "gopher", Size: "Large", Type: "photo"})
+	if images.ErrorMessage != "" || len(images.Results) != 1 || images.Results[0].Width != 640 || images.Results[0].PageURL != "https://go.dev/" {
+		t.Errorf("image response = %+v", images)
+	}
+
+	videos, _ := c.VideoSearch(ctx, &VideoSearchRequest{Query: "golang intro"})
+	if videos.ErrorMessage != "" || len(videos.Results) != 1 || videos.Results[0].ViewCount != 1200 || videos.Results[0].ThumbnailURL != "https://i.example/m.jpg" {
+		t.Errorf("video response = %+v", videos)
+	}
+
+	if resp, _ := c.NewsSearch(ctx, &NewsSearchRequest{}); !strings.Contains(resp.ErrorMessage, "query is required") {
+		t.Errorf("empty query ErrorMessage = %q", resp.ErrorMessage)
+	}
+}
+
+// staticSearch is a Search stub returning fixed results
+type staticSearch struct {
+	Search
+	results []*TextSearchResult
+	request *TextSearchRequest
+}
+
+func (s *staticSearch) TextSearch(_ context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	s.request = input
+	return &TextSearchResponse{Results: s.results}, nil
+}
+
+func TestSearchAndRead(t *testing.T) {
+	search := &staticSearch{results: []*TextSearchResult{
+		{Title: "Long", URL: "https://a.example/"},
+		{Title: "Broken", URL: "https://b.example/"},
+		{Title: "Short", URL: "https://c.example/"},
+		{Title: "Extra", URL: "https://d.example/"},
+	}}
+	pages := map[string]string{
+		"https://a.example/": "[Home](/) | [About](/about)\n\n# Article\n\n" + strings.Repeat("word ", 400),
+		"https://c.example/": "Short page body.",
+	}
+	research := &researcher{search: search, fetch: func(_ context.Context, req *fetch.FetchRequest) (*fetch.FetchResponse, error) {
+		if req.Format != "markdown" {
+			t.Errorf("fetch format = %q, want markdown", req.Format)
+		}
+		content, ok := pages[req.URL]
+		if !ok {
+			return &fetch.FetchResponse{StatusCode: 404, ErrorMessage: "request failed with status code: 404"}, nil
+		}
+		return &fetch.FetchResponse{Content: content, StatusCode: 200}, nil
+	}}
+
+	resp, err := research.SearchAndRead(context.Background(), &SearchAndReadRequest{Query: "golang", MaxResults: 3, MaxChars: 500})
+	if err != nil || resp.ErrorMessage != "" {
+		t.Fatalf("SearchAndRead() = %+v, %v", resp, err)
+	}
+	if search.request.MaxResults != 3 || len(resp.Results) != 3 {
+		t.Fatalf("read %d results, search asked for %d", len(resp.Results), search.request.MaxResults)
+	}
+
+	long, broken, short := resp.Results[0], resp.Results[1], resp.Results[2]
+	if short.Content != "Short page body." || short.IsTruncated {
+		t.Errorf("short result = %+v", short)
+	}
+	if !long.IsTruncated || strings.Contains(long.Content, "About") || !strings.HasPrefix(long.Content, "# Article") {
+		t.Errorf("long result = %q", long.Content)
+	}
+	if total := len([]rune(long.Content)) + len([]rune(short.Content)); total > 500+2 {
+		t.Errorf("excerpts use %d characters, budget is 500", total)
+	}
+	if broken.Content != "" || !strings.Contains(broken.ErrorMessage, "404") {
+		t.Errorf("broken result = %+v", broken)
+	}
+}
+
+func TestShareBudget(t *testing.T) {
+	contents := []string{strings.Repeat("a", 1000), "", strings.Repeat("b", 100), strings.Repeat("c", 1000)}
+	shares := shareBudget(contents, 900)
+	if want := []int{400, 0, 100, 400}; fmt.Sprint(shares) != fmt.Sprint(want) {
+		t.Errorf("shareBudget() = %v, want %v", shares, want)
+	}
+}
+
+// replayFixtures serves the recorded DuckDuckGo HTML pages from testdata, page 1 for the
+// first request (or s=20), page 2 for the s=5 form value of the page 1 next link, and
+// records the requests it receives
+func replayFixtures(t *testing.T, respond func(attempt int, w http.ResponseWriter) bool) (*httptest.Server, *[]*http.Request) {
+	t.Helper()
+
+	pages := map[string][]byte{}
+	for s, name := range map[string]string{"": "ddg_html_page1.html", "5": "ddg_html_page2.html"} {
+		page, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[s] = page
+	}
+	pages["20"] = pages[""]
+
+	var requests []*http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		requests = append(requests, r)
+		if respond != nil && respond(len(requests), w) {
+			return
+		}
+		page, ok := pages[r.PostForm.Get("s")]
+		if !ok || r.PostForm.Get("q") != "golang" {
+			t.Errorf("unexpected form %v", r.PostForm)
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(page)
+	}))
+	t.Cleanup(server.Close)
+
+	original := searchHTMLURL
+	searchHTMLURL = server.URL + "/html/"
+	t.Cleanup(func() { searchHTMLURL = original })
+
+	return server, &requests
+}
+
+func TestTextSearchFixtures(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 3, baseDelay: time.Millisecond, maxDelay: 10 * time.Millisecond}
+
+	t.Run("pages", func(t *testing.T) {
+		server, requests := replayFixtures(t, nil)
+		c := &client{httpCli: server.Client(), maxResults: 10, limit: 50, region: RegionWT, retry: policy}
+
+		resp, _ := c.TextSearch(context.Background(), &TextSearchRequest{Query: "golang"})
+		if resp.ErrorMessage != "" {
+			t.Fatalf("ErrorMessage = %q", resp.ErrorMessage)
+		}
+
+		// The ad is dropped and A Tour of Go, repeated on page 2 under another form of its URL, appears once
+		var titles []string
+		for _, result := range resp.Results {
+			titles = append(titles, result.Title)
+		}
+		want := []string{
+			"The Go Programming Language",
+			"Go (programming language) - Wikipedia",
+			"Tutorial: Get started with Go - The Go Programming Language",
+			"GitHub - golang/go: The Go programming language",
+			"A Tour of Go",
+			"Go Packages",
+			"Go by Example",
+		}
+		if strings.Join(titles, "|") != strings.Join(want, "|") {
+			t.Fatalf("titles = %q, want %q", titles, want)
+		}
+
+		first := resp.Results[0]
+		if first.URL != "https://go.dev/" || !strings.Contains(first.Summary, "secure, scalable") ||
+			first.DisplayURL != "go.dev" || first.Domain != "go.dev" || first.Favicon != "https://external-content.duckduckgo.com/ip3/go.dev.ico" ||
+			first.Position != 1 || first.Page != 1 {
+			t.Errorf("first result = %+v", first)
+		}
+		if wiki := resp.Results[1]; wiki.Date != "2024-02-06" || wiki.Domain != "en.wikipedia.org" {
+			t.Errorf("dated result = %+v", wiki)
+		}
+		if github := resp.Results[3]; github.URL != "https://github.com/golang/go" || github.Domain != "github.com" {
+			t.Errorf("redirect result = %+v", github)
+		}
+		if last := resp.Results[6]; last.Position != 7 || last.Page != 2 {
+			t.Errorf("page 2 result = %+v", last)
+		}
+
+		if len(*requests) < 2 {
+			t.Fatalf("requests = %d, want at least 2", len(*requests))
+		}
+		agent := (*requests)[0].Header.Get("User-Agent")
+		for _, r := range *requests {
+			if ua := r.Header.Get("User-Agent"); ua == "" || ua != agent {
+				t.Errorf("User-Agent = %q, want the session agent %q", ua, agent)
+			}
+		}
+	})
+
+	t.Run("ads and offset", func(t *testing.T) {
+		server, _ := replayFixtures(t, nil)
+		c := &client{httpCli: server.Client(), maxResults: 3, limit: 50, region: RegionWT, retry: policy}
+
+		resp, _ := c.TextSearch(context.Background(), &TextSearchRequest{Query: "golang", IncludeAds: true, Offset: 20})
+		if resp.ErrorMessage != "" || len(resp.Results) != 3 {
+			t.Fatalf("response = %+v", resp)
+		}
+		if ad := resp.Results[0]; !ad.Sponsored || ad.Domain != "example-ads.com" || ad.Position != 21 {
+			t.Errorf("ad = %+v", ad)
+		}
+		if resp.Results[1].Sponsored || resp.Results[1].Position != 22 {
+			t.Errorf("organic result = %+v", resp.Results[1])
+		}
+	})
+
+	t.Run("throttled then served", func(t *testing.T) {
+		server, requests := replayFixtures(t, func(attempt int, w http.ResponseWriter) bool {
+			switch attempt {
+			case 1:
+				w.WriteHeader(http.StatusAccepted)
+				return true
+			case 2:
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return true
+			}
+			return false
+		})
+		c := &client{httpCli: server.Client(), maxResults: 3, limit: 50, region: RegionWT, retry: policy}
+
+		resp, _ := c.TextSearch(context.Background(), &TextSearchRequest{Query: "golang"})
+		if resp.ErrorMessage != "" || len(resp.Results) != 3 {
+			t.Fatalf("response = %+v", resp)
+		}
+		if len(*requests) != 3 {
+			t.Errorf("requests = %d, want 3", len(*requests))
+		}
+		for _, r := range *requests {
+			if r.PostForm.Get("q") != "golang" {
+				t.Errorf("retried request lost its body: %v", r.PostForm)
+			}
+		}
+	})
+
+	t.Run("long retry after", func(t *testing.T) {
+		server, requests := replayFixtures(t, func(_ int, w http.ResponseWriter) bool {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return true
+		})
+		c := &client{httpCli: server.Client(), maxResults: 3, limit: 50, region: RegionWT, retry: policy}
+
+		resp, _ := c.TextSearch(context.Background(), &TextSearchRequest{Query: "golang"})
+		if !strings.Contains(resp.ErrorMessage, "rate limit") {
+			t.Errorf("ErrorMessage = %q", resp.ErrorMessage)
+		}
+		if len(*requests) != 1 {
+			t.Errorf("requests = %d, want 1", len(*requests))
+		}
+	})
+
+	t.Run("anomaly", func(t *testing.T) {
+		anomaly, err := os.ReadFile(filepath.Join("testdata", "ddg_anomaly.html"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		server, requests := replayFixtures(t, func(_ int, w http.ResponseWriter) bool {
+			w.Write(anomaly)
+			return true
+		})
+		c := &client{httpCli: server.Client(), maxResults: 3, limit: 50, region: RegionWT, retry: policy}
+
+		req, _ := http.NewRequest(http.MethodPost, searchHTMLURL, strings.NewReader("q=golang"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if _, _, err := c.doTextHTMLSearch(context.Background(), req); !errors.Is(err, ErrAnomalyDetected) {
+			t.Errorf("err = %v, want ErrAnomalyDetected", err)
+		}
+		if len(*requests) != policy.maxAttempts {
+			t.Errorf("requests = %d, want %d", len(*requests), policy.maxAttempts)
+		}
+
+		resp, _ := c.TextSearch(context.Background(), &TextSearchRequest{Query: "golang"})
+		if !strings.Contains(resp.ErrorMessage, "captcha") {
+			t.Errorf("ErrorMessage = %q", resp.ErrorMessage)
+		}
+	})
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 4, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+
+	for attempt, ceiling := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		for range 20 {
+			if delay := policy.backoff(attempt, 0); delay < ceiling/2 || delay > ceiling {
+				t.Errorf("backoff(%d) = %v, want within [%v, %v]", attempt, delay, ceiling/2, ceiling)
+			}
+		}
+	}
+	if delay := policy.backoff(0, 5*time.Second); delay != 5*time.Second {
+		t.Errorf("backoff with Retry-After = %v, want 5s", delay)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Mon, 01 Jan 2024 12:00:30 GMT": 30 * time.Second,
+		"Mon, 01 Jan 2024 11:00:00 GMT": 0,
+	}
+	for value, want := range tests {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+// querySearch is a textSearcher stub answering each query with fixed results
+type querySearch map[string][]*TextSearchResult
+
+func (s querySearch) TextSearch(_ context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	var results []*TextSearchResult
+	for _, result := range s[input.Query] {
+		copied := *result
+		results = append(results, &copied)
+	}
+	return &TextSearchResponse{Results: results}, nil
+}
+
+func TestSuggest(t *testing.T) {
+	var region string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ac/" {
+			http.NotFound(w, r)
+			return
+		}
+		region = r.URL.Query().Get("kl")
+		w.Write([]byte(`[{"phrase": "golang"}, {"phrase": "golang generics"}, {"phrase": "Golang Generics"}, {"phrase": "golang tutorial"}, {"phrase": " "}]`))
+	}))
+	defer server.Close()
+
+	original := searchBaseURL
+	searchBaseURL = server.URL
+	defer func() { searchBaseURL = original }()
+
+	c := &client{httpCli: server.Client(), maxResults: 10, limit: 50, region: RegionWT}
+	ctx := context.Background()
+
+	resp, _ := c.Suggest(ctx, &SuggestRequest{Query: "golang", Region: RegionDE})
+	if resp.ErrorMessage != "" || strings.Join(resp.Suggestions, "|") != "golang|golang generics|golang tutorial" {
+		t.Errorf("suggest response = %+v", resp)
+	}
+	if region != "de-de" {
+		t.Errorf("kl = %q, want de-de", region)
+	}
+
+	if resp, _ := c.Suggest(ctx, &SuggestRequest{Query: "golang", MaxResults: 2}); len(resp.Suggestions) != 2 {
+		t.Errorf("max_results suggestions = %q", resp.Suggestions)
+	}
+	if resp, _ := c.Suggest(ctx, &SuggestRequest{}); !strings.Contains(resp.ErrorMessage, "query is required") {
+		t.Errorf("empty query ErrorMessage = %q", resp.ErrorMessage)
+	}
+
+	stub := querySearch{
+		"golang":          {{Title: "Go", URL: "https://go.dev/"}, {Title: "Tour", URL: "https://go.dev/tour"}},
+		"golang generics": {{Title: "Generics", URL: "https://go.dev/doc/tutorial/generics"}, {Title: "Go", URL: "https://go.dev"}},
+		"golang tutorial": {{Title: "Tutorial", URL: "https://go.dev/doc/tutorial/getting-started"}},
+	}
+	registry := &engineRegistry{
+		engines:       map[string]textSearcher{"stub": stub},
+		duckduckgo:    c,
+		names:         []string{"stub"},
+		defaultEngine: "stub",
+		maxResults:    10,
+		limit:         50,
+	}
+
+	expanded, _ := registry.TextSearch(ctx, &TextSearchRequest{Query: "golang", Expand: true})
+	if expanded.ErrorMessage != "" || strings.Join(expanded.ExpandedQueries, "|") != "golang generics|golang tutorial" {
+		t.Fatalf("expanded response = %+v", expanded)
+	}
+	if len(expanded.Results) != 4 || expanded.Results[0].Title != "Go" || expanded.Results[3].Position != 4 {
+		t.Errorf("expanded results = %+v", expanded.Results)
+	}
+
+	plain, _ := registry.TextSearch(ctx, &TextSearchRequest{Query: "golang"})
+	if len(plain.Results) != 2 || plain.ExpandedQueries != nil {
+		t.Errorf("plain response = %+v", plain)
+	}
+
+	// Expanded DuckDuckGo searches run one at a time, API engines concurrently
+	for _, tt := range []struct {
+		engine  string
+		wantMax int32
+	}{
+		{EngineDuckDuckGo, 1},
+		{"api", 3},
+	} {
+		tracked := &inFlightSearch{search: stub}
+		registry.engines = map[string]textSearcher{tt.engine: tracked}
+		registry.names = []string{tt.engine}
+		resp, _ := registry.TextSearch(ctx, &TextSearchRequest{Query: "golang", Engine: tt.engine, Expand: true})
+		if resp.ErrorMessage != "" || len(resp.Results) != 4 {
+			t.Fatalf("%s expanded response = %+v", tt.engine, resp)
+		}
+		if got := tracked.max.Load(); got != tt.wantMax {
+			t.Errorf("%s concurrent searches = %d, want %d", tt.engine, got, tt.wantMax)
+		}
+	}
+}
+
+// inFlightSearch Records the most searches running at once; each search waits briefly so
+// concurrent ones overlap
+type inFlightSearch struct {
+	search   textSearcher
+	inFlight atomic.Int32
+	max      atomic.Int32
+}
+
+func (s *inFlightSearch) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		current := s.max.Load()
+		if n <= current || s.max.CompareAndSwap(current, n) {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	return s.search.TextSearch(ctx, input)
+}
+
+func TestInstantAnswer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("format") != "json" || query.Get("no_html") != "1" {
+			t.Errorf("instant answer params = %v", query)
+		}
+		switch query.Get("q") {
+		case "golang":
+			w.Write([]byte(`{
+				"Heading": "Go (programming language)",
+				"Type": "A",
+				"AbstractText": "Go is a statically typed, compiled high-level programming language designed at Google.",
+				"AbstractSource": "Wikipedia",
+				"AbstractURL": "https://en.wikipedia.org/wiki/Go_(programming_language)",
+				"Image": "/i/a4a3f0d2.png",
+				"Answer": "",
+				"Infobox": {"content": [
+					{"data_type": "string", "label": "Designed by", "value": "Robert Griesemer", "wiki_order": 0},
+					{"data_type": "instance", "label": "Instance of", "value": {"entity-type": "item", "id": "Q9143"}, "wiki_order": 1},
+					{"data_type": "string", "label": "First appeared", "value": "November 10, 2009", "wiki_order": 2}
+				]},
+				"RelatedTopics": [
+					{"FirstURL": "https://duckduckgo.com/Gopher", "Text": "Gopher - The Go mascot", "Icon": {"URL": ""}},
+					{"Name": "See also", "Topics": [{"FirstURL": "https://duckduckgo.com/Rust", "Text": "Rust (programming language)"}]}
+				]
+			}`))
+		case "2+2":
+			w.Write([]byte(`{"Heading": "", "Type": "E", "Answer": "2 + 2 = 4", "AnswerType": "calc", "Infobox": "", "RelatedTopics": []}`))
+		default:
+			w.Write([]byte(`{"Heading": "", "Type": "", "AbstractText": "", "Answer": "", "Infobox": "", "RelatedTopics": []}`))
+		}
+	}))
+	defer server.Close()
+
+	originalAnswer, originalBase := instantAnswerURL, searchBaseURL
+	instantAnswerURL, searchBaseURL = server.URL+"/", "https://duckduckgo.com"
+	defer func() { instantAnswerURL, searchBaseURL = originalAnswer, originalBase }()
+
+	c := &client{httpCli: server.Client(), maxResults: 10, limit: 50, region: RegionWT}
+	ctx := context.Background()
+
+	resp, _ := c.InstantAnswer(ctx, &InstantAnswerRequest{Query: "golang"})
+	if resp.ErrorMessage != "" || resp.Answer == nil {
+		t.Fatalf("instant answer response = %+v", resp)
+	}
+	answer := resp.Answer
+	if answer.Type != "article" || answer.Source != "Wikipedia" || answer.Image != "https://duckduckgo.com/i/a4a3f0d2.png" {
+		t.Errorf("answer = %+v", answer)
+	}
+	if len(answer.Infobox) != 2 || answer.Infobox[1].Label != "First appeared" {
+		t.Errorf("infobox = %+v", answer.Infobox)
+	}
+	if len(answer.RelatedTopics) != 2 || answer.RelatedTopics[1].Category != "See also" {
+		t.Errorf("related topics = %+v", answer.RelatedTopics)
+	}
+
+	if resp, _ := c.InstantAnswer(ctx, &InstantAnswerRequest{Query: "2+2"}); resp.Answer == nil || resp.Answer.Answer != "2 + 2 = 4" {
+		t.Errorf("calc response = %+v", resp)
+	}
+	if resp, _ := c.InstantAnswer(ctx, &InstantAnswerRequest{Query: "zzqx"}); resp.Answer != nil || resp.ErrorMessage != "" {
+		t.Errorf("no answer response = %+v", resp)
+	}
+
+	registry := &engineRegistry{
+		engines:       map[string]textSearcher{"stub": querySearch{"golang": {{Title: "Go", URL: "https://go.dev/"}}}},
+		duckduckgo:    c,
+		names:         []string{"stub"},
+		defaultEngine: "stub",
+		maxResults:    10,
+		limit:         50,
+	}
+	search, _ := registry.TextSearch(ctx, &TextSearchRequest{Query: "golang", IncludeAnswer: true})
+	if len(search.Results) != 1 || search.Answer == nil || search.Answer.Heading != "Go (programming language)" {
+		t.Errorf("search with answer = %+v", search)
+	}
+}
@@ -0,0 +1,155 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultSuggestions Default number of query suggestions returned
+	DefaultSuggestions = 8
+	// MaxSuggestions Maximum number of query suggestions returned
+	MaxSuggestions = 20
+	// expandQueries Number of suggestions searched alongside the query when expand is set
+	expandQueries = 2
+)
+
+func (c *client) Suggest(ctx context.Context, input *SuggestRequest) (*SuggestResponse, error) {
+	if msg := validateQuery(strings.TrimSpace(input.Query)); msg != "" {
+		return &SuggestResponse{
+			ErrorMessage: msg,
+		}, nil
+	}
+
+	params := url.Values{
+		"q":  {input.Query},
+		"kl": {string(mediaRegion(input.Region, c.region))},
+	}
+	body, err := c.getMedia(ctx, searchBaseURL+"/ac/?"+params.Encode())
+	if err != nil {
+		return &SuggestResponse{
+			ErrorMessage: fmt.Sprintf("suggestion request failed: %v. Please try again", err),
+		}, nil
+	}
+
+	var items []struct {
+		Phrase string `json:"phrase"`
+	}
+	if err := json.Unmarshal(body, &items); err != nil {
+		return &SuggestResponse{
+			ErrorMessage: fmt.Sprintf("failed to parse suggestions: %v", err),
+		}, nil
+	}
+
+	count := boundedCount(input.MaxResults, DefaultSuggestions, MaxSuggestions)
+	suggestions := make([]string, 0, min(len(items), count))
+	seen := map[string]bool{}
+	for _, item := range items {
+		phrase := strings.TrimSpace(item.Phrase)
+		key := strings.ToLower(phrase)
+		if phrase == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		suggestions = append(suggestions, phrase)
+		if len(suggestions) == count {
+			break
+		}
+	}
+
+	if len(suggestions) == 0 {
+		return &SuggestResponse{
+			Message: "No suggestions found for this query.",
+		}, nil
+	}
+
+	return &SuggestResponse{
+		Message:     fmt.Sprintf("Found %d suggestions.", len(suggestions)),
+		Suggestions: suggestions,
+	}, nil
+}
+
+// Suggest is served by DuckDuckGo's autocomplete, whatever the search engine
+func (r *engineRegistry) Suggest(ctx context.Context, input *SuggestRequest) (*SuggestResponse, error) {
+	return r.duckduckgo.Suggest(ctx, input)
+}
+
+// expandedSearch searches the query together with its top suggestions on the selected
+// engines and fuses the rankings; without suggestions it is a plain search
+func (r *engineRegistry) expandedSearch(ctx context.Context, input *TextSearchRequest, names []string) (*TextSearchResponse, error) {
+	queries := []string{input.Query}
+
+	suggestResp, err := r.duckduckgo.Suggest(ctx, &SuggestRequest{Query: input.Query, Region: input.Region, MaxResults: expandQueries + 1})
+	if err == nil && suggestResp.ErrorMessage == "" {
+		for _, suggestion := range suggestResp.Suggestions {
+			if len(queries) > expandQueries {
+				break
+			}
+			if !strings.EqualFold(strings.TrimSpace(suggestion), strings.TrimSpace(input.Query)) {
+				queries = append(queries, suggestion)
+			}
+		}
+	}
+
+	responses := make([]engineResults, len(queries))
+	errs := make([]error, len(queries))
+	run := func(i int) {
+		sub := *input
+		sub.Query = queries[i]
+		resp, err := r.search(ctx, &sub, names)
+		responses[i] = engineResults{response: resp}
+		errs[i] = err
+	}
+
+	// DuckDuckGo is scraped from its HTML pages, so its searches run one after another
+	// to avoid tripping its rate limiting; API engines are searched concurrently
+	if slices.Contains(names, EngineDuckDuckGo) {
+		for i := range queries {
+			run(i)
+		}
+	} else {
+		var wg sync.WaitGroup
+		for i := range queries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run(i)
+			}()
+		}
+		wg.Wait()
+	}
+
+	// The query itself decides success; failed suggestion searches only lose their results
+	if errs[0] != nil {
+		return nil, errs[0]
+	}
+	if responses[0].response.ErrorMessage != "" || len(queries) == 1 {
+		return responses[0].response, nil
+	}
+
+	var fused []engineResults
+	for i, er := range responses {
+		if errs[i] == nil {
+			fused = append(fused, er)
+		}
+	}
+
+	results := fuseResults(fused, input.resultCount(r.maxResults, r.limit))
+	if len(results) == 0 {
+		return &TextSearchResponse{
+			Message:         "No results found for your query or its suggestions. Try using different keywords or broader search terms.",
+			ExpandedQueries: queries[1:],
+		}, nil
+	}
+
+	return &TextSearchResponse{
+		Message:         fmt.Sprintf("Found %d results successfully for the query and %d suggested queries.", len(results), len(queries)-1),
+		Results:         results,
+		ExpandedQueries: queries[1:],
+	}, nil
+}
@@ -3,37 +3,34 @@ package search
 import (
 	"context"
 	"fmt"
-	"io"
+	"math/rand/v2"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
-	"github.com/corpix/uarand"
 )
 
 func (c *client) TextSearch(ctx context.Context, input *TextSearchRequest) (*TextSearchResponse, error) {
 	// Validate input
-	if input.Query == "" {
+	if msg := validateQuery(input.Query); msg != "" {
 		return &TextSearchResponse{
-			ErrorMessage: "search query is required, please provide a query string",
+			ErrorMessage: msg,
 		}, nil
 	}
 
-	// Validate query length
-	if len(input.Query) > 500 {
-		return &TextSearchResponse{
-			ErrorMessage: "search query is too long (max 500 characters), please shorten your query",
-		}, nil
-	}
-
-	results := make([]*TextSearchResult, 0, c.maxResults)
+	maxResults := input.resultCount(c.maxResults, c.limit)
+	results := make([]*TextSearchResult, 0, maxResults)
 
+	offset := input.startOffset(maxResults)
 	header := buildTextHTMLRequestHeader()
-	reqBody := input.buildTextHTMLRequestBody(c.region)
+	reqBody := input.buildTextHTMLRequestBody(c.region, offset)
 
-	for {
+	// Pages overlap, so results are deduplicated across pages by canonical URL
+	seen := make(map[string]bool)
+
+	for page := 1; ; page++ {
 		var req *http.Request
 		req, err := http.NewRequestWithContext(ctx, http.MethodPost, searchHTMLURL, strings.NewReader(reqBody.Encode()))
 		if err != nil {
@@ -51,15 +48,26 @@ func (c *client) TextSearch(ctx context.Context, input *TextSearchRequest) (*Tex
 			}, nil
 		}
 
-		if len(resultsTmp) == 0 {
+		added := 0
+		for _, result := range resultsTmp {
+			key := normalizeURL(result.URL)
+			if seen[key] || (result.Sponsored && !input.IncludeAds) {
+				continue
+			}
+			seen[key] = true
+			result.Page = page
+			result.Position = offset + len(results) + 1
+			results = append(results, result)
+			added++
+		}
+		if added == 0 {
 			break
 		}
 
-		results = append(results, resultsTmp...)
 		reqBody = nextReqBody
 
-		if len(results) >= c.maxResults {
-			results = results[:c.maxResults]
+		if len(results) >= maxResults {
+			results = results[:maxResults]
 			break
 		}
 
@@ -67,7 +75,10 @@ func (c *client) TextSearch(ctx context.Context, input *TextSearchRequest) (*Tex
 			break
 		}
 
-		<-time.After(3 * time.Second) // request too fast may cause 202
+		// Requesting pages too fast causes 202 responses; jitter avoids a detectable rhythm
+		if err := sleepContext(ctx, c.pageDelay+rand.N(c.pageDelay/2+1)); err != nil {
+			break
+		}
 	}
 
 	if len(results) == 0 {
@@ -92,11 +103,10 @@ func buildTextHTMLRequestHeader() http.Header {
 		"Sec-Fetch-Mode": {"navigate"},
 		"Sec-Fetch-User": {"?1"},
 		"Content-Type":   {"application/x-www-form-urlencoded"},
-		"User-Agent":     {uarand.GetRandom()},
 	}
 }
 
-func (t *TextSearchRequest) buildTextHTMLRequestBody(region Region) url.Values {
+func (t *TextSearchRequest) buildTextHTMLRequestBody(region Region, offset int) url.Values {
 	// q (str): Search query string
 	// s (int): Search offset for pagination
 	// nextParams (str): Continuation parameters from previous page response, typically empty
@@ -107,18 +117,31 @@ func (t *TextSearchRequest) buildTextHTMLRequestBody(region Region) url.Values {
 	// vqd (str): Validation query digest
 	// kl (str): Keyboard language/region code (e.g., 'en-us')
 	// df (str): Time filter, maps to values like 'd' (day), 'w' (week), 'm' (month), 'y' (year)
+	// kp (int): Safe search, 1 (strict), -1 (moderate), -2 (off)
 
 	body := url.Values{
-		"q":  {t.Query},
+		"q":  {t.searchQuery()},
 		"b":  {""},
 		"kl": {""},
 		"df": {string(TimeRangeAny)},
 	}
 
-	if region != RegionWT {
+	if region = t.searchRegion(region); region != RegionWT {
 		body["kl"] = []string{string(region)}
 	}
 
+	switch t.SafeSearch {
+	case SafeSearchStrict:
+		body["kp"] = []string{"1"}
+	case SafeSearchOff:
+		body["kp"] = []string{"-2"}
+	}
+
+	if offset > 0 {
+		body["s"] = []string{strconv.Itoa(offset)}
+		body["dc"] = []string{strconv.Itoa(offset + 1)}
+	}
+
 	switch t.TimeRange {
 	case TimeRangeDay, TimeRangeWeek, TimeRangeMonth, TimeRangeYear:
 		body["df"] = []string{string(t.TimeRange)}
@@ -127,28 +150,10 @@ func (t *TextSearchRequest) buildTextHTMLRequestBody(region Region) url.Values {
 	return body
 }
 
-func (c *client) doTextHTMLSearch(_ context.Context, req *http.Request) (results []*TextSearchResult, nextReqBody url.Values, err error) {
-	resp, err := c.httpCli.Do(req)
+func (c *client) doTextHTMLSearch(ctx context.Context, req *http.Request) (results []*TextSearchResult, nextReqBody url.Values, err error) {
+	respBody, err := c.send(ctx, req)
 	if err != nil {
-		return nil, nil, fmt.Errorf("network error, please check your connection: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, nil, fmt.Errorf("rate limit exceeded (status 429), please wait a moment and try again")
-	}
-
-	if resp.StatusCode == http.StatusForbidden {
-		return nil, nil, fmt.Errorf("access forbidden (status 403), the search service may be blocking requests")
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, nil, fmt.Errorf("search service returned status %d, please try again later", resp.StatusCode)
-	}
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read search results: %w", err)
+		return nil, nil, err
 	}
 
 	results, nextReqBody, err = parseTextHTMLSearchResponse(string(respBody))
@@ -170,23 +175,23 @@ func parseTextHTMLSearchResponse(respBody string) (results []*TextSearchResult,
 		elements = append(elements, s)
 	})
 
-	hrefCache := make(map[string]bool)
+	seen := make(map[string]bool)
 	results = make([]*TextSearchResult, 0, len(elements))
 
-	doc.Find("div#links div.web-result").Each(func(i int, s *goquery.Selection) {
+	doc.Find("div#links div.web-result, div#links div.result--ad").Each(func(i int, s *goquery.Selection) {
 		title := s.Find("h2.result__title > a").First()
 		if title.Length() == 0 {
 			return
 		}
 
 		href, _ := title.Attr("href")
-		if href == "" {
+		href = unwrapRedirect(href)
+		if href == "" || strings.HasPrefix(href, "http://www.google.com/search?q=") {
 			return
 		}
 
-		if _, ok := hrefCache[href]; ok ||
-			strings.HasPrefix(href, "http://www.google.com/search?q=") ||
-			strings.HasPrefix(href, "https://duckduckgo.com/y.js?ad_domain") {
+		key := normalizeURL(href)
+		if seen[key] {
 			return
 		}
 
@@ -195,24 +200,49 @@ func parseTextHTMLSearchResponse(respBody string) (results []*TextSearchResult,
 			return
 		}
 
-		hrefCache[href] = true
+		seen[key] = true
+
+		result := &TextSearchResult{
+			Title:      strings.TrimSpace(title.Text()),
+			URL:        href,
+			Summary:    strings.TrimSpace(summary.Text()),
+			DisplayURL: strings.TrimSpace(s.Find("a.result__url").First().Text()),
+			Domain:     resultDomain(href),
+			Sponsored:  s.HasClass("result--ad") || isAdURL(href),
+		}
 
-		results = append(results, &TextSearchResult{
-			Title:   strings.TrimSpace(title.Text()),
-			URL:     href,
-			Summary: strings.TrimSpace(summary.Text()),
+		// The date follows the display URL in an unclassed span
+		s.Find("div.result__extras__url > span").EachWithBreak(func(_ int, span *goquery.Selection) bool {
+			result.Date = parseResultDate(span.Text())
+			return result.Date == ""
 		})
+
+		if icon, ok := s.Find("img.result__icon__img").First().Attr("src"); ok {
+			result.Favicon = absoluteURL(icon)
+		}
+
+		if result.Sponsored {
+			// Ad links go through DuckDuckGo's click tracker; the advertiser is named in ad_domain
+			if u, err := url.Parse(href); err == nil && u.Query().Get("ad_domain") != "" {
+				result.Domain = strings.TrimPrefix(strings.ToLower(u.Query().Get("ad_domain")), "www.")
+			}
+		}
+
+		results = append(results, result)
 	})
 
-	navLinks := doc.Find("div.nav-link")
-	if navLinks.Length() == 0 {
+	// The last page only links back to the previous one, which must not be followed
+	nextForm := doc.Find("div.nav-link form").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		value, _ := s.Find("input[type=submit]").Attr("value")
+		return strings.EqualFold(strings.TrimSpace(value), "next")
+	}).Last()
+	if nextForm.Length() == 0 {
 		return results, nil, nil
 	}
 
 	nextReqBody = url.Values{}
 
-	lastForm := doc.Find("form").Last()
-	lastForm.Find("input[type=hidden]").Each(func(_ int, s *goquery.Selection) {
+	nextForm.Find("input[type=hidden]").Each(func(_ int, s *goquery.Selection) {
 		name, nameExist := s.Attr("name")
 		value, valueExist := s.Attr("value")
 		if nameExist && valueExist {
@@ -222,3 +252,17 @@ func parseTextHTMLSearchResponse(respBody string) (results []*TextSearchResult,
 
 	return results, nextReqBody, nil
 }
+
+// statusError turns a non-200 DuckDuckGo status into an error that guides the model
+func statusError(statusCode int) error {
+	switch statusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("rate limit exceeded (status 429), please wait a moment and try again")
+	case http.StatusForbidden:
+		return fmt.Errorf("access forbidden (status 403), the search service may be blocking requests")
+	default:
+		return fmt.Errorf("search service returned status %d, please try again later", statusCode)
+	}
+}
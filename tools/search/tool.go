@@ -3,12 +3,14 @@ package search
 import (
 	"context"
 	"fkmcps/structs"
+	"fkmcps/tools/fetch"
 	"log"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-const searchToolDescription = `Search for information using DuckDuckGo search engine.
+const searchToolDescription = `Search for information on the web (DuckDuckGo by default).
 
 ## When to Use
 Use this tool when you need to:
@@ -18,17 +20,133 @@ Use this tool when you need to:
 
 ## Usage Tips
 - Provide clear, specific search keywords for better results
-- You can use the time_range parameter to limit search results to a specific time period`
+- You can use the time_range parameter to limit search results to a specific time period
+- Use region (e.g. us-en, de-de) for local results and safe_search (strict, moderate, off) to filter adult content
+- Use site / exclude_site to restrict results to or remove results from specific domains
+- Set max_results for more results, and page or offset (see next_offset in the response) to page through them
+- Repeated searches within a few minutes are answered from a cache (cached: true)
+- Use the engine parameter to pick a search engine, or "all" to merge and deduplicate results from every engine
+- Results carry their domain, position and, when the engine shows one, the published date; ads are dropped unless include_ads is set
+- Set expand to also search the top autocomplete suggestions for the query and merge their results (see expanded_queries)
+- Set include_answer for factual queries to also get the instant answer (abstract, definition, infobox)`
+
+const newsSearchToolDescription = `Search recent news articles using DuckDuckGo News.
+
+## When to Use
+Use this tool when you need to:
+- Find current events and breaking news
+- Follow coverage of a topic over the past day, week or month
+
+## Usage Tips
+- Results include the source outlet and publication date
+- Use time_range (d, w, m) to keep results fresh and region for local news`
+
+const imageSearchToolDescription = `Search images using DuckDuckGo Images.
+
+## When to Use
+Use this tool when you need to:
+- Find pictures, diagrams, logos or illustrations of a subject
+- Get image URLs with dimensions and the page each image appears on
+
+## Usage Tips
+- Filter with size, color, type (photo, clipart, gif, transparent, line), layout and license
+- Use license to find images that may be reused`
+
+const videoSearchToolDescription = `Search videos using DuckDuckGo Videos.
+
+## When to Use
+Use this tool when you need to:
+- Find tutorials, talks, reviews or clips about a topic
+
+## Usage Tips
+- Results include duration, publisher, upload date, view count and a thumbnail
+- Filter with resolution (high, standard), duration (short, medium, long) and license`
+
+const suggestToolDescription = `Get query suggestions (autocomplete) for a query or prefix from DuckDuckGo.
+
+## When to Use
+Use this tool when you need to:
+- Refine a vague or misspelled query before searching
+- Discover how people commonly phrase a search on a topic
+
+## Usage Tips
+- Suggestions are ordered from most to least popular
+- Use region (e.g. us-en, de-de) for suggestions in another market or language`
+
+const instantAnswerToolDescription = `Get a structured instant answer for a topic or factual question from the DuckDuckGo Instant Answer API.
+
+## When to Use
+Use this tool when you need to:
+- Get a short summary of a person, place, organization or concept
+- Look up definitions, calculations, conversions or key facts without reading web pages
+
+## Features
+- Returns the abstract with its source, direct answers, definitions, infobox facts and related topics
+- Ambiguous queries return their possible meanings as related topics
+
+## Usage Tips
+- Use short entity names (e.g. "golang", "Ada Lovelace") rather than full sentences
+- When no answer is found, use search instead`
+
+const searchAndReadToolDescription = `Search the web and read the top results in one call.
+
+## When to Use
+Use this tool when you need to:
+- Answer a question from the content of several web pages, not just search snippets
+- Replace a search call followed by several fetch calls
+
+## Features
+- Fetches the top results concurrently and returns each page's main content as Markdown
+- Splits the max_chars budget across pages so short pages are returned whole
+- Reports pages that fail to load on that result (error_message) instead of failing the call
+
+## Usage Tips
+- Keep max_results small (default 3) for focused answers; raise max_chars when pages are long
+- Use site to read only from trusted domains`
 
 func GetTools(s *mcp.Server) {
-	search, err := NewDuckDuckGoSearch(context.Background())
+	registry, err := newEngineRegistry(context.Background(), options)
 	if err != nil {
 		log.Printf("failed to create search tool: %v", err)
 		return
 	}
 
+	search := newCachedRegistry(registry, options)
+
 	mcp.AddTool(s, &mcp.Tool{
 		Name:        "search",
-		Description: searchToolDescription,
+		Description: searchToolDescription + "\n\nAvailable engines: " + strings.Join(registry.names, ", ") + " (default: " + registry.defaultEngine + ")",
 	}, structs.WarpToolFunc(search.TextSearch))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "search_news",
+		Description: newsSearchToolDescription,
+	}, structs.WarpToolFunc(search.NewsSearch))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "search_images",
+		Description: imageSearchToolDescription,
+	}, structs.WarpToolFunc(search.ImageSearch))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "search_videos",
+		Description: videoSearchToolDescription,
+	}, structs.WarpToolFunc(search.VideoSearch))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "search_suggest",
+		Description: suggestToolDescription,
+	}, structs.WarpToolFunc(search.Suggest))
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "instant_answer",
+		Description: instantAnswerToolDescription,
+	}, structs.WarpToolFunc(search.InstantAnswer))
+
+	research := &researcher{search: search, fetch: fetch.Fetch}
+
+	mcp.AddTool(s, &mcp.Tool{
+		Name:        "search_and_read",
+		Description: searchAndReadToolDescription,
+	}, structs.WarpToolFunc(research.SearchAndRead))
 }